@@ -3,8 +3,8 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
-	"gymshark-interview/database/migrations"
+	"gymshark-interview/database"
+	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/server"
 	"gymshark-interview/internal/service"
 	"gymshark-interview/internal/storage"
@@ -14,38 +14,82 @@ import (
 	"os/signal"
 	"strconv"
 	"time"
-
-	_ "github.com/glebarez/go-sqlite"
-	"github.com/jmoiron/sqlx"
-	migrate "github.com/rubenv/sql-migrate"
 )
 
 const (
-	defaultHTTPServerPort  = 8080
-	shutdownGracefulPeriod = 2 * time.Second
+	defaultHTTPServerPort    = 8080
+	defaultDatabaseDriver    = "sqlite"
+	memoryDatabaseDriver     = "memory"
+	defaultDatabaseDSN       = "gymshark.db"
+	defaultIdempotencyWindow = 24 * time.Hour
+	defaultCatalogCacheTTL   = time.Minute
+	catalogCacheReportPeriod = time.Minute
+	shutdownGracefulPeriod   = 2 * time.Second
 )
 
 func main() {
-	// start in-memory sqlite with empty db
-	db, err := sqlx.Open("sqlite", ":memory:")
-	if err != nil {
-		log.Fatal(err)
+	// open the database, an sqlite file by default, and run migrations + seeds
+	dbConfig := database.Config{
+		Driver: defaultDatabaseDriver,
+		DSN:    defaultDatabaseDSN,
+	}
+	if driverFromEnv := os.Getenv("DATABASE_DRIVER"); len(driverFromEnv) > 0 {
+		dbConfig.Driver = driverFromEnv
+	}
+	if dsnFromEnv := os.Getenv("DATABASE_DSN"); len(dsnFromEnv) > 0 {
+		dbConfig.DSN = dsnFromEnv
+	}
+	if seedFromEnv := os.Getenv("DATABASE_SEED"); len(seedFromEnv) > 0 {
+		seed, err := strconv.ParseBool(seedFromEnv)
+		if err != nil {
+			log.Fatal("DATABASE_SEED value is not valid: ", err)
+		}
+		dbConfig.Seed = seed
+	}
+	if connsFromEnv := os.Getenv("DATABASE_MAX_CONNS"); len(connsFromEnv) > 0 {
+		conns, err := strconv.Atoi(connsFromEnv)
+		if err != nil || conns < 1 {
+			log.Fatal("DATABASE_MAX_CONNS value is not valid: ", connsFromEnv)
+		}
+		dbConfig.MaxOpenConns = conns
 	}
-	defer db.Close()
 
-	// run migrations + seeds
-	mg := migrations.GetMigrationSource()
-	migrate.SetTable("migrations")
-	_, err = migrate.Exec(db.DB, "sqlite3", mg, migrate.Up)
-	if err != nil {
-		log.Fatal(fmt.Errorf("migrations failed: %w", err))
+	// initialise dependencies, in memory without a database for throwaway deployments
+	var repo storage.Backend
+	if dbConfig.Driver == memoryDatabaseDriver {
+		memory := storage.NewMemory()
+		if dbConfig.Seed {
+			seedMemory(memory)
+		}
+		repo = memory
+	} else {
+		db, err := database.Open(dbConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+		repo = storage.New(db)
 	}
 
-	// initialise dependencies
-	repo := storage.New(db)
+	// cache the catalog in front of the database, a TTL of 0 reads it every time
+	cacheTTL := defaultCatalogCacheTTL
+	if ttlFromEnv := os.Getenv("CATALOG_CACHE_TTL"); len(ttlFromEnv) > 0 {
+		ttl, err := time.ParseDuration(ttlFromEnv)
+		if err != nil || ttl < 0 {
+			log.Fatal("CATALOG_CACHE_TTL value is not a valid duration: ", ttlFromEnv)
+		}
+		cacheTTL = ttl
+	}
+	var cache *storage.Cache
+	if cacheTTL > 0 && dbConfig.Driver != memoryDatabaseDriver {
+		cache = storage.NewCache(repo, cacheTTL)
+		repo = cache
+	}
 	productService := service.NewProductService(repo)
 	packageService := service.NewPackageService(repo)
+	orderService := service.NewOrderService(repo)
 
+	var err error
 	port := defaultHTTPServerPort
 	portFromEnv := os.Getenv("SERVER_PORT")
 	if len(portFromEnv) > 0 {
@@ -55,10 +99,23 @@ func main() {
 		}
 	}
 
-	server := server.New(port, productService, packageService)
+	idempotencyWindow := defaultIdempotencyWindow
+	windowFromEnv := os.Getenv("IDEMPOTENCY_WINDOW")
+	if len(windowFromEnv) > 0 {
+		idempotencyWindow, err = time.ParseDuration(windowFromEnv)
+		if err != nil || idempotencyWindow <= 0 {
+			log.Fatal("IDEMPOTENCY_WINDOW value is not a valid duration: ", windowFromEnv)
+		}
+	}
+	idempotencyService := service.NewIdempotencyService(repo, idempotencyWindow)
+
+	server := server.New(port, productService, packageService, orderService, idempotencyService)
 
 	// start server
 	go server.Start()
+	if cache != nil {
+		go reportCache(cache)
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
@@ -73,4 +130,29 @@ func main() {
 		log.Fatalf("server shutdown error: %v", err)
 	}
 	log.Println("server shutdown gracefully")
+	if cache != nil {
+		logCacheStats(cache)
+	}
+}
+
+// reportCache sweeps the expired products out of the catalog cache and logs its stats every period, so products
+// that aren't read again don't stay in memory.
+func reportCache(cache *storage.Cache) {
+	for range time.Tick(catalogCacheReportPeriod) {
+		cache.Sweep()
+		logCacheStats(cache)
+	}
+}
+
+func logCacheStats(cache *storage.Cache) {
+	stats := cache.Stats()
+	log.Printf("catalog cache: %d hits, %d misses, %d products", stats.Hits, stats.Misses, cache.Len())
+}
+
+// seedMemory creates the example product of the database seeds, with an id of its own.
+func seedMemory(memory *storage.Memory) {
+	product := model.Product{Name: "Product ABC", PackageSizes: []int{250, 500, 1000, 2000, 5000}}
+	if _, err := memory.CreateProduct(context.Background(), product); err != nil {
+		log.Fatal("seeds failed: ", err)
+	}
 }
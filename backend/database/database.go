@@ -0,0 +1,130 @@
+// Package database opens the database behind the storage, migrated to the latest schema
+package database
+
+import (
+	"errors"
+	"fmt"
+	"gymshark-interview/database/migrations"
+	"gymshark-interview/database/migrations/postgres"
+	"gymshark-interview/database/seeds"
+	"log"
+	"net/url"
+	"runtime"
+	"strings"
+
+	_ "github.com/glebarez/go-sqlite"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var ErrUnsupportedDriver = errors.New("unsupported database driver")
+
+// sqliteBusyTimeout is how long, in milliseconds, an sqlite change waits for the write lock before failing.
+const sqliteBusyTimeout = 5000
+
+// Config selects the database to open.
+type Config struct {
+	// Driver is "sqlite" or "postgres".
+	Driver string
+	// DSN is handed to the driver as is, eg. the path of the sqlite file, ":memory:" or a postgres:// URL.
+	DSN string
+	// Seed loads the example data. Each seed is only loaded once, even when the database is opened again.
+	Seed bool
+	// MaxOpenConns sizes the connection pool, twice the CPUs when 0. In-memory sqlite databases always use a single
+	// connection, as every connection would open a database of its own.
+	MaxOpenConns int
+}
+
+// dialect is how a supported database is opened and migrated.
+type dialect struct {
+	// driver is the name of the database/sql driver, which sqlx also rebinds the queries of the storage by
+	driver string
+	// migrate is the name sql-migrate knows the dialect by
+	migrate    string
+	migrations migrate.MigrationSource
+}
+
+var dialects = map[string]dialect{
+	"sqlite":   {driver: "sqlite", migrate: "sqlite3", migrations: migrations.GetMigrationSource()},
+	"postgres": {driver: "pgx", migrate: "postgres", migrations: postgres.GetMigrationSource()},
+}
+
+var (
+	migrationSet = migrate.MigrationSet{TableName: "migrations"}
+	seedSet      = migrate.MigrationSet{TableName: "seeds"}
+)
+
+// Open connects to the database and applies the migrations it's missing, so it can be opened again with the data
+// of previous runs.
+func Open(cfg Config) (*sqlx.DB, error) {
+	dialect, ok := dialects[cfg.Driver]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedDriver, cfg.Driver)
+	}
+
+	dsn, maxOpenConns := cfg.DSN, cfg.MaxOpenConns
+	if maxOpenConns <= 0 {
+		maxOpenConns = 2 * runtime.NumCPU()
+	}
+	if dialect.driver == "sqlite" {
+		if isSQLiteInMemory(dsn) {
+			maxOpenConns = 1
+		}
+		dsn = sqliteDSN(dsn)
+	}
+
+	db, err := sqlx.Open(dialect.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	applied, err := migrationSet.Exec(db.DB, dialect.migrate, dialect.migrations, migrate.Up)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrations failed: %w", err)
+	}
+	log.Printf("applied %d migrations", applied)
+
+	if cfg.Seed {
+		applied, err = seedSet.Exec(db.DB, dialect.migrate, seeds.GetSeedSource(), migrate.Up)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("seeds failed: %w", err)
+		}
+		log.Printf("applied %d seeds", applied)
+	}
+	return db, nil
+}
+
+// sqliteDSN has every connection to an sqlite file use WAL, so reads carry on while a change is written, and begin
+// transactions IMMEDIATE, so changes queue for the write lock for up to sqliteBusyTimeout rather than failing to
+// upgrade a read lock. Read-only transactions are still deferred. Foreign keys are enforced, so deleting a product cascades
+// to its package sizes and order history like in Postgres. Parameters already in the DSN take precedence.
+func sqliteDSN(dsn string) string {
+	params := url.Values{}
+	if !strings.Contains(dsn, "foreign_keys") {
+		params.Add("_pragma", "foreign_keys(1)")
+	}
+	if !isSQLiteInMemory(dsn) && !strings.Contains(dsn, "journal_mode") {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+	if !strings.Contains(dsn, "busy_timeout") {
+		params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout))
+	}
+	params.Set("_txlock", "immediate")
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + params.Encode()
+	}
+	return dsn + "?" + params.Encode()
+}
+
+func isSQLiteInMemory(dsn string) bool {
+	return strings.HasPrefix(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE products ADD COLUMN solver TEXT NOT NULL DEFAULT '';
+
+-- +migrate Down
+
+ALTER TABLE products DROP COLUMN solver;
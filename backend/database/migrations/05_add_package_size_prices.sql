@@ -0,0 +1,9 @@
+-- +migrate Up
+
+ALTER TABLE package_sizes ADD COLUMN unit_cost INTEGER;
+ALTER TABLE package_sizes ADD COLUMN currency TEXT;
+
+-- +migrate Down
+
+ALTER TABLE package_sizes DROP COLUMN currency;
+ALTER TABLE package_sizes DROP COLUMN unit_cost;
@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE package_sizes ADD COLUMN stock INTEGER;
+
+-- +migrate Down
+
+ALTER TABLE package_sizes DROP COLUMN stock;
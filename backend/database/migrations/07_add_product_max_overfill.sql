@@ -0,0 +1,9 @@
+-- +migrate Up
+
+ALTER TABLE products ADD COLUMN max_overfill INTEGER;
+ALTER TABLE products ADD COLUMN max_overfill_percent INTEGER;
+
+-- +migrate Down
+
+ALTER TABLE products DROP COLUMN max_overfill_percent;
+ALTER TABLE products DROP COLUMN max_overfill;
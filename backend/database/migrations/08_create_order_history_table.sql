@@ -0,0 +1,14 @@
+-- +migrate Up
+
+CREATE TABLE order_history (
+    id INTEGER PRIMARY KEY,
+    product_id TEXT NOT NULL,
+    units INTEGER NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX order_history_product_id ON order_history (product_id);
+
+-- +migrate Down
+
+DROP TABLE order_history;
@@ -0,0 +1,38 @@
+-- +migrate Up
+
+-- orders keep a snapshot of their calculation: later catalog edits, or deleting the product, don't change them
+CREATE TABLE orders (
+    id TEXT PRIMARY KEY,
+    product_id TEXT NOT NULL,
+    units INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    solver TEXT NOT NULL,
+    objective TEXT NOT NULL,
+    total_cost INTEGER,
+    currency TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX orders_product_id ON orders (product_id);
+
+CREATE TABLE order_package_units (
+    order_id TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    PRIMARY KEY (order_id, size)
+);
+
+CREATE TABLE order_package_sizes (
+    order_id TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    PRIMARY KEY (order_id, size)
+);
+
+-- +migrate Down
+
+DROP TABLE order_package_sizes;
+DROP TABLE order_package_units;
+DROP TABLE orders;
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- responses of mutating requests, replayed when a request is retried with the same Idempotency-Key
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status INTEGER,
+    content_type TEXT,
+    body BLOB,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idempotency_keys_created_at ON idempotency_keys (created_at);
+
+-- +migrate Down
+
+DROP TABLE idempotency_keys;
@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE idempotency_keys ADD COLUMN location TEXT;
+
+-- +migrate Down
+
+ALTER TABLE idempotency_keys DROP COLUMN location;
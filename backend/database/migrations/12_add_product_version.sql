@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- bumped by every change to a product or its package sizes, see the ETag of the product endpoints
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +migrate Down
+
+ALTER TABLE products DROP COLUMN version;
@@ -0,0 +1,7 @@
+-- +migrate Up
+
+ALTER TABLE idempotency_keys ADD COLUMN etag TEXT;
+
+-- +migrate Down
+
+ALTER TABLE idempotency_keys DROP COLUMN etag;
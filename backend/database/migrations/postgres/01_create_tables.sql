@@ -0,0 +1,87 @@
+-- +migrate Up
+
+-- the schema the sqlite migrations reach with 12_add_product_version.sql. Integers are 64 bits like in sqlite,
+-- and ids and names sort bytewise like sqlite's BINARY collation, which the product pagination relies on
+CREATE TABLE products (
+    id TEXT COLLATE "C" PRIMARY KEY,
+    name TEXT COLLATE "C" UNIQUE NOT NULL,
+    solver TEXT NOT NULL DEFAULT '',
+    max_overfill BIGINT,
+    max_overfill_percent BIGINT,
+    version BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE package_sizes (
+    id TEXT PRIMARY KEY,
+    product_id TEXT COLLATE "C" NOT NULL,
+    size BIGINT NOT NULL,
+    unit_cost BIGINT,
+    currency TEXT,
+    stock BIGINT,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    UNIQUE (product_id, size)
+);
+
+CREATE TABLE order_history (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    product_id TEXT COLLATE "C" NOT NULL,
+    units BIGINT NOT NULL,
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+);
+
+CREATE INDEX order_history_product_id ON order_history (product_id);
+
+-- orders keep a snapshot of their calculation: later catalog edits, or deleting the product, don't change them
+CREATE TABLE orders (
+    id TEXT COLLATE "C" PRIMARY KEY,
+    product_id TEXT NOT NULL,
+    units BIGINT NOT NULL,
+    state TEXT NOT NULL,
+    solver TEXT NOT NULL,
+    objective TEXT NOT NULL,
+    total_cost BIGINT,
+    currency TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX orders_product_id ON orders (product_id);
+
+CREATE TABLE order_package_units (
+    order_id TEXT COLLATE "C" NOT NULL,
+    size BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    PRIMARY KEY (order_id, size)
+);
+
+CREATE TABLE order_package_sizes (
+    order_id TEXT COLLATE "C" NOT NULL,
+    size BIGINT NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    PRIMARY KEY (order_id, size)
+);
+
+-- responses of mutating requests, replayed when a request is retried with the same Idempotency-Key
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status INTEGER,
+    content_type TEXT,
+    location TEXT,
+    etag TEXT,
+    body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idempotency_keys_created_at ON idempotency_keys (created_at);
+
+-- +migrate Down
+
+DROP TABLE idempotency_keys;
+DROP TABLE order_package_sizes;
+DROP TABLE order_package_units;
+DROP TABLE orders;
+DROP TABLE order_history;
+DROP TABLE package_sizes;
+DROP TABLE products;
@@ -0,0 +1,22 @@
+// Package postgres contains the *.sql files for the migrations of Postgres databases
+// It also exports an embed.FS to allow iteration through the migration files
+package postgres
+
+import (
+	"embed"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// FS contains the migration files
+//
+//go:embed *.sql
+var FS embed.FS
+
+// GetMigrationSource returns the migration source.
+func GetMigrationSource() *migrate.EmbedFileSystemMigrationSource {
+	return &migrate.EmbedFileSystemMigrationSource{
+		FileSystem: FS,
+		Root:       ".",
+	}
+}
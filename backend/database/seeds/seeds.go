@@ -0,0 +1,22 @@
+// Package seeds contains the *.sql files with example data, only loaded on request
+// It also exports an embed.FS to allow iteration through the seed files
+package seeds
+
+import (
+	"embed"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// FS contains the seed files
+//
+//go:embed *.sql
+var FS embed.FS
+
+// GetSeedSource returns the seed source.
+func GetSeedSource() *migrate.EmbedFileSystemMigrationSource {
+	return &migrate.EmbedFileSystemMigrationSource{
+		FileSystem: FS,
+		Root:       ".",
+	}
+}
@@ -1,16 +1,224 @@
 package model
 
+import "time"
+
 type Product struct {
-	ID           string
-	Name         string
-	PackageSizes []int
+	ID            string
+	Name          string
+	PackageSizes  []int
+	PackagePrices []PackagePrice
+	PackageStock  []PackageStock
+	Solver        string
+	MaxOverfill   OverfillLimit
+	// Version is bumped by every change to the product or its package sizes.
+	Version int
+}
+
+// OverfillLimit caps the items shipped over an order. Nil fields mean no cap, when both are set the smallest
+// applies. A cap of 0 items only ships exact orders.
+type OverfillLimit struct {
+	Items *int
+	// Percent is a share of the ordered units, rounded down.
+	Percent *int
+}
+
+// PackagePrice is the unit cost of a package size, in minor units of the currency (eg. pence).
+type PackagePrice struct {
+	Size     int
+	UnitCost int64
+	Currency string
+}
+
+// PackageStock is the amount of packs of a package size available in the warehouse. Package sizes without a
+// PackageStock aren't tracked and have unlimited supply.
+type PackageStock struct {
+	Size      int
+	Available int
 }
 
 type Package struct {
 	PackageUnits []PackageUnit
+	Solver       string
+	Objective    string
+	// TotalCost is only set when every package unit has a price in the same Currency.
+	TotalCost *int64
+	Currency  string
+	// Alternatives are the best packings when requested, ranked by the Objective. Only their PackageUnits and cost
+	// are set.
+	Alternatives []Package
+	// Explanation tells why the PackageUnits were chosen, when requested.
+	Explanation *Explanation
+}
+
+// Explanation lists the best packings the chosen one was preferred to.
+type Explanation struct {
+	Rejected []RejectedPackage
+	// ResidueShortcut is whether the dp solver topped up the best packing of a remainder class with packs of a
+	// single size, instead of searching a table bounded by the order.
+	ResidueShortcut bool
+}
+
+// RejectedPackage is a packing that lost to the chosen one because of Rule.
+type RejectedPackage struct {
+	Package
+	Rule string
+	// PartialItems are the items of a partial pack that would ship exactly the order, only for the whole packs rule.
+	PartialItems int
 }
 
 type PackageUnit struct {
 	Amount int
 	Size   int
 }
+
+type BasketLine struct {
+	ProductID string
+	Units     int
+}
+
+// BasketLineResult is the outcome of a line of a basket: its Package, or the Err that prevented calculating it.
+type BasketLineResult struct {
+	BasketLine
+	Package *Package
+	Err     error
+}
+
+// Basket totals the lines that could be calculated.
+type Basket struct {
+	Lines       []BasketLineResult
+	Items       int
+	Packs       int
+	Overfill    int
+	FailedLines int
+}
+
+// BatchResult is the outcome of a quantity of a batch: its Package, or the Err that prevented calculating it.
+type BatchResult struct {
+	Units   int
+	Package *Package
+	Err     error
+}
+
+// PackSetAnalysis describes how well a set of package sizes fulfils orders.
+type PackSetAnalysis struct {
+	PackageSizes          []int
+	GreatestCommonDivisor int
+	// LeastCommonMultiple is nil when it doesn't fit in an int.
+	LeastCommonMultiple *int
+	// FrobeniusNumber is the largest multiple of the divisor that can't be shipped exactly, nil when there isn't one.
+	FrobeniusNumber *int
+	// From and To bound the quantities the overfill is measured over.
+	From, To        int
+	WorstOverfill   int
+	WorstUnits      int // smallest quantity with the worst overfill
+	AverageOverfill float64
+	RedundantSizes  []RedundantPackageSize
+}
+
+// RedundantPackageSize is a package size that changes neither the items nor the packs shipped over the analysed
+// quantities.
+type RedundantPackageSize struct {
+	Size int
+	// ReplacedBy is empty when the other sizes can't ship the size exactly.
+	ReplacedBy []PackageUnit
+}
+
+// Simulation compares the packings of historical orders with the current and a candidate pack set.
+type Simulation struct {
+	Orders    int
+	Current   SimulationTotals
+	Candidate SimulationTotals
+	// Changed lists the distinct quantities whose packing changes, ascending.
+	Changed []SimulatedQuantity
+}
+
+// SimulationTotals totals the orders a pack set could calculate.
+type SimulationTotals struct {
+	PackageSizes []int
+	Items        int
+	Packs        int
+	Overfill     int
+	FailedOrders int
+}
+
+// SimulatedQuantity is a quantity ordered Orders times, with its result for both pack sets.
+type SimulatedQuantity struct {
+	Units     int
+	Orders    int
+	Current   BatchResult
+	Candidate BatchResult
+}
+
+// Recommendation is the best set of package sizes found for the orders of a product, next to its current sizes.
+type Recommendation struct {
+	Orders      int
+	Recommended SimulationTotals
+	Current     SimulationTotals
+	// Evaluated is the amount of candidate sets the search scored.
+	Evaluated int
+}
+
+// Order is a calculation recorded for a product. Package and PackageSizes are snapshots taken when the order was
+// quoted, so later changes to the product don't rewrite it.
+type Order struct {
+	ID           string
+	ProductID    string
+	Units        int
+	State        string
+	Package      Package
+	PackageSizes []int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// OrderFilter narrows a list of orders, empty fields match any order.
+type OrderFilter struct {
+	ProductID string
+	State     string
+}
+
+// IdempotentResponse is the response to a request sent with an idempotency key, replayed when the request is
+// retried. A Status of 0 means the request is still being handled.
+type IdempotentResponse struct {
+	Key string
+	// Fingerprint identifies the request, a retry must have the same one.
+	Fingerprint string
+	Status      int
+	ContentType string
+	// Location is the header of the resource the request created, if any.
+	Location string
+	// ETag is the header of the resource the request changed, if any.
+	ETag      string
+	Body      []byte
+	CreatedAt time.Time
+}
+
+// Sort keys of a product listing.
+const (
+	ProductSortName = "name"
+	// ProductSortCreated sorts by ID, product IDs are time-ordered UUIDv7s.
+	ProductSortCreated = "created_at"
+)
+
+// ProductQuery selects a page of products.
+type ProductQuery struct {
+	SortBy     string
+	Descending bool
+	// NamePrefix keeps the products whose name starts with it, ignoring the case of ASCII letters.
+	NamePrefix string
+	// After is the last product of the previous page, nil for the first page.
+	After *ProductCursor
+	Limit int
+}
+
+// ProductCursor is the position of a product in a listing.
+type ProductCursor struct {
+	Name string
+	ID   string
+}
+
+// ProductPage is a page of a product listing. NextCursor is empty on the last page.
+type ProductPage struct {
+	Products   []Product
+	NextCursor string
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+func (s *Server) AnalysePackSet(ctx context.Context, req *AnalysePackSetRequest) (*AnalysePackSetResponse, error) {
+	analysis, err := s.packagesService.AnalysePackSet(ctx, req.ProductID, req.PackageSizes, req.From, req.To)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrProductWithoutPackages) {
+			return nil, huma.Error400BadRequest("product has no available package sizes")
+		} else if errors.Is(err, service.ErrInvalidPackageSizes) {
+			return nil, huma.Error400BadRequest("package sizes must be positive")
+		} else if errors.Is(err, service.ErrInvalidAnalysisRange) {
+			return nil, huma.Error400BadRequest("invalid analysis range")
+		} else if errors.Is(err, service.ErrCalculationOverflow) {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		} else if errors.Is(err, service.ErrCalculationLimit) {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
+		return nil, err
+	}
+	return &AnalysePackSetResponse{Body: convertAnalysis(analysis)}, nil
+}
+
+func convertAnalysis(analysis *model.PackSetAnalysis) AnalysePackSetResponseBody {
+	res := AnalysePackSetResponseBody{
+		PackageSizes:          analysis.PackageSizes,
+		GreatestCommonDivisor: analysis.GreatestCommonDivisor,
+		LeastCommonMultiple:   analysis.LeastCommonMultiple,
+		FrobeniusNumber:       analysis.FrobeniusNumber,
+		From:                  analysis.From,
+		To:                    analysis.To,
+		WorstOverfill:         analysis.WorstOverfill,
+		WorstUnits:            analysis.WorstUnits,
+		AverageOverfill:       analysis.AverageOverfill,
+		RedundantSizes:        make([]RedundantPackageSizeResponseBody, len(analysis.RedundantSizes)),
+	}
+	for i, redundant := range analysis.RedundantSizes {
+		res.RedundantSizes[i] = RedundantPackageSizeResponseBody{
+			Size:       redundant.Size,
+			ReplacedBy: convertPackages(model.Package{PackageUnits: redundant.ReplacedBy}),
+		}
+	}
+	return res
+}
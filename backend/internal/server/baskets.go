@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+	"log"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+func (s *Server) CalculateBasket(ctx context.Context, req *CalculateBasketRequest) (*CalculateBasketResponse, error) {
+	lines := make([]model.BasketLine, len(req.Body.Lines))
+	for i, line := range req.Body.Lines {
+		lines[i] = model.BasketLine{ProductID: line.ProductID, Units: line.Units}
+	}
+
+	opts := calculateOptions(req.Solver, req.Objective, req.MaxOverfill, req.MaxPercent, req.Exact)
+	basket, err := s.packagesService.CalculateBasket(ctx, lines, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptyBasket) {
+			return nil, huma.Error400BadRequest("basket has no lines")
+		} else if errors.Is(err, service.ErrTooManyBasketLines) {
+			return nil, huma.Error400BadRequest("basket has too many lines")
+		}
+		return nil, err
+	}
+
+	res := &CalculateBasketResponse{
+		Body: CalculateBasketResponseBody{
+			Lines:       make([]BasketLineResponseBody, len(basket.Lines)),
+			Items:       basket.Items,
+			Packs:       basket.Packs,
+			Overfill:    basket.Overfill,
+			FailedLines: basket.FailedLines,
+		},
+	}
+	for i, line := range basket.Lines {
+		res.Body.Lines[i] = convertBasketLine(line)
+	}
+	return res, nil
+}
+
+func convertBasketLine(line model.BasketLineResult) BasketLineResponseBody {
+	res := BasketLineResponseBody{
+		ProductID: line.ProductID,
+		Units:     line.Units,
+	}
+	if line.Err != nil {
+		res.Error = convertLineError(line.Err)
+		return res
+	}
+
+	res.Packages = convertPackages(*line.Package)
+	res.Solver = line.Package.Solver
+	res.Objective = line.Package.Objective
+	res.TotalCost = line.Package.TotalCost
+	res.Currency = line.Package.Currency
+	res.Items, res.Packs = countItemsAndPacks(*line.Package)
+	res.Overfill = res.Items - line.Units
+	return res
+}
+
+// countItemsAndPacks returns the amount of items and packs of a package.
+func countItemsAndPacks(pack model.Package) (int, int) {
+	items, packs := 0, 0
+	for _, packageUnit := range pack.PackageUnits {
+		items += packageUnit.Amount * packageUnit.Size
+		packs += packageUnit.Amount
+	}
+	return items, packs
+}
+
+// convertLineError reports the error of a basket line or batch quantity like the calculate endpoint would.
+func convertLineError(err error) *LineErrorResponseBody {
+	var apiErr huma.StatusError
+	if errors.As(calculatePackagesError(err), &apiErr) {
+		return &LineErrorResponseBody{Status: apiErr.GetStatus(), Detail: apiErr.Error()}
+	}
+	log.Printf("failed to calculate basket line: %v", err)
+	return &LineErrorResponseBody{Status: http.StatusInternalServerError, Detail: "failed to calculate packages"}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+func (s *Server) CalculatePackagesBatch(ctx context.Context, req *CalculateBatchRequest) (*CalculateBatchResponse, error) {
+	quantities, err := req.Body.quantities()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := calculateOptions(req.Solver, req.Objective, req.MaxOverfill, req.MaxPercent, req.Exact)
+	results, err := s.packagesService.CalculatePackagesBatch(ctx, req.ProductID, quantities, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrEmptyBatch) {
+			return nil, huma.Error400BadRequest("batch has no quantities")
+		} else if errors.Is(err, service.ErrTooManyQuantities) {
+			return nil, huma.Error400BadRequest("batch has too many quantities")
+		}
+		return nil, calculatePackagesError(err)
+	}
+
+	res := &CalculateBatchResponse{
+		Body: CalculateBatchResponseBody{
+			Results: make([]BatchResultResponseBody, len(results)),
+		},
+	}
+	for i, result := range results {
+		res.Body.Results[i] = convertBatchResult(result)
+		if result.Package != nil && res.Body.Solver == "" {
+			res.Body.Solver = result.Package.Solver
+			res.Body.Objective = result.Package.Objective
+		}
+	}
+	return res, nil
+}
+
+// quantities returns the quantities listed in the body or expanded from its range.
+func (b CalculateBatchRequestBody) quantities() ([]int, error) {
+	if (len(b.Quantities) == 0) == (b.Range == nil) {
+		return nil, huma.Error400BadRequest("either quantities or range is required")
+	}
+	if b.Range == nil {
+		return b.Quantities, nil
+	}
+
+	r := b.Range
+	step := max(r.Step, 1)
+	if r.To < r.From {
+		return nil, huma.Error400BadRequest("range ends before it starts")
+	}
+	if (r.To-r.From)/step >= service.MaxBatchQuantities {
+		return nil, huma.Error400BadRequest("batch has too many quantities")
+	}
+	// counting the quantities rather than stepping past To, which would wrap around close to the maximum int
+	n := (r.To-r.From)/step + 1
+	quantities := make([]int, 0, n)
+	for i := range n {
+		quantities = append(quantities, r.From+i*step)
+	}
+	return quantities, nil
+}
+
+func convertBatchResult(result model.BatchResult) BatchResultResponseBody {
+	res := BatchResultResponseBody{Units: result.Units}
+	if result.Err != nil {
+		res.Error = convertLineError(result.Err)
+		return res
+	}
+
+	res.Packages = convertPackages(*result.Package)
+	res.Items, res.Packs = countItemsAndPacks(*result.Package)
+	res.Overfill = res.Items - result.Units
+	res.TotalCost = result.Package.TotalCost
+	res.Currency = result.Package.Currency
+	return res
+}
@@ -15,7 +15,10 @@ type Server struct {
 	server          *http.Server
 	productService  ProductsService
 	packagesService PackagesService
-	api             huma.API
+	ordersService   OrdersService
+	// idempotencyService replays retried requests, nil disables Idempotency-Key support
+	idempotencyService IdempotencyService
+	api                huma.API
 }
 
 func (s Server) Start() {
@@ -30,20 +33,25 @@ func (s Server) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
-func New(port int, productService ProductsService, packagesService PackagesService) *Server {
+func New(port int, productService ProductsService, packagesService PackagesService, ordersService OrdersService, idempotencyService IdempotencyService) *Server {
 	router := http.NewServeMux()
 	api := humago.New(router, huma.DefaultConfig("Product Package Sizes API", "1.0.0"))
 
-	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: router,
+	s := &Server{
+		api:                api,
+		productService:     productService,
+		packagesService:    packagesService,
+		ordersService:      ordersService,
+		idempotencyService: idempotencyService,
 	}
 
-	s := &Server{
-		api:             api,
-		server:          httpServer,
-		productService:  productService,
-		packagesService: packagesService,
+	var handler http.Handler = router
+	if idempotencyService != nil {
+		handler = s.idempotent(router)
+	}
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: handler,
 	}
 
 	s.api.UseMiddleware(allowCORS)
@@ -53,11 +61,15 @@ func New(port int, productService ProductsService, packagesService PackagesServi
 	return s
 }
 
+// exposedHeaders are the response headers a browser lets the caller read
+const exposedHeaders = "ETag, Location, Idempotent-Replayed"
+
 // allow server to be called by an external browser
 func allowCORS(ctx huma.Context, next func(huma.Context)) {
 	ctx.SetHeader("Access-Control-Allow-Origin", "*") // or specific origin
-	ctx.SetHeader("Access-Control-Allow-Methods", "POST, GET, DELETE, OPTIONS")
-	ctx.SetHeader("Access-Control-Allow-Headers", "Content-Type")
+	ctx.SetHeader("Access-Control-Allow-Methods", "POST, GET, PUT, PATCH, DELETE, OPTIONS")
+	ctx.SetHeader("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key, If-Match")
+	ctx.SetHeader("Access-Control-Expose-Headers", exposedHeaders)
 
 	if ctx.Method() == http.MethodOptions {
 		ctx.SetStatus(http.StatusNoContent)
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotentRequestBytes = 1024 * 1024
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, key, fingerprint string) (*model.IdempotentResponse, error)
+	Complete(ctx context.Context, response model.IdempotentResponse) error
+	Abort(ctx context.Context, key string) error
+}
+
+// idempotent replays the stored response of POST and DELETE requests retried with the same Idempotency-Key,
+// instead of applying them twice. Reusing a key for a different request answers 409. Server errors and panics
+// aren't stored, so those requests can be retried.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodDelete) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			writeError(w, huma.Error400BadRequest("failed reading the request body"))
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			writeError(w, huma.NewError(http.StatusRequestEntityTooLarge, "request body is too large"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		// replays and key errors don't reach the huma middlewares
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+
+		fingerprint := requestFingerprint(r, body)
+		stored, err := s.idempotencyService.Begin(r.Context(), key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidIdempotencyKey):
+				writeError(w, huma.Error400BadRequest("invalid idempotency key"))
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				writeError(w, huma.Error409Conflict("idempotency key was used for a different request"))
+			case errors.Is(err, service.ErrIdempotentRequestInFlight):
+				writeError(w, huma.Error409Conflict("a request with the same idempotency key is in progress"))
+			default:
+				writeError(w, huma.Error500InternalServerError("failed checking the idempotency key"))
+			}
+			return
+		}
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			if stored.Location != "" {
+				w.Header().Set("Location", stored.Location)
+			}
+			if stored.ETag != "" {
+				w.Header().Set("ETag", stored.ETag)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		// the request context may be cancelled once the response is written
+		ctx := context.WithoutCancel(r.Context())
+		handled := false
+		defer func() {
+			// a panicking handler frees the key on its way up, rather than holding it for the lease
+			if !handled {
+				if err := s.idempotencyService.Abort(ctx, key); err != nil {
+					log.Printf("failed to free idempotency key: %v", err)
+				}
+			}
+		}()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		handled = true
+
+		if recorder.status >= http.StatusInternalServerError {
+			err = s.idempotencyService.Abort(ctx, key)
+		} else {
+			err = s.idempotencyService.Complete(ctx, model.IdempotentResponse{
+				Key:         key,
+				Fingerprint: fingerprint,
+				Status:      recorder.status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Location:    recorder.Header().Get("Location"),
+				ETag:        recorder.Header().Get("ETag"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+		}
+	})
+}
+
+// requestFingerprint identifies a request by its method, URL and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, err huma.StatusError) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(err.GetStatus())
+	_ = json.NewEncoder(w).Encode(err)
+}
+
+// responseRecorder copies the status and body of a response as it's written.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
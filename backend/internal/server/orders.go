@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type OrdersService interface {
+	Create(ctx context.Context, productID string, units int, opts service.CalculateOptions) (*model.Order, error)
+	Get(ctx context.Context, id string) (*model.Order, error)
+	List(ctx context.Context, filter model.OrderFilter) ([]model.Order, error)
+	Transition(ctx context.Context, id string, state string) (*model.Order, error)
+}
+
+func (s *Server) CreateOrder(ctx context.Context, req *CreateOrderRequest) (*CreateOrderResponse, error) {
+	if req.Body.Units < 1 {
+		return nil, huma.Error400BadRequest("invalid units request")
+	}
+
+	opts := calculateOptions(req.Solver, req.Objective, req.MaxOverfill, req.MaxPercent, req.Exact)
+	order, err := s.ordersService.Create(ctx, req.Body.ProductID, req.Body.Units, opts)
+	if err != nil {
+		return nil, calculatePackagesError(err)
+	}
+	return &CreateOrderResponse{Location: v1 + "/orders/" + order.ID, Body: convertOrder(*order)}, nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *GetOrderRequest) (*OrderResponse, error) {
+	order, err := s.ordersService.Get(ctx, req.OrderID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return nil, huma.Error404NotFound("order not found")
+		}
+		return nil, err
+	}
+	return &OrderResponse{Body: convertOrder(*order)}, nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *ListOrdersRequest) (*ListOrdersResponse, error) {
+	orders, err := s.ordersService.List(ctx, model.OrderFilter{ProductID: req.ProductID, State: req.State})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOrderState) {
+			return nil, huma.Error400BadRequest("invalid order state")
+		}
+		return nil, err
+	}
+
+	res := &ListOrdersResponse{Body: make([]OrderResponseBody, len(orders))}
+	for i, order := range orders {
+		res.Body[i] = convertOrder(order)
+	}
+	return res, nil
+}
+
+func (s *Server) SetOrderState(ctx context.Context, req *SetOrderStateRequest) (*OrderResponse, error) {
+	order, err := s.ordersService.Transition(ctx, req.OrderID, req.Body.State)
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return nil, huma.Error404NotFound("order not found")
+		} else if errors.Is(err, service.ErrInvalidOrderState) {
+			return nil, huma.Error400BadRequest("invalid order state")
+		} else if errors.Is(err, service.ErrInvalidTransition) {
+			return nil, huma.Error409Conflict("order can't move to " + req.Body.State)
+		} else if errors.Is(err, service.ErrConcurrentTransition) {
+			return nil, huma.Error409Conflict("order state changed, retry")
+		}
+		return nil, err
+	}
+	return &OrderResponse{Body: convertOrder(*order)}, nil
+}
+
+func convertOrder(order model.Order) OrderResponseBody {
+	return OrderResponseBody{
+		ID:           order.ID,
+		ProductID:    order.ProductID,
+		Units:        order.Units,
+		State:        order.State,
+		Packages:     convertPackages(order.Package),
+		PackageSizes: order.PackageSizes,
+		Solver:       order.Package.Solver,
+		Objective:    order.Package.Objective,
+		TotalCost:    order.Package.TotalCost,
+		Currency:     order.Package.Currency,
+		CreatedAt:    order.CreatedAt,
+		UpdatedAt:    order.UpdatedAt,
+	}
+}
@@ -5,14 +5,27 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/service"
+	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type PackagesService interface {
-	AddPackageSize(ctx context.Context, productID string, size int) (*model.Product, error)
-	RemovePackageSize(ctx context.Context, productID string, size int) (*model.Product, error)
-	CalculatePackages(ctx context.Context, productID string, units int) (*model.Package, error)
+	AddPackageSize(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) (*model.Product, error)
+	RemovePackageSize(ctx context.Context, productID string, size int, ifVersion int) (*model.Product, error)
+	ReplacePackageSizes(ctx context.Context, productID string, sizes []int, ifVersion int) (*model.Product, error)
+	SetPackageSizePrice(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) (*model.Product, error)
+	SetPackageSizeStock(ctx context.Context, productID string, size int, stock *int, ifVersion int) (*model.Product, error)
+	AdjustPackageSizeStock(ctx context.Context, productID string, size int, delta int, ifVersion int) (*model.Product, error)
+	CalculatePackages(ctx context.Context, productID string, units int, opts service.CalculateOptions) (*model.Package, error)
+	CalculateBasket(ctx context.Context, lines []model.BasketLine, opts service.CalculateOptions) (*model.Basket, error)
+	CalculatePackagesBatch(ctx context.Context, productID string, quantities []int, opts service.CalculateOptions) ([]model.BatchResult, error)
+	SetMaxOverfill(ctx context.Context, productID string, limit model.OverfillLimit, ifVersion int) (*model.Product, error)
+	AnalysePackSet(ctx context.Context, productID string, proposed []int, from, to int) (*model.PackSetAnalysis, error)
+	SetOrderHistory(ctx context.Context, productID string, quantities []int) error
+	GetOrderHistory(ctx context.Context, productID string) ([]int, error)
+	SimulatePackSet(ctx context.Context, productID string, candidate []int, quantities []int, opts service.CalculateOptions) (*model.Simulation, error)
+	RecommendPackSet(ctx context.Context, productID string, quantities []int, opts service.RecommendOptions) (*model.Recommendation, error)
 }
 
 func (s *Server) AddPackageSize(ctx context.Context, req *AddPackageSizeRequest) (*AddPackageSizeResponse, error) {
@@ -20,40 +33,173 @@ func (s *Server) AddPackageSize(ctx context.Context, req *AddPackageSizeRequest)
 		return nil, huma.Error400BadRequest("invalid package size")
 	}
 
-	product, err := s.packagesService.AddPackageSize(ctx, req.ProductID, req.PackageSize)
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.AddPackageSize(ctx, req.ProductID, req.PackageSize, req.Body.toModel(req.PackageSize), ifVersion)
 	if err != nil {
 		if errors.Is(err, service.ErrConstraintViolation) {
 			return nil, huma.Error400BadRequest("constraint violation")
 		} else if errors.Is(err, service.ErrProductNotFound) {
 			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		} else if errors.Is(err, service.ErrInvalidPrice) {
+			return nil, huma.Error400BadRequest("invalid package size price")
 		}
 		return nil, err
 	}
 
 	return &AddPackageSizeResponse{
-		Body: ProductResponseBody{
-			ID:           product.ID,
-			Name:         product.Name,
-			PackageSizes: product.PackageSizes,
-		},
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) ReplacePackageSizes(ctx context.Context, req *ReplacePackageSizesRequest) (*ReplacePackageSizesResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.ReplacePackageSizes(ctx, req.ProductID, req.Body.PackageSizes, ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPackageSizes) {
+			return nil, huma.Error400BadRequest("invalid package size")
+		} else if errors.Is(err, service.ErrConstraintViolation) {
+			return nil, huma.Error400BadRequest("constraint violation")
+		} else if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		}
+		return nil, err
+	}
+
+	return &ReplacePackageSizesResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) SetPackageSizePrice(ctx context.Context, req *SetPackageSizePriceRequest) (*SetPackageSizePriceResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.SetPackageSizePrice(ctx, req.ProductID, req.PackageSize, req.Body.toModel(req.PackageSize), ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrPackageSizeNotFound) {
+			return nil, huma.Error404NotFound("package size not found")
+		} else if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		} else if errors.Is(err, service.ErrInvalidPrice) {
+			return nil, huma.Error400BadRequest("invalid package size price")
+		}
+		return nil, err
+	}
+
+	return &SetPackageSizePriceResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) SetPackageSizeStock(ctx context.Context, req *SetPackageSizeStockRequest) (*SetPackageSizeStockResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.SetPackageSizeStock(ctx, req.ProductID, req.PackageSize, req.Body.Available, ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrPackageSizeNotFound) {
+			return nil, huma.Error404NotFound("package size not found")
+		} else if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		} else if errors.Is(err, service.ErrInvalidStock) {
+			return nil, huma.Error400BadRequest("stock can't be negative")
+		}
+		return nil, err
+	}
+
+	return &SetPackageSizeStockResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) AdjustPackageSizeStock(ctx context.Context, req *AdjustPackageSizeStockRequest) (*AdjustPackageSizeStockResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.AdjustPackageSizeStock(ctx, req.ProductID, req.PackageSize, req.Body.Delta, ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrPackageSizeNotFound) {
+			return nil, huma.Error404NotFound("package size not found")
+		} else if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		} else if errors.Is(err, service.ErrStockNotTracked) {
+			return nil, huma.Error409Conflict("package size stock isn't tracked")
+		} else if errors.Is(err, service.ErrNegativeStock) {
+			return nil, huma.Error409Conflict("package size stock can't go below zero")
+		}
+		return nil, err
+	}
+
+	return &AdjustPackageSizeStockResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) SetMaxOverfill(ctx context.Context, req *SetMaxOverfillRequest) (*SetMaxOverfillResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.SetMaxOverfill(ctx, req.ProductID, req.Body.toModel(), ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		} else if errors.Is(err, service.ErrInvalidMaxOverfill) {
+			return nil, huma.Error400BadRequest("maximum overfill can't be negative")
+		}
+		return nil, err
+	}
+
+	return &SetMaxOverfillResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
 	}, nil
 }
 
 func (s *Server) RemovePackageSize(ctx context.Context, req *RemovePackageSizeRequest) (*RemovePackageSizeResponse, error) {
-	product, err := s.packagesService.RemovePackageSize(ctx, req.ProductID, req.PackageSize)
+	ifVersion, err := s.ifMatchVersion(ctx, req.ProductID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.packagesService.RemovePackageSize(ctx, req.ProductID, req.PackageSize, ifVersion)
 	if err != nil {
 		if errors.Is(err, service.ErrProductNotFound) {
 			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
 		}
 		return nil, err
 	}
 
 	return &RemovePackageSizeResponse{
-		Body: ProductResponseBody{
-			ID:           product.ID,
-			Name:         product.Name,
-			PackageSizes: product.PackageSizes,
-		},
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
 	}, nil
 }
 
@@ -62,21 +208,133 @@ func (s *Server) CalculatePackages(ctx context.Context, req *CalculatePackageSiz
 		return nil, huma.Error400BadRequest("invalid units request")
 	}
 
-	pack, err := s.packagesService.CalculatePackages(ctx, req.ProductID, req.ProductUnits)
+	opts := calculateOptions(req.Solver, req.Objective, req.MaxOverfill, req.MaxPercent, req.Exact)
+	opts.Alternatives = req.Alternatives
+	opts.Explain = req.Explain
+	pack, err := s.packagesService.CalculatePackages(ctx, req.ProductID, req.ProductUnits, opts)
 	if err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
-			return nil, huma.Error404NotFound("product not found")
-		} else if errors.Is(err, service.ErrProductWithoutPackages) {
-			return nil, huma.Error400BadRequest("product has no available package sizes")
-		}
-		return nil, err
+		return nil, calculatePackagesError(err)
 	}
 
-	return &CalculatePackageSizeResponse{
+	res := &CalculatePackageSizeResponse{
 		Body: CalculatePackageSizeResponseBody{
-			Packages: convertPackages(*pack),
+			Packages:  convertPackages(*pack),
+			Solver:    pack.Solver,
+			Objective: pack.Objective,
+			TotalCost: pack.TotalCost,
+			Currency:  pack.Currency,
 		},
-	}, nil
+	}
+	for _, alternative := range pack.Alternatives {
+		res.Body.Alternatives = append(res.Body.Alternatives, convertAlternative(alternative, req.ProductUnits))
+	}
+	if pack.Explanation != nil {
+		res.Body.Explanation = convertExplanation(*pack.Explanation, req.ProductUnits)
+	}
+	return res, nil
+}
+
+func convertExplanation(explanation model.Explanation, units int) *ExplanationResponseBody {
+	res := &ExplanationResponseBody{
+		Rejected:        make([]RejectedPackageResponseBody, len(explanation.Rejected)),
+		ResidueShortcut: explanation.ResidueShortcut,
+	}
+	for i, rejected := range explanation.Rejected {
+		items, packs := countItemsAndPacks(rejected.Package)
+		if rejected.PartialItems > 0 {
+			items, packs = items+rejected.PartialItems, packs+1
+		}
+		res.Rejected[i] = RejectedPackageResponseBody{
+			Packages:     convertPackages(rejected.Package),
+			PartialItems: rejected.PartialItems,
+			Items:        items,
+			Packs:        packs,
+			Overfill:     items - units,
+			TotalCost:    rejected.TotalCost,
+			Currency:     rejected.Currency,
+			Rule:         rejected.Rule,
+		}
+	}
+	return res
+}
+
+func convertAlternative(pack model.Package, units int) AlternativeResponseBody {
+	res := AlternativeResponseBody{
+		Packages:  convertPackages(pack),
+		TotalCost: pack.TotalCost,
+		Currency:  pack.Currency,
+	}
+	res.Items, res.Packs = countItemsAndPacks(pack)
+	res.Overfill = res.Items - units
+	return res
+}
+
+// calculateOptions converts the calculation query parameters, a negative maximum overfill means no maximum.
+func calculateOptions(solver, objective string, maxOverfill, maxPercent int, exact bool) service.CalculateOptions {
+	opts := service.CalculateOptions{
+		Solver:    solver,
+		Objective: service.Objective(objective),
+	}
+	if exact {
+		maxOverfill = 0
+	}
+	if maxOverfill >= 0 {
+		opts.MaxOverfill = &maxOverfill
+	}
+	if maxPercent >= 0 {
+		opts.MaxOverfillPercent = &maxPercent
+	}
+	return opts
+}
+
+// calculatePackagesError maps the errors of a calculation to the API errors.
+func calculatePackagesError(err error) error {
+	if errors.Is(err, service.ErrProductNotFound) {
+		return huma.Error404NotFound("product not found")
+	} else if errors.Is(err, service.ErrInvalidUnits) {
+		return huma.Error400BadRequest("invalid units request")
+	} else if errors.Is(err, service.ErrProductWithoutPackages) {
+		return huma.Error400BadRequest("product has no available package sizes")
+	} else if errors.Is(err, service.ErrUnknownObjective) {
+		return huma.Error400BadRequest("unknown objective")
+	} else if errors.Is(err, service.ErrObjectiveNotSupported) {
+		return huma.Error400BadRequest("objective not supported by solver")
+	}
+	var noPacking *service.NoFeasiblePackingError
+	if errors.As(err, &noPacking) {
+		return &NoFeasiblePackingErrorModel{
+			ErrorModel: huma.ErrorModel{
+				Title:  http.StatusText(http.StatusUnprocessableEntity),
+				Status: http.StatusUnprocessableEntity,
+				Detail: noPacking.Error(),
+			},
+			NearestBelow: noPacking.Below,
+			NearestAbove: noPacking.Above,
+		}
+	}
+
+	if errors.Is(err, service.ErrNoFeasiblePacking) {
+		return huma.Error422UnprocessableEntity("no packing satisfies the objective")
+	} else if errors.Is(err, service.ErrCalculationOverflow) {
+		return huma.Error422UnprocessableEntity(err.Error())
+	} else if errors.Is(err, service.ErrCalculationLimit) {
+		return huma.Error422UnprocessableEntity(err.Error())
+	} else if errors.Is(err, service.ErrSolverLimitExceeded) {
+		return huma.Error422UnprocessableEntity("solver exceeded its search limit")
+	} else if errors.Is(err, service.ErrMissingPackagePrices) {
+		return huma.Error422UnprocessableEntity("no package size has a price")
+	} else if errors.Is(err, service.ErrMixedCurrencies) {
+		return huma.Error422UnprocessableEntity("package sizes are priced in different currencies")
+	} else if errors.Is(err, service.ErrInsufficientStock) {
+		return huma.Error422UnprocessableEntity(err.Error())
+	} else if errors.Is(err, service.ErrStockNotSupported) {
+		return huma.Error400BadRequest("solver doesn't support limited stock")
+	} else if errors.Is(err, service.ErrInvalidMaxOverfill) {
+		return huma.Error400BadRequest("maximum overfill can't be negative")
+	} else if errors.Is(err, service.ErrInvalidAlternatives) {
+		return huma.Error400BadRequest("invalid amount of alternatives")
+	}
+	return err
 }
 
 func convertPackages(pack model.Package) []PackageResponseBody {
@@ -89,3 +347,26 @@ func convertPackages(pack model.Package) []PackageResponseBody {
 	}
 	return res
 }
+
+// toModel returns the price of the package size, or nil when the body carries no unit cost.
+func (b *PackageSizePriceRequestBody) toModel(size int) *model.PackagePrice {
+	if b == nil || b.UnitCost == nil {
+		return nil
+	}
+	return &model.PackagePrice{
+		Size:     size,
+		UnitCost: *b.UnitCost,
+		Currency: b.Currency,
+	}
+}
+
+func (b MaxOverfillBody) toModel() model.OverfillLimit {
+	return model.OverfillLimit{Items: b.MaxOverfill, Percent: b.MaxOverfillPercent}
+}
+
+// NoFeasiblePackingErrorModel is the 422 answered when no packing ships an order within its maximum overfill.
+type NoFeasiblePackingErrorModel struct {
+	huma.ErrorModel
+	NearestBelow int `json:"nearest_below" example:"12000" doc:"Nearest amount of items below the order that whole packs in stock can ship"`
+	NearestAbove int `json:"nearest_above" example:"12250" doc:"Nearest amount of items above the order that whole packs in stock can ship, -1 when there is none"`
+}
@@ -5,30 +5,52 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/service"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 )
 
 type ProductsService interface {
-	List(ctx context.Context) ([]model.Product, error)
+	List(ctx context.Context, opts service.ListProductsOptions) (*model.ProductPage, error)
+	Get(ctx context.Context, id string) (*model.Product, error)
 	Create(ctx context.Context, product model.Product) (*model.Product, error)
-	DeleteByID(ctx context.Context, id string) error
+	Update(ctx context.Context, id string, name *string, ifVersion int) (*model.Product, error)
+	DeleteByID(ctx context.Context, id string, ifVersion int) error
 }
 
-func (s *Server) ListProducts(ctx context.Context, _ *ListProductsRequest) (*ListProductsResponse, error) {
-	products, err := s.productService.List(ctx)
+// errProductChanged answers a change whose If-Match isn't the current ETag of the product.
+var errProductChanged = huma.Error412PreconditionFailed("product has changed, get it again")
+
+func (s *Server) ListProducts(ctx context.Context, req *ListProductsRequest) (*ListProductsResponse, error) {
+	page, err := s.productService.List(ctx, service.ListProductsOptions{
+		SortBy:     req.Sort,
+		Descending: req.Order == "desc",
+		NamePrefix: req.NamePrefix,
+		Cursor:     req.Cursor,
+		Limit:      req.Limit,
+	})
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return nil, huma.Error400BadRequest("invalid cursor, it must come from a listing with the same sort and filter")
+		} else if errors.Is(err, service.ErrInvalidProductSort) {
+			return nil, huma.Error400BadRequest("invalid sort")
+		} else if errors.Is(err, service.ErrInvalidPageSize) {
+			return nil, huma.Error400BadRequest("invalid limit")
+		}
 		return nil, err
 	}
 
-	data := make([]ProductResponseBody, len(products))
-	for i, product := range products {
+	data := make([]ProductResponseBody, len(page.Products))
+	for i, product := range page.Products {
 		data[i] = convertProductToResponseBody(product)
 	}
 
 	return &ListProductsResponse{
 		Body: ListProductsResponseBody{
-			Data: data,
+			Data:       data,
+			NextCursor: page.NextCursor,
 		},
 	}, nil
 }
@@ -43,36 +65,160 @@ func (s *Server) CreateProduct(ctx context.Context, req *CreateProductRequest) (
 	product, err := s.productService.Create(ctx, model.Product{
 		Name:         req.Body.Name,
 		PackageSizes: req.Body.PackageSizes,
+		Solver:       req.Body.Solver,
+		MaxOverfill:  req.Body.toModel(),
 	})
 	if err != nil {
 		if errors.Is(err, service.ErrConstraintViolation) {
 			return nil, huma.Error400BadRequest("constraint violation")
+		} else if errors.Is(err, service.ErrInvalidMaxOverfill) {
+			return nil, huma.Error400BadRequest("maximum overfill can't be negative")
 		}
 		return nil, err
 	}
 
 	return &CreateProductResponse{
-		Body: ProductResponseBody{
-			ID:           product.ID,
-			Name:         product.Name,
-			PackageSizes: product.PackageSizes,
-		},
+		Location: v1 + "/products/" + product.ID,
+		ETag:     productETag(*product),
+		Body:     convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) GetProduct(ctx context.Context, req *GetProductRequest) (*GetProductResponse, error) {
+	product, err := s.productService.Get(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		}
+		return nil, err
+	}
+
+	return &GetProductResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
+	}, nil
+}
+
+func (s *Server) UpdateProduct(ctx context.Context, req *UpdateProductRequest) (*UpdateProductResponse, error) {
+	ifVersion, err := s.ifMatchVersion(ctx, req.ID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.productService.Update(ctx, req.ID, req.Body.Name, ifVersion)
+	if err != nil {
+		if errors.Is(err, service.ErrConstraintViolation) {
+			return nil, huma.Error400BadRequest("constraint violation")
+		} else if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		}
+		return nil, err
+	}
+
+	return &UpdateProductResponse{
+		ETag: productETag(*product),
+		Body: convertProductToResponseBody(*product),
 	}, nil
 }
 
 func (s *Server) DeleteProductByID(ctx context.Context, req *DeleteProductByIDRequest) (*DeleteProductByIDResponse, error) {
-	err := s.productService.DeleteByID(ctx, req.ID)
+	ifVersion, err := s.ifMatchVersion(ctx, req.ID, req.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+	err = s.productService.DeleteByID(ctx, req.ID, ifVersion)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			return nil, errProductChanged
+		}
 		return nil, err
 	}
 	return &DeleteProductByIDResponse{}, nil
 }
 
 func convertProductToResponseBody(product model.Product) ProductResponseBody {
+	var prices []PackagePriceResponseBody
+	for _, price := range product.PackagePrices {
+		prices = append(prices, PackagePriceResponseBody{
+			Size:     price.Size,
+			UnitCost: price.UnitCost,
+			Currency: price.Currency,
+		})
+	}
+	var stock []PackageStockResponseBody
+	for _, packageStock := range product.PackageStock {
+		stock = append(stock, PackageStockResponseBody{
+			Size:      packageStock.Size,
+			Available: packageStock.Available,
+		})
+	}
 	return ProductResponseBody{
-		ID:           product.ID,
-		Name:         product.Name,
-		PackageSizes: product.PackageSizes,
+		ID:                 product.ID,
+		Name:               product.Name,
+		PackageSizes:       product.PackageSizes,
+		PackagePrices:      prices,
+		PackageStock:       stock,
+		Solver:             product.Solver,
+		MaxOverfill:        product.MaxOverfill.Items,
+		MaxOverfillPercent: product.MaxOverfill.Percent,
+		Version:            product.Version,
+	}
+}
+
+// productETag is the strong ETag of a product, its quoted version.
+func productETag(product model.Product) string {
+	return strconv.Quote(strconv.Itoa(product.Version))
+}
+
+// ifMatchVersion returns the version of a product a change expects from its If-Match header, 0 when any version
+// will do. When the header lists several ETags, it's the current version of the product if it's one of them, which
+// the change still checks, so a product changed meanwhile answers 412 like with a single ETag.
+func (s *Server) ifMatchVersion(ctx context.Context, productID, ifMatch string) (int, error) {
+	versions, err := parseIfMatch(ifMatch)
+	if err != nil {
+		return 0, err
+	}
+	switch len(versions) {
+	case 0:
+		return 0, nil
+	case 1:
+		return versions[0], nil
+	}
+	product, err := s.productService.Get(ctx, productID)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return 0, huma.Error404NotFound("product not found")
+		}
+		return 0, err
+	}
+	if !slices.Contains(versions, product.Version) {
+		return 0, errProductChanged
 	}
+	return product.Version, nil
+}
 
+// parseIfMatch returns the versions of the product an If-Match header lists as comma separated ETags, none when
+// it's empty or "*" and any version will do. If-Match compares ETags strongly, so weak ETags and values that can't
+// be one of our ETags never match, and it answers errProductChanged when no ETag can.
+func parseIfMatch(value string) ([]int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	var versions []int
+	for _, etag := range strings.Split(value, ",") {
+		etag = strings.TrimSpace(etag)
+		if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.Atoi(etag[1 : len(etag)-1])
+		if err == nil && version > 0 && !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, errProductChanged
+	}
+	return versions, nil
 }
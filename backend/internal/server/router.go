@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -10,10 +11,26 @@ const (
 	v1                            = "/v1"
 	listProductsEndpointPath      = v1 + "/products"
 	createProductEndpointPath     = v1 + "/products"
+	getProductByIDEndpointPath    = v1 + "/products/{productID}"
 	deleteProductByIDEndpointPath = v1 + "/products/{productID}"
+	updateProductEndpointPath     = v1 + "/products/{productID}"
+
+	replacePackageSizesEndpointPath = v1 + "/products/{productID}/packageSizes"
 
 	modifyPackageSizeEndpointPath = v1 + "/products/{productID}/packageSizes/{packageSize}"
+	packageSizeStockEndpointPath  = v1 + "/products/{productID}/packageSizes/{packageSize}/stock"
+	adjustStockEndpointPath       = v1 + "/products/{productID}/packageSizes/{packageSize}/stock/adjustments"
+	maxOverfillEndpointPath       = v1 + "/products/{productID}/maxOverfill"
 	calculatePackagesEndpointPath = v1 + "/products/{productID}/calculate/{productUnits}"
+	calculateBatchEndpointPath    = v1 + "/products/{productID}/calculate"
+	calculateBasketEndpointPath   = v1 + "/baskets/calculate"
+	analysePackSetEndpointPath    = v1 + "/products/{productID}/analysis"
+	orderHistoryEndpointPath      = v1 + "/products/{productID}/orderHistory"
+	simulatePackSetEndpointPath   = v1 + "/products/{productID}/simulate"
+	recommendPackSetEndpointPath  = v1 + "/products/{productID}/recommend"
+	ordersEndpointPath            = v1 + "/orders"
+	orderByIDEndpointPath         = v1 + "/orders/{orderID}"
+	orderStateEndpointPath        = v1 + "/orders/{orderID}/state"
 )
 
 func (s *Server) declareRoutes() {
@@ -39,6 +56,14 @@ func (s *Server) declareRoutes() {
 		DefaultStatus: http.StatusNoContent,
 		Hidden:        true,
 	}, s.CreateProduct)
+	var getProductResponse *GetProductResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodGet, getProductByIDEndpointPath, getProductResponse),
+		Summary:       "v1 - Get Product",
+		Method:        http.MethodGet,
+		Path:          getProductByIDEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.GetProduct)
 	var deleteProductResponse *DeleteProductByIDResponse
 	huma.Register(s.api, huma.Operation{
 		OperationID:   huma.GenerateOperationID(http.MethodDelete, deleteProductByIDEndpointPath, deleteProductResponse),
@@ -54,6 +79,30 @@ func (s *Server) declareRoutes() {
 		Hidden:        true,
 	}, s.DeleteProductByID)
 
+	var updateProductResponse *UpdateProductResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPatch, updateProductEndpointPath, updateProductResponse),
+		Summary:       "v1 - Update Product",
+		Method:        http.MethodPatch,
+		Path:          updateProductEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.UpdateProduct)
+
+	var replacePackageSizesResponse *ReplacePackageSizesResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, replacePackageSizesEndpointPath, replacePackageSizesResponse),
+		Summary:       "v1 - Replace Package Sizes",
+		Method:        http.MethodPut,
+		Path:          replacePackageSizesEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.ReplacePackageSizes)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          replacePackageSizesEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.ReplacePackageSizes)
+
 	var addPackageResponse *AddPackageSizeResponse
 	huma.Register(s.api, huma.Operation{
 		OperationID:   huma.GenerateOperationID(http.MethodPost, modifyPackageSizeEndpointPath, addPackageResponse),
@@ -62,6 +111,14 @@ func (s *Server) declareRoutes() {
 		Path:          modifyPackageSizeEndpointPath,
 		DefaultStatus: http.StatusCreated,
 	}, s.AddPackageSize)
+	var setPackagePriceResponse *SetPackageSizePriceResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, modifyPackageSizeEndpointPath, setPackagePriceResponse),
+		Summary:       "v1 - Set Package Size Price",
+		Method:        http.MethodPut,
+		Path:          modifyPackageSizeEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SetPackageSizePrice)
 	var removePackageResponse *RemovePackageSizeResponse
 	huma.Register(s.api, huma.Operation{
 		OperationID:   huma.GenerateOperationID(http.MethodDelete, modifyPackageSizeEndpointPath, removePackageResponse),
@@ -76,6 +133,48 @@ func (s *Server) declareRoutes() {
 		DefaultStatus: http.StatusNoContent,
 		Hidden:        true,
 	}, s.AddPackageSize)
+	var setPackageStockResponse *SetPackageSizeStockResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, packageSizeStockEndpointPath, setPackageStockResponse),
+		Summary:       "v1 - Set Package Size Stock",
+		Method:        http.MethodPut,
+		Path:          packageSizeStockEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SetPackageSizeStock)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          packageSizeStockEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.SetPackageSizeStock)
+	var adjustStockResponse *AdjustPackageSizeStockResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, adjustStockEndpointPath, adjustStockResponse),
+		Summary:       "v1 - Adjust Package Size Stock",
+		Method:        http.MethodPost,
+		Path:          adjustStockEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.AdjustPackageSizeStock)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          adjustStockEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.AdjustPackageSizeStock)
+	var setMaxOverfillResponse *SetMaxOverfillResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, maxOverfillEndpointPath, setMaxOverfillResponse),
+		Summary:       "v1 - Set Product Max Overfill",
+		Method:        http.MethodPut,
+		Path:          maxOverfillEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SetMaxOverfill)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          maxOverfillEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.SetMaxOverfill)
 	var calculatePackageResponse *CalculatePackageSizeResponse
 	huma.Register(s.api, huma.Operation{
 		OperationID:   huma.GenerateOperationID(http.MethodPost, calculatePackagesEndpointPath, calculatePackageResponse),
@@ -90,22 +189,177 @@ func (s *Server) declareRoutes() {
 		DefaultStatus: http.StatusNoContent,
 		Hidden:        true,
 	}, s.AddPackageSize)
+	var calculateBatchResponse *CalculateBatchResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, calculateBatchEndpointPath, calculateBatchResponse),
+		Summary:       "v1 - Calculate Package Batch",
+		Method:        http.MethodPost,
+		Path:          calculateBatchEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.CalculatePackagesBatch)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          calculateBatchEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.CalculatePackagesBatch)
+	var calculateBasketResponse *CalculateBasketResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, calculateBasketEndpointPath, calculateBasketResponse),
+		Summary:       "v1 - Calculate Basket",
+		Method:        http.MethodPost,
+		Path:          calculateBasketEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.CalculateBasket)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          calculateBasketEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.CalculateBasket)
+	var analysePackSetResponse *AnalysePackSetResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodGet, analysePackSetEndpointPath, analysePackSetResponse),
+		Summary:       "v1 - Analyse Pack Set",
+		Method:        http.MethodGet,
+		Path:          analysePackSetEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.AnalysePackSet)
+	var setOrderHistoryResponse *OrderHistoryResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, orderHistoryEndpointPath, setOrderHistoryResponse),
+		Summary:       "v1 - Set Order History",
+		Method:        http.MethodPut,
+		Path:          orderHistoryEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SetOrderHistory)
+	var getOrderHistoryResponse *OrderHistoryResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodGet, orderHistoryEndpointPath, getOrderHistoryResponse),
+		Summary:       "v1 - Get Order History",
+		Method:        http.MethodGet,
+		Path:          orderHistoryEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.GetOrderHistory)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          orderHistoryEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.SetOrderHistory)
+	var simulatePackSetResponse *SimulatePackSetResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, simulatePackSetEndpointPath, simulatePackSetResponse),
+		Summary:       "v1 - Simulate Pack Set",
+		Method:        http.MethodPost,
+		Path:          simulatePackSetEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SimulatePackSet)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          simulatePackSetEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.SimulatePackSet)
+	var recommendPackSetResponse *RecommendPackSetResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, recommendPackSetEndpointPath, recommendPackSetResponse),
+		Summary:       "v1 - Recommend Pack Set",
+		Method:        http.MethodPost,
+		Path:          recommendPackSetEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.RecommendPackSet)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          recommendPackSetEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.RecommendPackSet)
+
+	var createOrderResponse *CreateOrderResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPost, ordersEndpointPath, createOrderResponse),
+		Summary:       "v1 - Create Order",
+		Method:        http.MethodPost,
+		Path:          ordersEndpointPath,
+		DefaultStatus: http.StatusCreated,
+	}, s.CreateOrder)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          ordersEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.CreateOrder)
+	var listOrdersResponse *ListOrdersResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodGet, ordersEndpointPath, listOrdersResponse),
+		Summary:       "v1 - List Orders",
+		Method:        http.MethodGet,
+		Path:          ordersEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.ListOrders)
+	var getOrderResponse *OrderResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodGet, orderByIDEndpointPath, getOrderResponse),
+		Summary:       "v1 - Get Order",
+		Method:        http.MethodGet,
+		Path:          orderByIDEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.GetOrder)
+	var setOrderStateResponse *OrderResponse
+	huma.Register(s.api, huma.Operation{
+		OperationID:   huma.GenerateOperationID(http.MethodPut, orderStateEndpointPath, setOrderStateResponse),
+		Summary:       "v1 - Set Order State",
+		Method:        http.MethodPut,
+		Path:          orderStateEndpointPath,
+		DefaultStatus: http.StatusOK,
+	}, s.SetOrderState)
+	huma.Register(s.api, huma.Operation{
+		Method:        http.MethodOptions,
+		Path:          orderStateEndpointPath,
+		DefaultStatus: http.StatusNoContent,
+		Hidden:        true,
+	}, s.SetOrderState)
 }
 
-type ListProductsRequest struct{}
+type ListProductsRequest struct {
+	Sort       string `query:"sort" enum:"name,created_at" default:"created_at" doc:"Sort products by name or creation time"`
+	Order      string `query:"order" enum:"asc,desc" default:"asc" doc:"Sort order"`
+	NamePrefix string `query:"name_prefix" example:"Product" doc:"Only list the products whose name starts with it, ignoring the case of ASCII letters"`
+	Cursor     string `query:"cursor" doc:"next_cursor of the previous page, listed with the same sort, order and name prefix"`
+	Limit      int    `query:"limit" minimum:"1" maximum:"100" default:"20" doc:"Products per page"`
+}
 
 type ListProductsResponse struct {
 	Body ListProductsResponseBody
 }
 
 type ListProductsResponseBody struct {
-	Data []ProductResponseBody
+	Data       []ProductResponseBody
+	NextCursor string `json:"next_cursor,omitempty" doc:"Cursor of the next page, omitted on the last page"`
 }
 
 type ProductResponseBody struct {
-	ID           string `json:"id" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
-	Name         string `json:"name" example:"My First Product" doc:"Name of the Product"`
-	PackageSizes []int  `json:"package_sizes,omitempty" doc:"Available Package Sizes"`
+	ID                 string                     `json:"id" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Name               string                     `json:"name" example:"My First Product" doc:"Name of the Product"`
+	PackageSizes       []int                      `json:"package_sizes,omitempty" doc:"Available Package Sizes"`
+	PackagePrices      []PackagePriceResponseBody `json:"package_prices,omitempty" doc:"Prices of the Package Sizes that have one"`
+	PackageStock       []PackageStockResponseBody `json:"package_stock,omitempty" doc:"Packs in stock of the Package Sizes with tracked stock, the others have unlimited supply"`
+	Solver             string                     `json:"solver,omitempty" example:"dp" doc:"Solver used to calculate packages, the default solver when empty"`
+	MaxOverfill        *int                       `json:"max_overfill,omitempty" example:"500" doc:"Maximum amount of items shipped over any order"`
+	MaxOverfillPercent *int                       `json:"max_overfill_percent,omitempty" example:"10" doc:"Maximum items shipped over any order, as a percentage of its units"`
+	Version            int                        `json:"version" example:"3" doc:"Version of the Product, bumped by every change to it. Its ETag"`
+}
+
+type PackageStockResponseBody struct {
+	Size      int `json:"size" example:"5000" doc:"Package Size"`
+	Available int `json:"available" example:"3" doc:"Packs in stock"`
+}
+
+type PackagePriceResponseBody struct {
+	Size     int    `json:"size" example:"250" doc:"Package Size"`
+	UnitCost int64  `json:"unit_cost" example:"499" doc:"Cost of one Package, in minor units of the currency"`
+	Currency string `json:"currency" example:"GBP" doc:"ISO 4217 currency code"`
 }
 
 type CreateProductRequest struct {
@@ -115,39 +369,160 @@ type CreateProductRequest struct {
 type CreateProductRequestBody struct {
 	Name         string `json:"name" minLength:"5" required:"true" example:"My First Product" doc:"Name of the Product"`
 	PackageSizes []int  `json:"package_sizes" required:"false" example:"[100]" doc:"Available Package Sizes"`
+	Solver       string `json:"solver,omitempty" required:"false" enum:"dp,branch-and-bound,greedy" doc:"Solver used to calculate packages, which can only be set when creating the product"`
+	MaxOverfillBody
 }
 
 type CreateProductResponse struct {
+	Location string `header:"Location" doc:"Path of the created Product"`
+	ETag     string `header:"ETag" doc:"Version of the Product"`
+	Body     ProductResponseBody
+}
+
+type GetProductRequest struct {
+	ID string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+}
+
+type GetProductResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
+}
+
+type UpdateProductRequest struct {
+	ID      string                   `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	IfMatch string                   `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body    UpdateProductRequestBody `required:"true"`
+}
+
+type UpdateProductRequestBody struct {
+	Name *string `json:"name,omitempty" required:"false" minLength:"5" example:"My Renamed Product" doc:"New name of the Product, left unchanged when omitted"`
+}
+
+type UpdateProductResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
+}
+
+type ReplacePackageSizesRequest struct {
+	ProductID string                         `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	IfMatch   string                         `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body      ReplacePackageSizesRequestBody `required:"true"`
+}
+
+type ReplacePackageSizesRequestBody struct {
+	PackageSizes []int `json:"package_sizes" required:"true" example:"[250,500,1000]" doc:"Package Sizes replacing the current ones. Sizes the product already has keep their price and stock"`
+}
+
+type ReplacePackageSizesResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
 	Body ProductResponseBody
 }
 
 type DeleteProductByIDRequest struct {
-	ID string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	ID      string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	IfMatch string `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the deletion expects, answered with 412 when it has changed since"`
 }
 
 type DeleteProductByIDResponse struct{}
 
 type AddPackageSizeRequest struct {
-	ProductID   string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
-	PackageSize int    `path:"packageSize" example:"250" doc:"Package Size"`
+	ProductID   string                       `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	PackageSize int                          `path:"packageSize" example:"250" doc:"Package Size"`
+	IfMatch     string                       `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body        *PackageSizePriceRequestBody `required:"false"`
+}
+
+type PackageSizePriceRequestBody struct {
+	UnitCost *int64 `json:"unit_cost,omitempty" required:"false" minimum:"0" maximum:"1000000000" example:"499" doc:"Cost of one Package, in minor units of the currency. Omit to leave the Package Size without a price"`
+	Currency string `json:"currency,omitempty" required:"false" pattern:"^[A-Z]{3}$" example:"GBP" doc:"ISO 4217 currency code"`
+}
+
+type SetPackageSizePriceRequest struct {
+	ProductID   string                       `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	PackageSize int                          `path:"packageSize" example:"250" doc:"Package Size"`
+	IfMatch     string                       `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body        *PackageSizePriceRequestBody `required:"true"`
+}
+
+type SetPackageSizePriceResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
+}
+
+type SetPackageSizeStockRequest struct {
+	ProductID   string                         `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	PackageSize int                            `path:"packageSize" example:"5000" doc:"Package Size"`
+	IfMatch     string                         `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body        SetPackageSizeStockRequestBody `required:"true"`
+}
+
+type SetPackageSizeStockRequestBody struct {
+	Available *int `json:"available,omitempty" required:"false" minimum:"0" example:"3" doc:"Packs in stock. Omit to stop tracking the stock and give the Package Size unlimited supply"`
+}
+
+type SetPackageSizeStockResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
+}
+
+type SetMaxOverfillRequest struct {
+	ProductID string          `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	IfMatch   string          `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body      MaxOverfillBody `required:"true"`
+}
+
+type MaxOverfillBody struct {
+	MaxOverfill        *int `json:"max_overfill,omitempty" required:"false" minimum:"0" example:"500" doc:"Maximum amount of items shipped over any order, 0 to only ship exact orders. Omit for no maximum"`
+	MaxOverfillPercent *int `json:"max_overfill_percent,omitempty" required:"false" minimum:"0" example:"10" doc:"Maximum items shipped over any order, as a percentage of its units rounded down. Omit for no maximum"`
+}
+
+type SetMaxOverfillResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
+}
+
+type AdjustPackageSizeStockRequest struct {
+	ProductID   string                            `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	PackageSize int                               `path:"packageSize" example:"5000" doc:"Package Size"`
+	IfMatch     string                            `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
+	Body        AdjustPackageSizeStockRequestBody `required:"true"`
+}
+
+type AdjustPackageSizeStockRequestBody struct {
+	Delta int `json:"delta" required:"true" example:"-2" doc:"Packs added to the stock, negative to remove them"`
+}
+
+type AdjustPackageSizeStockResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
+	Body ProductResponseBody
 }
 
 type AddPackageSizeResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
 	Body ProductResponseBody
 }
 
 type RemovePackageSizeRequest struct {
 	ProductID   string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
 	PackageSize int    `path:"packageSize" example:"250" doc:"Package Size"`
+	IfMatch     string `header:"If-Match" example:"\"3\"" doc:"ETag of the Product the change expects, answered with 412 when it has changed since"`
 }
 
 type RemovePackageSizeResponse struct {
+	ETag string `header:"ETag" doc:"Version of the Product"`
 	Body ProductResponseBody
 }
 
 type CalculatePackageSizeRequest struct {
 	ProductID    string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
 	ProductUnits int    `path:"productUnits" example:"250" doc:"Product Units"`
+	Solver       string `query:"solver" enum:"dp,branch-and-bound,greedy" doc:"Solver to use instead of the one configured for the product"`
+	Objective    string `query:"objective" enum:"items-first,packs-first,exact,cost" doc:"How packings are ranked. items-first (default): fewest items, then fewest packs. packs-first: fewest packs, then fewest items. exact: exactly the ordered items in the fewest packs, or nothing. cost: lowest total cost using the priced package sizes, then fewest items, then fewest packs"`
+	MaxOverfill  int    `query:"max_overfill" minimum:"-1" default:"-1" doc:"Maximum amount of items shipped over the order, -1 for no maximum. The product's own maximum still applies"`
+	MaxPercent   int    `query:"max_overfill_percent" minimum:"-1" default:"-1" doc:"Maximum items shipped over the order as a percentage of its units, -1 for no maximum"`
+	Exact        bool   `query:"exact" doc:"Only ship exactly the ordered units, like a maximum overfill of 0"`
+	Alternatives int    `query:"alternatives" minimum:"0" maximum:"20" default:"0" doc:"Amount of best packings to list, ranked by the objective"`
+	Explain      bool   `query:"explain" doc:"Explain why the packages were chosen over the best rejected packings"`
 }
 
 type CalculatePackageSizeResponse struct {
@@ -155,10 +530,316 @@ type CalculatePackageSizeResponse struct {
 }
 
 type CalculatePackageSizeResponseBody struct {
-	Packages []PackageResponseBody `json:"packages" doc:"List of Packages"`
+	Packages     []PackageResponseBody     `json:"packages" doc:"List of Packages"`
+	Solver       string                    `json:"solver" example:"dp" doc:"Solver that ran the calculation"`
+	Objective    string                    `json:"objective" example:"items-first" doc:"Objective the packages were ranked by"`
+	TotalCost    *int64                    `json:"total_cost,omitempty" example:"1497" doc:"Cost of the packages, only when every package size used has a price in the same currency"`
+	Currency     string                    `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
+	Alternatives []AlternativeResponseBody `json:"alternatives,omitempty" doc:"Best packings ranked by the objective, the first one being the packages above"`
+	Explanation  *ExplanationResponseBody  `json:"explanation,omitempty" doc:"Why the packages were chosen, only when explain is set"`
+}
+
+type ExplanationResponseBody struct {
+	Rejected        []RejectedPackageResponseBody `json:"rejected" doc:"Best packings rejected in favour of the packages, with the rule that eliminated each"`
+	ResidueShortcut bool                          `json:"residue_shortcut" doc:"Whether the dp solver topped up the best packing of a remainder class with packs of a single size, instead of searching a table bounded by the order"`
+}
+
+type RejectedPackageResponseBody struct {
+	Packages     []PackageResponseBody `json:"packages" doc:"List of whole Packages"`
+	PartialItems int                   `json:"partial_items,omitempty" example:"1" doc:"Items taken out of a partial pack of the smallest size, only for the whole-packs rule"`
+	Items        int                   `json:"items" example:"15000" doc:"Items shipped"`
+	Packs        int                   `json:"packs" example:"3" doc:"Packs shipped, including a partial pack"`
+	Overfill     int                   `json:"overfill" example:"2999" doc:"Items shipped over the units"`
+	TotalCost    *int64                `json:"total_cost,omitempty" example:"1497" doc:"Cost of the whole packages, only when every package size used has a price in the same currency"`
+	Currency     string                `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
+	Rule         string                `json:"rule" enum:"whole-packs,fewest-items,fewest-packs,lowest-cost,bigger-package-sizes" example:"fewest-items" doc:"Rule of the objective the packing lost on"`
+}
+
+type AlternativeResponseBody struct {
+	Packages  []PackageResponseBody `json:"packages" doc:"List of Packages"`
+	Items     int                   `json:"items" example:"750" doc:"Items shipped"`
+	Packs     int                   `json:"packs" example:"2" doc:"Packs shipped"`
+	Overfill  int                   `json:"overfill" example:"249" doc:"Items shipped over the units"`
+	TotalCost *int64                `json:"total_cost,omitempty" example:"1497" doc:"Cost of the packages, only when every package size used has a price in the same currency"`
+	Currency  string                `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
 }
 
 type PackageResponseBody struct {
 	Amount int `json:"units"  example:"3" doc:"Units of Package"`
 	Size   int `json:"size"  example:"250" doc:"Package Size"`
 }
+
+type CalculateBasketRequest struct {
+	Solver      string                     `query:"solver" enum:"dp,branch-and-bound,greedy" doc:"Solver to use for every line instead of the one configured for its product"`
+	Objective   string                     `query:"objective" enum:"items-first,packs-first,exact,cost" doc:"How packings are ranked for every line, see the calculate endpoint"`
+	MaxOverfill int                        `query:"max_overfill" minimum:"-1" default:"-1" doc:"Maximum amount of items shipped over each line, -1 for no maximum"`
+	MaxPercent  int                        `query:"max_overfill_percent" minimum:"-1" default:"-1" doc:"Maximum items shipped over each line as a percentage of its units, -1 for no maximum"`
+	Exact       bool                       `query:"exact" doc:"Only ship exactly the units of each line"`
+	Body        CalculateBasketRequestBody `required:"true"`
+}
+
+type CalculateBasketRequestBody struct {
+	Lines []BasketLineRequestBody `json:"lines" required:"true" minItems:"1" maxItems:"1000" doc:"Products and units to calculate"`
+}
+
+type BasketLineRequestBody struct {
+	ProductID string `json:"product_id" required:"true" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Units     int    `json:"units" required:"true" example:"250" doc:"Product Units"`
+}
+
+type CalculateBasketResponse struct {
+	Body CalculateBasketResponseBody
+}
+
+type CalculateBasketResponseBody struct {
+	Lines       []BasketLineResponseBody `json:"lines" doc:"Result of every line, in request order"`
+	Items       int                      `json:"items" example:"12250" doc:"Items shipped by the lines that could be calculated"`
+	Packs       int                      `json:"packs" example:"4" doc:"Packs shipped by the lines that could be calculated"`
+	Overfill    int                      `json:"overfill" example:"249" doc:"Items shipped over the units of the lines that could be calculated"`
+	FailedLines int                      `json:"failed_lines" example:"0" doc:"Lines that couldn't be calculated"`
+}
+
+type BasketLineResponseBody struct {
+	ProductID string                 `json:"product_id" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Units     int                    `json:"units" example:"12001" doc:"Product Units"`
+	Packages  []PackageResponseBody  `json:"packages,omitempty" doc:"List of Packages"`
+	Solver    string                 `json:"solver,omitempty" example:"dp" doc:"Solver that ran the calculation"`
+	Objective string                 `json:"objective,omitempty" example:"items-first" doc:"Objective the packages were ranked by"`
+	Items     int                    `json:"items" example:"12250" doc:"Items shipped"`
+	Packs     int                    `json:"packs" example:"4" doc:"Packs shipped"`
+	Overfill  int                    `json:"overfill" example:"249" doc:"Items shipped over the units"`
+	TotalCost *int64                 `json:"total_cost,omitempty" example:"1497" doc:"Cost of the packages, only when every package size used has a price in the same currency"`
+	Currency  string                 `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
+	Error     *LineErrorResponseBody `json:"error,omitempty" doc:"Why the line couldn't be calculated"`
+}
+
+type LineErrorResponseBody struct {
+	Status int    `json:"status" example:"404" doc:"HTTP status the calculate endpoint would have answered with"`
+	Detail string `json:"detail" example:"product not found" doc:"Error detail"`
+}
+
+type CalculateBatchRequest struct {
+	ProductID   string                    `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Solver      string                    `query:"solver" enum:"dp,branch-and-bound,greedy" doc:"Solver to use instead of the one configured for the product"`
+	Objective   string                    `query:"objective" enum:"items-first,packs-first,exact,cost" doc:"How packings are ranked, see the calculate endpoint"`
+	MaxOverfill int                       `query:"max_overfill" minimum:"-1" default:"-1" doc:"Maximum amount of items shipped over each quantity, -1 for no maximum"`
+	MaxPercent  int                       `query:"max_overfill_percent" minimum:"-1" default:"-1" doc:"Maximum items shipped over each quantity as a percentage of it, -1 for no maximum"`
+	Exact       bool                      `query:"exact" doc:"Only ship exactly each quantity"`
+	Body        CalculateBatchRequestBody `required:"true"`
+}
+
+type CalculateBatchRequestBody struct {
+	Quantities []int                     `json:"quantities,omitempty" required:"false" maxItems:"10000" example:"[250,251,501]" doc:"Product Units to calculate, instead of a range"`
+	Range      *QuantityRangeRequestBody `json:"range,omitempty" required:"false" doc:"Range of Product Units to calculate, instead of a list"`
+}
+
+type QuantityRangeRequestBody struct {
+	From int `json:"from" required:"true" minimum:"1" example:"1000" doc:"First Product Units"`
+	To   int `json:"to" required:"true" minimum:"1" example:"2000" doc:"Last Product Units, included"`
+	Step int `json:"step,omitempty" required:"false" minimum:"1" example:"100" doc:"Units between quantities, 1 by default"`
+}
+
+type CalculateBatchResponse struct {
+	Body CalculateBatchResponseBody
+}
+
+type CalculateBatchResponseBody struct {
+	Results   []BatchResultResponseBody `json:"results" doc:"Result of every quantity, in request order"`
+	Solver    string                    `json:"solver,omitempty" example:"dp" doc:"Solver that ran the calculations"`
+	Objective string                    `json:"objective,omitempty" example:"items-first" doc:"Objective the packages were ranked by"`
+}
+
+type BatchResultResponseBody struct {
+	Units     int                    `json:"units" example:"12001" doc:"Product Units"`
+	Packages  []PackageResponseBody  `json:"packages,omitempty" doc:"List of Packages"`
+	Items     int                    `json:"items" example:"12250" doc:"Items shipped"`
+	Packs     int                    `json:"packs" example:"4" doc:"Packs shipped"`
+	Overfill  int                    `json:"overfill" example:"249" doc:"Items shipped over the units"`
+	TotalCost *int64                 `json:"total_cost,omitempty" example:"1497" doc:"Cost of the packages, only when every package size used has a price in the same currency"`
+	Currency  string                 `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
+	Error     *LineErrorResponseBody `json:"error,omitempty" doc:"Why the quantity couldn't be calculated"`
+}
+
+type AnalysePackSetRequest struct {
+	ProductID    string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	PackageSizes []int  `query:"package_sizes" example:"[250,500,750]" doc:"Proposed Package Sizes to analyse instead of the product's, eg. its sizes plus one to add"`
+	From         int    `query:"from" minimum:"1" default:"1" doc:"Smallest quantity the overfill is measured over"`
+	To           int    `query:"to" minimum:"0" default:"0" doc:"Biggest quantity the overfill is measured over, up to 1000000 quantities. 0 for one LCM of the sizes, or up to the Frobenius number when it's bigger"`
+}
+
+type AnalysePackSetResponse struct {
+	Body AnalysePackSetResponseBody
+}
+
+type AnalysePackSetResponseBody struct {
+	PackageSizes          []int                              `json:"package_sizes" doc:"Analysed Package Sizes"`
+	GreatestCommonDivisor int                                `json:"gcd" example:"250" doc:"Greatest common divisor of the sizes, only its multiples can be shipped exactly"`
+	LeastCommonMultiple   *int                               `json:"lcm,omitempty" example:"10000" doc:"Least common multiple of the sizes, omitted when too big"`
+	FrobeniusNumber       *int                               `json:"frobenius_number,omitempty" example:"1350" doc:"Largest multiple of the gcd that can't be shipped exactly, omitted when every multiple can"`
+	From                  int                                `json:"from" example:"1" doc:"Smallest quantity the overfill was measured over"`
+	To                    int                                `json:"to" example:"10000" doc:"Biggest quantity the overfill was measured over"`
+	WorstOverfill         int                                `json:"worst_overfill" example:"249" doc:"Most items shipped over a quantity of the range"`
+	WorstUnits            int                                `json:"worst_units" example:"1" doc:"Smallest quantity with the worst overfill"`
+	AverageOverfill       float64                            `json:"average_overfill" example:"124.5" doc:"Average items shipped over the quantities of the range"`
+	RedundantSizes        []RedundantPackageSizeResponseBody `json:"redundant_sizes" doc:"Sizes that can each be removed without changing the items or packs shipped for any quantity of the range"`
+}
+
+type RedundantPackageSizeResponseBody struct {
+	Size       int                   `json:"size" example:"500" doc:"Package Size"`
+	ReplacedBy []PackageResponseBody `json:"replaced_by" doc:"Fewest packs of the other sizes shipping the same items, empty when they can't"`
+}
+
+type SetOrderHistoryRequest struct {
+	ProductID string                  `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Body      OrderHistoryRequestBody `required:"true"`
+}
+
+type OrderHistoryRequestBody struct {
+	Quantities []int `json:"quantities" required:"true" maxItems:"100000" example:"[250,12001,501]" doc:"Historical order quantities, replacing the stored ones"`
+}
+
+type GetOrderHistoryRequest struct {
+	ProductID string `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+}
+
+type OrderHistoryResponse struct {
+	Body OrderHistoryResponseBody
+}
+
+type OrderHistoryResponseBody struct {
+	Quantities []int `json:"quantities" doc:"Historical order quantities, in the order they were stored"`
+}
+
+type SimulatePackSetRequest struct {
+	ProductID   string                     `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Solver      string                     `query:"solver" enum:"dp,branch-and-bound,greedy" doc:"Solver to use instead of the one configured for the product"`
+	Objective   string                     `query:"objective" enum:"items-first,packs-first,exact,cost" doc:"How packings are ranked, see the calculate endpoint"`
+	MaxOverfill int                        `query:"max_overfill" minimum:"-1" default:"-1" doc:"Maximum amount of items shipped over each order, -1 for no maximum"`
+	MaxPercent  int                        `query:"max_overfill_percent" minimum:"-1" default:"-1" doc:"Maximum items shipped over each order as a percentage of it, -1 for no maximum"`
+	Exact       bool                       `query:"exact" doc:"Only ship exactly each order"`
+	Body        SimulatePackSetRequestBody `required:"true"`
+}
+
+type SimulatePackSetRequestBody struct {
+	PackageSizes []int `json:"package_sizes" required:"true" minItems:"1" example:"[250,500,1000,2000]" doc:"Candidate Package Sizes"`
+	Quantities   []int `json:"quantities,omitempty" required:"false" maxItems:"100000" example:"[250,12001,501]" doc:"Order quantities to simulate. Omit to simulate the order history of the product"`
+}
+
+type SimulatePackSetResponse struct {
+	Body SimulatePackSetResponseBody
+}
+
+type SimulatePackSetResponseBody struct {
+	Orders        int                             `json:"orders" example:"3" doc:"Orders simulated"`
+	Current       SimulationTotalsResponseBody    `json:"current" doc:"Totals of the current Package Sizes"`
+	Candidate     SimulationTotalsResponseBody    `json:"candidate" doc:"Totals of the candidate Package Sizes"`
+	ItemsDelta    int                             `json:"items_delta" example:"0" doc:"Items shipped by the candidate minus the current Package Sizes"`
+	PacksDelta    int                             `json:"packs_delta" example:"3" doc:"Packs shipped by the candidate minus the current Package Sizes"`
+	OverfillDelta int                             `json:"overfill_delta" example:"0" doc:"Overfill of the candidate minus the current Package Sizes"`
+	Changed       []SimulatedQuantityResponseBody `json:"changed" doc:"Distinct quantities whose packing changes, ascending"`
+}
+
+type SimulationTotalsResponseBody struct {
+	PackageSizes []int `json:"package_sizes" doc:"Package Sizes simulated"`
+	Items        int   `json:"items" example:"13000" doc:"Items shipped by the orders that could be calculated"`
+	Packs        int   `json:"packs" example:"6" doc:"Packs shipped by the orders that could be calculated"`
+	Overfill     int   `json:"overfill" example:"748" doc:"Items shipped over the orders that could be calculated"`
+	FailedOrders int   `json:"failed_orders" example:"0" doc:"Orders that couldn't be calculated"`
+}
+
+type SimulatedQuantityResponseBody struct {
+	Units     int                     `json:"units" example:"12001" doc:"Product Units"`
+	Orders    int                     `json:"orders" example:"1" doc:"Orders of these units"`
+	Current   BatchResultResponseBody `json:"current" doc:"Result with the current Package Sizes"`
+	Candidate BatchResultResponseBody `json:"candidate" doc:"Result with the candidate Package Sizes"`
+}
+
+type RecommendPackSetRequest struct {
+	ProductID string                      `path:"productID" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Body      RecommendPackSetRequestBody `required:"true"`
+}
+
+type RecommendPackSetRequestBody struct {
+	Sizes      int   `json:"sizes" required:"true" minimum:"1" maximum:"10" example:"5" doc:"Amount of Package Sizes to recommend"`
+	MinSize    int   `json:"min_size,omitempty" required:"false" minimum:"0" example:"250" doc:"Smallest Package Size to recommend, 0 for no minimum"`
+	MaxSize    int   `json:"max_size,omitempty" required:"false" minimum:"0" example:"5000" doc:"Biggest Package Size to recommend, 0 for no maximum"`
+	Keep       []int `json:"keep,omitempty" required:"false" example:"[250]" doc:"Package Sizes the recommendation must include"`
+	Quantities []int `json:"quantities,omitempty" required:"false" maxItems:"100000" example:"[250,12001,501]" doc:"Order quantities to recommend for. Omit to use the order history of the product"`
+}
+
+type RecommendPackSetResponse struct {
+	Body RecommendPackSetResponseBody
+}
+
+type RecommendPackSetResponseBody struct {
+	Orders      int                              `json:"orders" example:"3" doc:"Orders the recommendation was scored on"`
+	Recommended RecommendationTotalsResponseBody `json:"recommended" doc:"Recommended Package Sizes, with their totals. Fewer sizes than asked when no more would improve them"`
+	Current     RecommendationTotalsResponseBody `json:"current" doc:"Current Package Sizes, with their totals"`
+	Evaluated   int                              `json:"evaluated" example:"120" doc:"Candidate sets of Package Sizes scored by the search"`
+}
+
+type RecommendationTotalsResponseBody struct {
+	SimulationTotalsResponseBody
+	ExpectedOverfill float64 `json:"expected_overfill" example:"33.9" doc:"Average items shipped over an order that could be calculated"`
+	ExpectedPacks    float64 `json:"expected_packs" example:"4.4" doc:"Average packs shipped for an order that could be calculated"`
+}
+
+type CreateOrderRequest struct {
+	Solver      string                 `query:"solver" enum:"dp,branch-and-bound,greedy" doc:"Solver to use instead of the one configured for the product"`
+	Objective   string                 `query:"objective" enum:"items-first,packs-first,exact,cost" doc:"How packings are ranked, see the calculate endpoint"`
+	MaxOverfill int                    `query:"max_overfill" minimum:"-1" default:"-1" doc:"Maximum amount of items shipped over the order, -1 for no maximum"`
+	MaxPercent  int                    `query:"max_overfill_percent" minimum:"-1" default:"-1" doc:"Maximum items shipped over the order as a percentage of it, -1 for no maximum"`
+	Exact       bool                   `query:"exact" doc:"Only ship exactly the order"`
+	Body        CreateOrderRequestBody `required:"true"`
+}
+
+type CreateOrderRequestBody struct {
+	ProductID string `json:"product_id" required:"true" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Units     int    `json:"units" required:"true" example:"12001" doc:"Product Units"`
+}
+
+type GetOrderRequest struct {
+	OrderID string `path:"orderID" example:"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d" doc:"Order ID"`
+}
+
+type ListOrdersRequest struct {
+	ProductID string `query:"product_id" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Only list the orders of this product"`
+	State     string `query:"state" enum:"quoted,confirmed,picked,shipped,cancelled" doc:"Only list the orders in this state"`
+}
+
+type ListOrdersResponse struct {
+	Body []OrderResponseBody
+}
+
+type SetOrderStateRequest struct {
+	OrderID string                   `path:"orderID" example:"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d" doc:"Order ID"`
+	Body    SetOrderStateRequestBody `required:"true"`
+}
+
+type SetOrderStateRequestBody struct {
+	State string `json:"state" required:"true" enum:"quoted,confirmed,picked,shipped,cancelled" doc:"State to move the order to: quoted orders can be confirmed, confirmed ones picked and picked ones shipped. Orders can be cancelled until shipped"`
+}
+
+type CreateOrderResponse struct {
+	Location string `header:"Location" doc:"Path of the created Order"`
+	Body     OrderResponseBody
+}
+
+type OrderResponse struct {
+	Body OrderResponseBody
+}
+
+type OrderResponseBody struct {
+	ID           string                `json:"id" example:"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d" doc:"Order ID"`
+	ProductID    string                `json:"product_id" example:"018ef16a-31a7-7e11-a77d-78b2eea91e2f" doc:"Product ID"`
+	Units        int                   `json:"units" example:"12001" doc:"Product Units"`
+	State        string                `json:"state" example:"quoted" doc:"State of the order"`
+	Packages     []PackageResponseBody `json:"packages" doc:"Packages calculated when the order was quoted"`
+	PackageSizes []int                 `json:"package_sizes" doc:"Package Sizes of the product when the order was quoted"`
+	Solver       string                `json:"solver" example:"dp" doc:"Solver that calculated the packages"`
+	Objective    string                `json:"objective" example:"items-first" doc:"Objective the packages were ranked by"`
+	TotalCost    *int64                `json:"total_cost,omitempty" example:"1497" doc:"Cost of the packages when quoted, only when every package size used had a price in the same currency"`
+	Currency     string                `json:"currency,omitempty" example:"GBP" doc:"Currency of the total cost"`
+	CreatedAt    time.Time             `json:"created_at" doc:"When the order was quoted"`
+	UpdatedAt    time.Time             `json:"updated_at" doc:"When the order last changed state"`
+}
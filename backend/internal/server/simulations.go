@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+func (s *Server) SetOrderHistory(ctx context.Context, req *SetOrderHistoryRequest) (*OrderHistoryResponse, error) {
+	err := s.packagesService.SetOrderHistory(ctx, req.ProductID, req.Body.Quantities)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		} else if errors.Is(err, service.ErrInvalidUnits) {
+			return nil, huma.Error400BadRequest("invalid units request")
+		} else if errors.Is(err, service.ErrTooManyOrders) {
+			return nil, huma.Error400BadRequest("too many orders")
+		}
+		return nil, err
+	}
+	return &OrderHistoryResponse{Body: OrderHistoryResponseBody{Quantities: req.Body.Quantities}}, nil
+}
+
+func (s *Server) GetOrderHistory(ctx context.Context, req *GetOrderHistoryRequest) (*OrderHistoryResponse, error) {
+	quantities, err := s.packagesService.GetOrderHistory(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			return nil, huma.Error404NotFound("product not found")
+		}
+		return nil, err
+	}
+	return &OrderHistoryResponse{Body: OrderHistoryResponseBody{Quantities: quantities}}, nil
+}
+
+func (s *Server) SimulatePackSet(ctx context.Context, req *SimulatePackSetRequest) (*SimulatePackSetResponse, error) {
+	opts := calculateOptions(req.Solver, req.Objective, req.MaxOverfill, req.MaxPercent, req.Exact)
+	simulation, err := s.packagesService.SimulatePackSet(ctx, req.ProductID, req.Body.PackageSizes, req.Body.Quantities, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPackageSizes) {
+			return nil, huma.Error400BadRequest("package sizes must be positive")
+		} else if errors.Is(err, service.ErrTooManyOrders) {
+			return nil, huma.Error400BadRequest("too many orders")
+		} else if errors.Is(err, service.ErrNoOrderHistory) {
+			return nil, huma.Error422UnprocessableEntity("product has no order history to simulate")
+		}
+		return nil, calculatePackagesError(err)
+	}
+
+	res := &SimulatePackSetResponse{
+		Body: SimulatePackSetResponseBody{
+			Orders:    simulation.Orders,
+			Current:   convertSimulationTotals(simulation.Current),
+			Candidate: convertSimulationTotals(simulation.Candidate),
+			Changed:   make([]SimulatedQuantityResponseBody, len(simulation.Changed)),
+		},
+	}
+	res.Body.ItemsDelta = simulation.Candidate.Items - simulation.Current.Items
+	res.Body.PacksDelta = simulation.Candidate.Packs - simulation.Current.Packs
+	res.Body.OverfillDelta = simulation.Candidate.Overfill - simulation.Current.Overfill
+	for i, changed := range simulation.Changed {
+		res.Body.Changed[i] = SimulatedQuantityResponseBody{
+			Units:     changed.Units,
+			Orders:    changed.Orders,
+			Current:   convertBatchResult(changed.Current),
+			Candidate: convertBatchResult(changed.Candidate),
+		}
+	}
+	return res, nil
+}
+
+func convertSimulationTotals(totals model.SimulationTotals) SimulationTotalsResponseBody {
+	return SimulationTotalsResponseBody{
+		PackageSizes: totals.PackageSizes,
+		Items:        totals.Items,
+		Packs:        totals.Packs,
+		Overfill:     totals.Overfill,
+		FailedOrders: totals.FailedOrders,
+	}
+}
+
+func (s *Server) RecommendPackSet(ctx context.Context, req *RecommendPackSetRequest) (*RecommendPackSetResponse, error) {
+	opts := service.RecommendOptions{
+		Sizes:   req.Body.Sizes,
+		MinSize: req.Body.MinSize,
+		MaxSize: req.Body.MaxSize,
+		Keep:    req.Body.Keep,
+	}
+	recommendation, err := s.packagesService.RecommendPackSet(ctx, req.ProductID, req.Body.Quantities, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRecommendation) {
+			return nil, huma.Error400BadRequest("invalid recommendation constraints")
+		} else if errors.Is(err, service.ErrTooManyOrders) {
+			return nil, huma.Error400BadRequest("too many orders")
+		} else if errors.Is(err, service.ErrNoOrderHistory) {
+			return nil, huma.Error422UnprocessableEntity("product has no order history to recommend for")
+		}
+		return nil, calculatePackagesError(err)
+	}
+
+	return &RecommendPackSetResponse{
+		Body: RecommendPackSetResponseBody{
+			Orders:      recommendation.Orders,
+			Recommended: convertRecommendationTotals(recommendation.Recommended, recommendation.Orders),
+			Current:     convertRecommendationTotals(recommendation.Current, recommendation.Orders),
+			Evaluated:   recommendation.Evaluated,
+		},
+	}, nil
+}
+
+func convertRecommendationTotals(totals model.SimulationTotals, orders int) RecommendationTotalsResponseBody {
+	res := RecommendationTotalsResponseBody{SimulationTotalsResponseBody: convertSimulationTotals(totals)}
+	if calculated := orders - totals.FailedOrders; calculated > 0 {
+		res.ExpectedOverfill = float64(totals.Overfill) / float64(calculated)
+		res.ExpectedPacks = float64(totals.Packs) / float64(calculated)
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+)
+
+// MaxBasketLines bounds the lines of a basket
+const MaxBasketLines = 1000
+
+var (
+	ErrEmptyBasket        = errors.New("basket has no lines")
+	ErrTooManyBasketLines = errors.New("basket has too many lines")
+	ErrInvalidUnits       = errors.New("units must be positive")
+)
+
+// CalculateBasket calculates the packages of every line of a basket like CalculatePackages does, loading all the
+// products at once. A line that can't be calculated carries its error instead of failing the basket.
+func (s *Packages) CalculateBasket(ctx context.Context, lines []model.BasketLine, opts CalculateOptions) (*model.Basket, error) {
+	if len(lines) == 0 {
+		return nil, ErrEmptyBasket
+	}
+	if len(lines) > MaxBasketLines {
+		return nil, ErrTooManyBasketLines
+	}
+
+	ids := make([]string, 0, len(lines))
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if !seen[line.ProductID] {
+			seen[line.ProductID] = true
+			ids = append(ids, line.ProductID)
+		}
+	}
+	products, err := s.storage.GetProductsWithPackageSizes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	basket := &model.Basket{Lines: make([]model.BasketLineResult, len(lines))}
+	for i, line := range lines {
+		result := &basket.Lines[i]
+		result.BasketLine = line
+
+		product, ok := byID[line.ProductID]
+		if !ok {
+			result.Err = ErrProductNotFound
+		} else if line.Units < 1 {
+			result.Err = ErrInvalidUnits
+		} else {
+			result.Package, result.Err = calculateProduct(product, line.Units, opts)
+		}
+		if result.Err != nil {
+			basket.FailedLines++
+			continue
+		}
+
+		items, packs := countItemsAndPacks(result.Package.PackageUnits)
+		// totals saturate rather than wrap around on absurd baskets
+		basket.Items = saturatingAdd(basket.Items, items)
+		basket.Packs = saturatingAdd(basket.Packs, packs)
+		basket.Overfill = saturatingAdd(basket.Overfill, items-line.Units)
+	}
+	return basket, nil
+}
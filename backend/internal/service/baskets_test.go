@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"slices"
+	"testing"
+)
+
+// given a basket with a good line, a missing product, a product without sizes and invalid units - test every line is reported
+func TestCalculateBasket(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: []model.Product{
+		{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500, 1000, 2000, 5000}},
+		{ID: "EMPTY", Name: "EMPTY"},
+	}}
+	service := NewPackageService(mockStorage)
+
+	basket, err := service.CalculateBasket(context.TODO(), []model.BasketLine{
+		{ProductID: "ABC", Units: 12001},
+		{ProductID: "MISSING", Units: 1},
+		{ProductID: "EMPTY", Units: 1},
+		{ProductID: "ABC", Units: 0},
+		{ProductID: "ABC", Units: 251},
+	}, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantErrs := []error{nil, ErrProductNotFound, ErrProductWithoutPackages, ErrInvalidUnits, nil}
+	for i, wantErr := range wantErrs {
+		if !errors.Is(basket.Lines[i].Err, wantErr) {
+			t.Fatalf("line %d: want %v got %v", i, wantErr, basket.Lines[i].Err)
+		}
+	}
+	want := []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 2000, Amount: 1}, {Size: 5000, Amount: 2}}
+	if !slices.Equal(basket.Lines[0].Package.PackageUnits, want) {
+		t.Fatalf("want %v got %v", want, basket.Lines[0].Package.PackageUnits)
+	}
+	if basket.Items != 12750 || basket.Packs != 5 || basket.Overfill != 498 || basket.FailedLines != 3 {
+		t.Fatalf("unexpected totals %+v", basket)
+	}
+}
+
+func TestCalculateBasketFailsOnStorageError(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantErr: errors.New("db is unhealthy")})
+
+	_, err := service.CalculateBasket(context.TODO(), []model.BasketLine{{ProductID: "ABC", Units: 1}}, CalculateOptions{})
+	if err == nil {
+		t.Fail()
+	}
+	_, err = service.CalculateBasket(context.TODO(), nil, CalculateOptions{})
+	if !errors.Is(err, ErrEmptyBasket) {
+		t.Fail()
+	}
+}
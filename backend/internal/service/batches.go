@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"runtime"
+	"sync"
+)
+
+// MaxBatchQuantities bounds the quantities of a batch
+const MaxBatchQuantities = 10_000
+
+// batchWorkers bounds the quantities of a batch calculated at the same time
+var batchWorkers = runtime.GOMAXPROCS(0)
+
+var (
+	ErrEmptyBatch        = errors.New("batch has no quantities")
+	ErrTooManyQuantities = errors.New("batch has too many quantities")
+)
+
+// CalculatePackagesBatch calculates the packages of a product for many quantities, like CalculatePackages does.
+// The product is loaded and its solver set up once, then the quantities are shared by a bounded pool of workers.
+// See calculateAll for the results.
+func (s *Packages) CalculatePackagesBatch(ctx context.Context, productID string, quantities []int, opts CalculateOptions) ([]model.BatchResult, error) {
+	if len(quantities) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(quantities) > MaxBatchQuantities {
+		return nil, ErrTooManyQuantities
+	}
+
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	calculate, err := prepareProduct(product, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateAll(ctx, calculate, quantities)
+}
+
+// calculateAll shares the quantities among a bounded pool of workers running calculate. Results are in the order
+// of the quantities, and a quantity that can't be calculated carries its error.
+func calculateAll(ctx context.Context, calculate func(units int) (*model.Package, error), quantities []int) ([]model.BatchResult, error) {
+	results := make([]model.BatchResult, len(quantities))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(quantities)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := &results[i]
+				result.Units = quantities[i]
+				if result.Units < 1 {
+					result.Err = ErrInvalidUnits
+					continue
+				}
+				result.Package, result.Err = calculate(result.Units)
+			}
+		}()
+	}
+
+feed:
+	for i := range quantities {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"slices"
+	"testing"
+)
+
+// given more quantities than workers, with an invalid one - test results keep the order of the quantities
+func TestCalculatePackagesBatch(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500, 1000, 2000, 5000}}}
+	service := NewPackageService(mockStorage)
+
+	quantities := []int{0}
+	for units := 1; units <= 2000; units += 7 {
+		quantities = append(quantities, units)
+	}
+	results, err := service.CalculatePackagesBatch(context.TODO(), "ABC", quantities, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(quantities) || !errors.Is(results[0].Err, ErrInvalidUnits) {
+		t.Fatalf("unexpected first result %+v", results[0])
+	}
+	for i, result := range results[1:] {
+		want, err := service.CalculatePackages(context.TODO(), "ABC", quantities[i+1], CalculateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Units != quantities[i+1] || result.Err != nil || !slices.Equal(result.Package.PackageUnits, want.PackageUnits) {
+			t.Fatalf("units %d: want %v got %+v", quantities[i+1], want.PackageUnits, result)
+		}
+	}
+}
+
+func TestCalculatePackagesBatchFails(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantErr: errors.New("db is unhealthy")})
+
+	_, err := service.CalculatePackagesBatch(context.TODO(), "ABC", []int{1}, CalculateOptions{})
+	if err == nil {
+		t.Fail()
+	}
+	_, err = service.CalculatePackagesBatch(context.TODO(), "ABC", nil, CalculateOptions{})
+	if !errors.Is(err, ErrEmptyBatch) {
+		t.Fail()
+	}
+	_, err = service.CalculatePackagesBatch(context.TODO(), "ABC", make([]int, MaxBatchQuantities+1), CalculateOptions{})
+	if !errors.Is(err, ErrTooManyQuantities) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"time"
+)
+
+func NewIdempotencyService(storage IdempotencyStorage, window time.Duration) *Idempotency {
+	return &Idempotency{
+		storage: storage,
+		window:  window,
+		now:     time.Now,
+	}
+}
+
+// Idempotency keeps the responses of requests sent with an idempotency key for a window, so retries of a request
+// replay its response instead of applying it twice.
+type Idempotency struct {
+	storage IdempotencyStorage
+	window  time.Duration
+	now     func() time.Time
+}
+
+type IdempotencyStorage interface {
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, response model.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+// MaxIdempotencyKeyLength bounds the length of an idempotency key
+const MaxIdempotencyKeyLength = 255
+
+// idempotencyLease bounds how long a request holds its key without a response, so the key of a request whose server
+// died while handling it is free again for a retry. It's far longer than any request takes to handle.
+const idempotencyLease = time.Minute
+
+var (
+	ErrInvalidIdempotencyKey     = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused      = errors.New("idempotency key reused with a different request")
+	ErrIdempotentRequestInFlight = errors.New("request with the same idempotency key in progress")
+)
+
+// Begin reserves a key for a request identified by its fingerprint. It returns nil when the request has to be
+// handled, then Complete or Abort must be called, or the response to replay when it was already handled.
+func (s *Idempotency) Begin(ctx context.Context, key, fingerprint string) (*model.IdempotentResponse, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	now := s.now()
+	stored, err := s.storage.ReserveIdempotencyKey(ctx, key, fingerprint, now.Add(-s.window), now.Add(-min(idempotencyLease, s.window)))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if stored.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if stored.Status == 0 {
+		return nil, ErrIdempotentRequestInFlight
+	}
+	return stored, nil
+}
+
+// Complete stores the response of a request begun with its key and fingerprint.
+func (s *Idempotency) Complete(ctx context.Context, response model.IdempotentResponse) error {
+	return s.storage.SaveIdempotentResponse(ctx, response)
+}
+
+// Abort frees the key of a request that should be retried rather than replayed, eg. after a server error.
+func (s *Idempotency) Abort(ctx context.Context, key string) error {
+	return s.storage.DeleteIdempotencyKey(ctx, key)
+}
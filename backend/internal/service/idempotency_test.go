@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// given a key - test the request is handled once, then replayed, and a different request is refused
+func TestIdempotencyReplay(t *testing.T) {
+	mockStorage := &mockIdempotencyStorage{responses: map[string]model.IdempotentResponse{}}
+	service := NewIdempotencyService(mockStorage, time.Hour)
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	stored, err := service.Begin(context.TODO(), "KEY", "A")
+	if err != nil || stored != nil {
+		t.Fatalf("want a free key got %v %v", stored, err)
+	}
+	if !mockStorage.expiredBefore.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("want keys expired before %v got %v", now.Add(-time.Hour), mockStorage.expiredBefore)
+	}
+	if !mockStorage.abandonedBefore.Equal(now.Add(-idempotencyLease)) {
+		t.Fatalf("want keys abandoned before %v got %v", now.Add(-idempotencyLease), mockStorage.abandonedBefore)
+	}
+	if _, err = service.Begin(context.TODO(), "KEY", "A"); !errors.Is(err, ErrIdempotentRequestInFlight) {
+		t.Fatalf("want %v got %v", ErrIdempotentRequestInFlight, err)
+	}
+
+	err = service.Complete(context.TODO(), model.IdempotentResponse{Key: "KEY", Fingerprint: "A", Status: 201, Body: []byte("{}")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = service.Begin(context.TODO(), "KEY", "A")
+	if err != nil || stored == nil || stored.Status != 201 {
+		t.Fatalf("want the stored response got %v %v", stored, err)
+	}
+	if _, err = service.Begin(context.TODO(), "KEY", "B"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("want %v got %v", ErrIdempotencyKeyReused, err)
+	}
+}
+
+func TestIdempotencyAbort(t *testing.T) {
+	service := NewIdempotencyService(&mockIdempotencyStorage{responses: map[string]model.IdempotentResponse{}}, time.Hour)
+
+	if _, err := service.Begin(context.TODO(), "KEY", "A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Abort(context.TODO(), "KEY"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := service.Begin(context.TODO(), "KEY", "A"); err != nil || stored != nil {
+		t.Fatalf("want a free key got %v %v", stored, err)
+	}
+}
+
+func TestIdempotencyInvalidKey(t *testing.T) {
+	service := NewIdempotencyService(&mockIdempotencyStorage{responses: map[string]model.IdempotentResponse{}}, time.Hour)
+
+	for _, key := range []string{"", strings.Repeat("k", MaxIdempotencyKeyLength+1)} {
+		if _, err := service.Begin(context.TODO(), key, "A"); !errors.Is(err, ErrInvalidIdempotencyKey) {
+			t.Fatalf("want %v got %v", ErrInvalidIdempotencyKey, err)
+		}
+	}
+}
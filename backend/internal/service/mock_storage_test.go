@@ -3,11 +3,15 @@ package service
 import (
 	"context"
 	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"slices"
+	"time"
 )
 
 type mockPackageStorage struct {
-	wantRes interface{}
-	wantErr error
+	wantRes      interface{}
+	wantErr      error
+	orderHistory []int
 }
 
 func (m *mockPackageStorage) GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error) {
@@ -16,19 +20,61 @@ func (m *mockPackageStorage) GetProductWithPackageSizes(ctx context.Context, id
 	}
 	return (m.wantRes).(*model.Product), nil
 }
-func (m *mockPackageStorage) AddPackageSize(ctx context.Context, productId string, size int) error {
+func (m *mockPackageStorage) GetProductsWithPackageSizes(ctx context.Context, ids []string) ([]model.Product, error) {
+	if m.wantErr != nil {
+		return nil, m.wantErr
+	}
+	res := []model.Product{}
+	for _, product := range (m.wantRes).([]model.Product) {
+		if slices.Contains(ids, product.ID) {
+			res = append(res, product)
+		}
+	}
+	return res, nil
+}
+func (m *mockPackageStorage) AddPackageSize(ctx context.Context, productId string, size int, price *model.PackagePrice, ifVersion int) error {
+	return m.wantErr
+}
+func (m *mockPackageStorage) RemovePackageSize(ctx context.Context, productId string, size int, ifVersion int) error {
+	return m.wantErr
+}
+func (m *mockPackageStorage) ReplacePackageSizes(ctx context.Context, productId string, sizes []int, ifVersion int) error {
+	if m.wantErr != nil {
+		return m.wantErr
+	}
+	m.wantRes.(*model.Product).PackageSizes = sizes
+	return nil
+}
+func (m *mockPackageStorage) SetPackageSizePrice(ctx context.Context, productId string, size int, price *model.PackagePrice, ifVersion int) error {
 	return m.wantErr
 }
-func (m *mockPackageStorage) RemovePackageSize(ctx context.Context, productId string, size int) error {
+func (m *mockPackageStorage) SetPackageSizeStock(ctx context.Context, productId string, size int, stock *int, ifVersion int) error {
 	return m.wantErr
 }
+func (m *mockPackageStorage) AdjustPackageSizeStock(ctx context.Context, productId string, size int, delta int, ifVersion int) error {
+	return m.wantErr
+}
+func (m *mockPackageStorage) SetProductMaxOverfill(ctx context.Context, productId string, limit model.OverfillLimit, ifVersion int) error {
+	return m.wantErr
+}
+func (m *mockPackageStorage) SetOrderHistory(ctx context.Context, productId string, quantities []int) error {
+	return m.wantErr
+}
+func (m *mockPackageStorage) GetOrderHistory(ctx context.Context, productId string) ([]int, error) {
+	if m.wantErr != nil {
+		return nil, m.wantErr
+	}
+	return m.orderHistory, nil
+}
 
 type mockProductStorage struct {
 	wantRes interface{}
 	wantErr error
+	query   model.ProductQuery
 }
 
-func (m *mockProductStorage) ListProducts(ctx context.Context) ([]model.Product, error) {
+func (m *mockProductStorage) ListProducts(ctx context.Context, query model.ProductQuery) ([]model.Product, error) {
+	m.query = query
 	if m.wantErr != nil {
 		return nil, m.wantErr
 	}
@@ -46,6 +92,87 @@ func (m *mockProductStorage) CreateProduct(ctx context.Context, product model.Pr
 	}
 	return (m.wantRes).(*model.Product), nil
 }
-func (m *mockProductStorage) DeleteProduct(ctx context.Context, id string) error {
+func (m *mockProductStorage) UpdateProductName(ctx context.Context, id string, name string, ifVersion int) error {
+	if m.wantErr != nil {
+		return m.wantErr
+	}
+	m.wantRes.(*model.Product).Name = name
+	return nil
+}
+func (m *mockProductStorage) DeleteProduct(ctx context.Context, id string, ifVersion int) error {
 	return m.wantErr
 }
+
+type mockOrderStorage struct {
+	product *model.Product
+	orders  map[string]*model.Order
+	wantErr error
+}
+
+func (m *mockOrderStorage) GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error) {
+	if m.product == nil {
+		return nil, storage.ErrProductNotFound
+	}
+	return m.product, nil
+}
+func (m *mockOrderStorage) CreateOrder(ctx context.Context, order model.Order) (*model.Order, error) {
+	if m.wantErr != nil {
+		return nil, m.wantErr
+	}
+	order.ID = "ORDER"
+	m.orders[order.ID] = &order
+	return &order, nil
+}
+func (m *mockOrderStorage) GetOrder(ctx context.Context, id string) (*model.Order, error) {
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, storage.ErrOrderNotFound
+	}
+	res := *order
+	return &res, nil
+}
+func (m *mockOrderStorage) ListOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, error) {
+	res := []model.Order{}
+	for _, order := range m.orders {
+		if (filter.ProductID == "" || order.ProductID == filter.ProductID) && (filter.State == "" || order.State == filter.State) {
+			res = append(res, *order)
+		}
+	}
+	return res, nil
+}
+func (m *mockOrderStorage) UpdateOrderState(ctx context.Context, id string, from, to string) error {
+	if m.wantErr != nil {
+		return m.wantErr
+	}
+	order, ok := m.orders[id]
+	if !ok {
+		return storage.ErrOrderNotFound
+	}
+	if order.State != from {
+		return storage.ErrOrderStateChanged
+	}
+	order.State = to
+	return nil
+}
+
+type mockIdempotencyStorage struct {
+	responses                      map[string]model.IdempotentResponse
+	expiredBefore, abandonedBefore time.Time
+}
+
+func (m *mockIdempotencyStorage) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*model.IdempotentResponse, error) {
+	m.expiredBefore, m.abandonedBefore = expiredBefore, abandonedBefore
+	if stored, ok := m.responses[key]; ok {
+		return &stored, nil
+	}
+	m.responses[key] = model.IdempotentResponse{Key: key, Fingerprint: fingerprint}
+	return nil, nil
+}
+func (m *mockIdempotencyStorage) SaveIdempotentResponse(ctx context.Context, response model.IdempotentResponse) error {
+	m.responses[response.Key] = response
+	return nil
+}
+func (m *mockIdempotencyStorage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	delete(m.responses, key)
+	return nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"gymshark-interview/internal/model"
+	"math"
+)
+
+// Objective names the order in which the solvers rank the packings of an order.
+// Whichever the objective, only whole packs are shipped and remaining ties prefer bigger package sizes.
+type Objective string
+
+const (
+	// ObjectiveItemsFirst ships the fewest items, then the fewest packs, as described in docs/REQUIREMENTS.md.
+	ObjectiveItemsFirst Objective = "items-first"
+	// ObjectivePacksFirst ships the fewest packs, then the fewest items. Without a maximum overfill it never
+	// ships a biggest pack worth of items over the order.
+	ObjectivePacksFirst Objective = "packs-first"
+	// ObjectiveExact ships exactly the ordered items in the fewest packs, or nothing.
+	ObjectiveExact Objective = "exact"
+	// ObjectiveCost ships the packs with the lowest total cost, then the fewest items, then the fewest packs.
+	// Only the package sizes with a price are used and they must share a currency.
+	ObjectiveCost Objective = "cost"
+
+	DefaultObjective = ObjectiveItemsFirst
+)
+
+var (
+	ErrUnknownObjective      = errors.New("unknown objective")
+	ErrObjectiveNotSupported = errors.New("objective not supported by solver")
+	ErrNoFeasiblePacking     = errors.New("no packing satisfies the objective")
+	ErrInvalidMaxOverfill    = errors.New("maximum overfill can't be negative")
+	ErrMissingPackagePrices  = errors.New("no package size has a price")
+	ErrMixedCurrencies       = errors.New("package sizes are priced in different currencies")
+)
+
+// ParseObjective returns the objective with the given name, or the default objective when name is empty.
+func ParseObjective(name string) (Objective, error) {
+	switch Objective(name) {
+	case "":
+		return DefaultObjective, nil
+	case ObjectiveItemsFirst, ObjectivePacksFirst, ObjectiveExact, ObjectiveCost:
+		return Objective(name), nil
+	}
+	return "", ErrUnknownObjective
+}
+
+// Order is what a Solver is asked to pack.
+type Order struct {
+	Units        int
+	PackageSizes []int
+	Objective    Objective
+	// MaxOverfill caps the items shipped over Units, nil means no cap.
+	MaxOverfill *int
+	// PackagePrices are only used by the cost objective.
+	PackagePrices []model.PackagePrice
+	// Stock limits the packs of the package sizes it lists, the others have unlimited supply.
+	Stock []model.PackageStock
+}
+
+func (o Order) overflow(reason string) error {
+	return &OverflowError{Units: o.Units, Reason: reason}
+}
+
+func (o Order) limit(reason string) error {
+	return &LimitError{Units: o.Units, Reason: reason}
+}
+
+// totalBounds returns the normalised amounts of items the order may ship, from lo to hi.
+// hi is capped at the biggest amount that can be represented once multiplied back by the divisor.
+func (r *reachability) totalBounds(order Order) (lo, hi int) {
+	units := max(order.Units, 0)
+	lo = ceilDiv(units, r.divisor)
+	hi = math.MaxInt / r.divisor
+
+	if order.MaxOverfill != nil {
+		limit := math.MaxInt
+		if units <= math.MaxInt-*order.MaxOverfill {
+			limit = units + *order.MaxOverfill
+		}
+		hi = min(hi, limit/r.divisor)
+	}
+	switch order.Objective {
+	case ObjectiveExact:
+		hi = min(hi, units/r.divisor)
+	case ObjectivePacksFirst, ObjectiveCost:
+		// as many biggest packs as needed is never more than a biggest pack over the order, and with any more
+		// items one pack could be dropped
+		if lo <= math.MaxInt-r.largest {
+			hi = min(hi, lo+r.largest-1)
+		}
+	}
+	return lo, hi
+}
+
+// itemsFirstTotal returns the normalised amount of items to ship for the items-first and exact objectives.
+func (r *reachability) itemsFirstTotal(order Order) (int, error) {
+	lo, hi := r.totalBounds(order)
+	total := r.minTotal(lo)
+	if total < 0 || total > math.MaxInt/r.divisor {
+		return 0, order.overflow("the amount of items to ship exceeds the maximum int")
+	}
+	if total > hi {
+		return 0, ErrNoFeasiblePacking
+	}
+	return total, nil
+}
+
+// normaliseOrder checks the parts of an order every solver relies on and fills in the default objective.
+func normaliseOrder(order Order) (Order, error) {
+	objective, err := ParseObjective(string(order.Objective))
+	if err != nil {
+		return order, err
+	}
+	order.Objective = objective
+	if order.MaxOverfill != nil && *order.MaxOverfill < 0 {
+		return order, ErrInvalidMaxOverfill
+	}
+	return order, nil
+}
+
+// countItemsAndPacks returns the amount of items and packs of a packing.
+func countItemsAndPacks(packageUnits []model.PackageUnit) (int, int) {
+	items, packs := 0, 0
+	for _, packageUnit := range packageUnits {
+		items += packageUnit.Amount * packageUnit.Size
+		packs += packageUnit.Amount
+	}
+	return items, packs
+}
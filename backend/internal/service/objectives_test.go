@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// bruteForce ranks every amount from units up to units+window with a plain dynamic programming table.
+func bruteForce(order Order, window int) (items, packs int, ok bool) {
+	fewest := make([]int, order.Units+window+1)
+	for i := range fewest {
+		fewest[i] = -1
+	}
+	fewest[0] = 0
+	for amount := 1; amount < len(fewest); amount++ {
+		for _, size := range order.PackageSizes {
+			if size <= amount && fewest[amount-size] >= 0 && (fewest[amount] < 0 || fewest[amount-size]+1 < fewest[amount]) {
+				fewest[amount] = fewest[amount-size] + 1
+			}
+		}
+	}
+
+	for amount := order.Units; amount < len(fewest); amount++ {
+		if fewest[amount] < 0 || (order.MaxOverfill != nil && amount-order.Units > *order.MaxOverfill) {
+			continue
+		}
+		if order.Objective == ObjectiveExact && amount != order.Units {
+			continue
+		}
+		better := !ok
+		if order.Objective == ObjectivePacksFirst {
+			better = better || fewest[amount] < packs
+		}
+		if better {
+			items, packs, ok = amount, fewest[amount], true
+		}
+	}
+	return items, packs, ok
+}
+
+// given random package sizes - the exact solvers rank packings like a brute force search for every objective
+func TestObjectivesMatchBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 1000 {
+		packageSizes := make([]int, 1+random.Intn(4))
+		for i := range packageSizes {
+			packageSizes[i] = 1 + random.Intn(40)
+		}
+		order := Order{
+			Units:        1 + random.Intn(2000),
+			PackageSizes: packageSizes,
+			Objective:    []Objective{ObjectiveItemsFirst, ObjectivePacksFirst, ObjectiveExact}[random.Intn(3)],
+		}
+		if random.Intn(2) == 0 {
+			maxOverfill := random.Intn(50)
+			order.MaxOverfill = &maxOverfill
+		}
+
+		wantItems, wantPacks, ok := bruteForce(order, slices.Max(packageSizes))
+		for _, name := range []string{SolverDP, SolverBranchAndBound} {
+			res, err := solvers[name].Solve(Order{
+				Units:        order.Units,
+				PackageSizes: slices.Clone(order.PackageSizes),
+				Objective:    order.Objective,
+				MaxOverfill:  order.MaxOverfill,
+			})
+			if !ok {
+				if !errors.Is(err, ErrNoFeasiblePacking) {
+					t.Fatalf("%s %+v: want no feasible packing, got %v %v", name, order, res, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s %+v: %v", name, order, err)
+			}
+			items, packs := countItemsAndPacks(res)
+			if items != wantItems || packs != wantPacks {
+				t.Fatalf("%s %+v: want %d items in %d packs, got %v", name, order, wantItems, wantPacks, res)
+			}
+		}
+	}
+}
+
+// given 250 500 1000 2000 5000 Items - test each objective on 251 and 12001
+func TestCalculatePackagesObjectives(t *testing.T) {
+	mockStorage := &mockPackageStorage{
+		wantRes: &model.Product{
+			ID:           uuid.NewString(),
+			Name:         "ABC",
+			PackageSizes: []int{250, 500, 1000, 2000, 5000},
+		},
+	}
+	service := NewPackageService(mockStorage)
+	overfill := func(n int) *int { return &n }
+
+	tests := []struct {
+		quantity    int
+		objective   Objective
+		maxOverfill *int
+		expectedRes []model.PackageUnit
+		expectedErr error
+	}{
+		{quantity: 251, objective: ObjectivePacksFirst, expectedRes: []model.PackageUnit{{Size: 500, Amount: 1}}},
+		{quantity: 12001, objective: ObjectivePacksFirst, expectedRes: []model.PackageUnit{{Size: 5000, Amount: 3}}},
+		{
+			quantity: 12001, objective: ObjectivePacksFirst, maxOverfill: overfill(1000),
+			expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 2000, Amount: 1}, {Size: 5000, Amount: 2}},
+		},
+		{quantity: 12001, objective: ObjectivePacksFirst, maxOverfill: overfill(100), expectedErr: ErrNoFeasiblePacking},
+		{quantity: 12001, objective: ObjectiveItemsFirst, maxOverfill: overfill(0), expectedErr: ErrNoFeasiblePacking},
+		{quantity: 12001, objective: ObjectiveExact, expectedErr: ErrNoFeasiblePacking},
+		{
+			quantity: 12250, objective: ObjectiveExact,
+			expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 2000, Amount: 1}, {Size: 5000, Amount: 2}},
+		},
+		{quantity: 12001, objective: "cheapest", expectedErr: ErrUnknownObjective},
+	}
+
+	for _, testCase := range tests {
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{
+			Objective:   testCase.objective,
+			MaxOverfill: testCase.maxOverfill,
+		})
+		if testCase.expectedErr != nil {
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("%s quantity %d: want %v got %v", testCase.objective, testCase.quantity, testCase.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s quantity %d: %v", testCase.objective, testCase.quantity, err)
+		}
+		if !slices.Equal(res.PackageUnits, testCase.expectedRes) || res.Objective != string(testCase.objective) {
+			t.Fatalf("%s quantity %d: want %v got %v", testCase.objective, testCase.quantity, testCase.expectedRes, res)
+		}
+	}
+}
+
+func TestGreedySolverObjectiveNotSupported(t *testing.T) {
+	_, err := solvers[SolverGreedy].Solve(Order{Units: 12001, PackageSizes: []int{250, 500}, Objective: ObjectivePacksFirst})
+	if !errors.Is(err, ErrObjectiveNotSupported) {
+		t.Fail()
+	}
+}
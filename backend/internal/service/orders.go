@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"slices"
+)
+
+func NewOrderService(storage OrdersStorage) *Orders {
+	return &Orders{
+		storage: storage,
+	}
+}
+
+type Orders struct {
+	storage OrdersStorage
+}
+
+type OrdersStorage interface {
+	GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error)
+	CreateOrder(ctx context.Context, order model.Order) (*model.Order, error)
+	GetOrder(ctx context.Context, id string) (*model.Order, error)
+	ListOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, error)
+	UpdateOrderState(ctx context.Context, id string, from, to string) error
+}
+
+// States of an order. An order is quoted when created and moves forward to shipped, or to cancelled until it ships.
+const (
+	OrderQuoted    = "quoted"
+	OrderConfirmed = "confirmed"
+	OrderPicked    = "picked"
+	OrderShipped   = "shipped"
+	OrderCancelled = "cancelled"
+)
+
+// orderTransitions are the states each state can move to
+var orderTransitions = map[string][]string{
+	OrderQuoted:    {OrderConfirmed, OrderCancelled},
+	OrderConfirmed: {OrderPicked, OrderCancelled},
+	OrderPicked:    {OrderShipped, OrderCancelled},
+	OrderShipped:   {},
+	OrderCancelled: {},
+}
+
+var (
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrInvalidOrderState    = errors.New("invalid order state")
+	ErrInvalidTransition    = errors.New("invalid order state transition")
+	ErrConcurrentTransition = errors.New("order state changed concurrently")
+)
+
+// Create calculates the packages of a product like CalculatePackages does and records them as a quoted order, with
+// the package sizes the product had at the time.
+func (s *Orders) Create(ctx context.Context, productID string, units int, opts CalculateOptions) (*model.Order, error) {
+	if units < 1 {
+		return nil, ErrInvalidUnits
+	}
+	product, err := s.storage.GetProductWithPackageSizes(ctx, productID)
+	if err != nil {
+		if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	opts.Alternatives, opts.Explain = 0, false
+	pkg, err := calculateProduct(product, units, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.CreateOrder(ctx, model.Order{
+		ProductID:    productID,
+		Units:        units,
+		State:        OrderQuoted,
+		Package:      *pkg,
+		PackageSizes: slices.Sorted(slices.Values(product.PackageSizes)),
+	})
+}
+
+func (s *Orders) Get(ctx context.Context, id string) (*model.Order, error) {
+	order, err := s.storage.GetOrder(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *Orders) List(ctx context.Context, filter model.OrderFilter) ([]model.Order, error) {
+	if _, ok := orderTransitions[filter.State]; filter.State != "" && !ok {
+		return nil, ErrInvalidOrderState
+	}
+	return s.storage.ListOrders(ctx, filter)
+}
+
+// Transition moves an order to a state, when its current state allows it.
+func (s *Orders) Transition(ctx context.Context, id string, state string) (*model.Order, error) {
+	if _, ok := orderTransitions[state]; !ok {
+		return nil, ErrInvalidOrderState
+	}
+	order, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(orderTransitions[order.State], state) {
+		return nil, ErrInvalidTransition
+	}
+
+	err = s.storage.UpdateOrderState(ctx, id, order.State, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrOrderNotFound):
+			return nil, ErrOrderNotFound
+		case errors.Is(err, storage.ErrOrderStateChanged):
+			return nil, ErrConcurrentTransition
+		}
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
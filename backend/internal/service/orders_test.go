@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"slices"
+	"testing"
+)
+
+// given a quoted order - test it snapshots the package sizes, and later changes to the product don't alter it
+func TestCreateOrder(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{500, 250}}
+	mockStorage := &mockOrderStorage{product: product, orders: map[string]*model.Order{}}
+	service := NewOrderService(mockStorage)
+
+	order, err := service.Create(context.TODO(), "ABC", 501, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.State != OrderQuoted || order.Units != 501 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if !slices.Equal(order.Package.PackageUnits, []model.PackageUnit{{Amount: 1, Size: 250}, {Amount: 1, Size: 500}}) {
+		t.Fatalf("unexpected package units %v", order.Package.PackageUnits)
+	}
+
+	product.PackageSizes[0] = 750
+	if !slices.Equal(order.PackageSizes, []int{250, 500}) {
+		t.Fatalf("unexpected package sizes %v", order.PackageSizes)
+	}
+}
+
+func TestCreateOrderErrors(t *testing.T) {
+	service := NewOrderService(&mockOrderStorage{orders: map[string]*model.Order{}})
+	if _, err := service.Create(context.TODO(), "ABC", 501, CalculateOptions{}); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("want %v got %v", ErrProductNotFound, err)
+	}
+
+	service = NewOrderService(&mockOrderStorage{product: &model.Product{ID: "ABC"}, orders: map[string]*model.Order{}})
+	if _, err := service.Create(context.TODO(), "ABC", 0, CalculateOptions{}); !errors.Is(err, ErrInvalidUnits) {
+		t.Fatalf("want %v got %v", ErrInvalidUnits, err)
+	}
+	if _, err := service.Create(context.TODO(), "ABC", 501, CalculateOptions{}); !errors.Is(err, ErrProductWithoutPackages) {
+		t.Fatalf("want %v got %v", ErrProductWithoutPackages, err)
+	}
+}
+
+func TestOrderTransitions(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  error
+	}{
+		{from: OrderQuoted, to: OrderConfirmed},
+		{from: OrderQuoted, to: OrderCancelled},
+		{from: OrderQuoted, to: OrderPicked, wantErr: ErrInvalidTransition},
+		{from: OrderConfirmed, to: OrderPicked},
+		{from: OrderConfirmed, to: OrderQuoted, wantErr: ErrInvalidTransition},
+		{from: OrderPicked, to: OrderShipped},
+		{from: OrderPicked, to: OrderCancelled},
+		{from: OrderShipped, to: OrderCancelled, wantErr: ErrInvalidTransition},
+		{from: OrderCancelled, to: OrderConfirmed, wantErr: ErrInvalidTransition},
+		{from: OrderQuoted, to: "lost", wantErr: ErrInvalidOrderState},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			mockStorage := &mockOrderStorage{orders: map[string]*model.Order{"ORDER": {ID: "ORDER", State: tt.from}}}
+			service := NewOrderService(mockStorage)
+
+			order, err := service.Transition(context.TODO(), "ORDER", tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v got %v", tt.wantErr, err)
+			}
+			if err == nil && order.State != tt.to {
+				t.Fatalf("want state %s got %s", tt.to, order.State)
+			}
+		})
+	}
+}
+
+func TestOrderTransitionStorageErrors(t *testing.T) {
+	service := NewOrderService(&mockOrderStorage{orders: map[string]*model.Order{}})
+	if _, err := service.Transition(context.TODO(), "ORDER", OrderConfirmed); !errors.Is(err, ErrOrderNotFound) {
+		t.Fatalf("want %v got %v", ErrOrderNotFound, err)
+	}
+
+	service = NewOrderService(&mockOrderStorage{
+		orders:  map[string]*model.Order{"ORDER": {ID: "ORDER", State: OrderQuoted}},
+		wantErr: storage.ErrOrderStateChanged,
+	})
+	if _, err := service.Transition(context.TODO(), "ORDER", OrderConfirmed); !errors.Is(err, ErrConcurrentTransition) {
+		t.Fatalf("want %v got %v", ErrConcurrentTransition, err)
+	}
+}
+
+func TestListOrdersInvalidState(t *testing.T) {
+	service := NewOrderService(&mockOrderStorage{orders: map[string]*model.Order{}})
+	if _, err := service.List(context.TODO(), model.OrderFilter{State: "lost"}); !errors.Is(err, ErrInvalidOrderState) {
+		t.Fatalf("want %v got %v", ErrInvalidOrderState, err)
+	}
+}
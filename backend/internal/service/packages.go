@@ -5,6 +5,7 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/storage"
+	"slices"
 )
 
 func NewPackageService(storage PackagesStorage) *Packages {
@@ -17,40 +18,109 @@ type Packages struct {
 	storage PackagesStorage
 }
 
-var ErrProductNotFound = errors.New("product not found")
+// MaxUnitCost bounds the unit cost of a package size, in minor units of its currency
+const MaxUnitCost = 1_000_000_000
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrPackageSizeNotFound = errors.New("package size not found")
+	ErrInvalidPrice        = errors.New("invalid package size price")
+	// ErrPreconditionFailed is returned when a change expects a version of the product it's no longer at.
+	ErrPreconditionFailed = errors.New("product version doesn't match")
+)
 
 type PackagesStorage interface {
 	GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error)
-	AddPackageSize(ctx context.Context, productId string, size int) error
-	RemovePackageSize(ctx context.Context, productId string, size int) error
+	GetProductsWithPackageSizes(ctx context.Context, ids []string) ([]model.Product, error)
+	AddPackageSize(ctx context.Context, productId string, size int, price *model.PackagePrice, ifVersion int) error
+	RemovePackageSize(ctx context.Context, productId string, size int, ifVersion int) error
+	ReplacePackageSizes(ctx context.Context, productId string, sizes []int, ifVersion int) error
+	SetPackageSizePrice(ctx context.Context, productId string, size int, price *model.PackagePrice, ifVersion int) error
+	SetPackageSizeStock(ctx context.Context, productId string, size int, stock *int, ifVersion int) error
+	AdjustPackageSizeStock(ctx context.Context, productId string, size int, delta int, ifVersion int) error
+	SetProductMaxOverfill(ctx context.Context, productId string, limit model.OverfillLimit, ifVersion int) error
+	SetOrderHistory(ctx context.Context, productId string, quantities []int) error
+	GetOrderHistory(ctx context.Context, productId string) ([]int, error)
 }
 
-func (s *Packages) AddPackageSize(ctx context.Context, productID string, size int) (*model.Product, error) {
-	err := s.storage.AddPackageSize(ctx, productID, size)
+// AddPackageSize adds a package size to a product, with an optional price. Like the other changes to a product, a
+// non-zero ifVersion makes it fail with ErrPreconditionFailed unless the product is at that version.
+func (s *Packages) AddPackageSize(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) (*model.Product, error) {
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	err := s.storage.AddPackageSize(ctx, productID, size, price, ifVersion)
 	if err != nil {
 		if errors.Is(err, storage.ErrConstraintViolation) {
 			return nil, ErrConstraintViolation
+		} else if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
 		}
 		return nil, err
 	}
-	product, err := s.storage.GetProductWithPackageSizes(ctx, productID)
+	return s.getProduct(ctx, productID)
+}
+
+func (s *Packages) RemovePackageSize(ctx context.Context, productID string, size int, ifVersion int) (*model.Product, error) {
+	err := s.storage.RemovePackageSize(ctx, productID, size, ifVersion)
 	if err != nil {
 		if errors.Is(err, storage.ErrProductNotFound) {
 			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
 		}
 		return nil, err
 	}
-	return product, nil
+	return s.getProduct(ctx, productID)
 }
 
-func (s *Packages) RemovePackageSize(ctx context.Context, productID string, size int) (*model.Product, error) {
-	err := s.storage.RemovePackageSize(ctx, productID, size)
+// ReplacePackageSizes replaces the package sizes of a product at once. Sizes the product already has keep their
+// price and stock, and like on creation a size given twice is a constraint violation.
+func (s *Packages) ReplacePackageSizes(ctx context.Context, productID string, sizes []int, ifVersion int) (*model.Product, error) {
+	if slices.ContainsFunc(sizes, func(size int) bool { return size < 1 }) {
+		return nil, ErrInvalidPackageSizes
+	}
+	sizes = slices.Sorted(slices.Values(sizes))
+	if len(slices.Compact(slices.Clone(sizes))) != len(sizes) {
+		return nil, ErrConstraintViolation
+	}
+	err := s.storage.ReplacePackageSizes(ctx, productID, sizes, ifVersion)
 	if err != nil {
 		if errors.Is(err, storage.ErrConstraintViolation) {
 			return nil, ErrConstraintViolation
+		} else if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
 		}
 		return nil, err
 	}
+	return s.getProduct(ctx, productID)
+}
+
+// SetPackageSizePrice sets the price of an existing package size of a product, a nil price clears it.
+func (s *Packages) SetPackageSizePrice(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) (*model.Product, error) {
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	err := s.storage.SetPackageSizePrice(ctx, productID, size, price, ifVersion)
+	if err != nil {
+		if errors.Is(err, storage.ErrPackageSizeNotFound) {
+			return nil, ErrPackageSizeNotFound
+		} else if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+	return s.getProduct(ctx, productID)
+}
+
+// getProduct returns the product with its package sizes after a change.
+func (s *Packages) getProduct(ctx context.Context, productID string) (*model.Product, error) {
 	product, err := s.storage.GetProductWithPackageSizes(ctx, productID)
 	if err != nil {
 		if errors.Is(err, storage.ErrProductNotFound) {
@@ -60,3 +130,18 @@ func (s *Packages) RemovePackageSize(ctx context.Context, productID string, size
 	}
 	return product, nil
 }
+
+func validatePrice(price *model.PackagePrice) error {
+	if price == nil {
+		return nil
+	}
+	if price.UnitCost < 0 || price.UnitCost > MaxUnitCost || len(price.Currency) != 3 {
+		return ErrInvalidPrice
+	}
+	for _, c := range price.Currency {
+		if c < 'A' || c > 'Z' {
+			return ErrInvalidPrice
+		}
+	}
+	return nil
+}
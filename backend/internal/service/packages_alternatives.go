@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"gymshark-interview/internal/model"
+	"maps"
+	"math"
+	"slices"
+)
+
+const (
+	// MaxAlternatives bounds the packings listed for a single calculation
+	MaxAlternatives = 20
+	// maxAlternativeNodes bounds the search of alternatives like maxBranchAndBoundNodes bounds the solver
+	maxAlternativeNodes = maxBranchAndBoundNodes
+)
+
+var ErrInvalidAlternatives = errors.New("invalid amount of alternatives")
+
+// alternatives returns up to k packings of the order, best first, ranked by its objective with remaining ties
+// preferring bigger package sizes. Only packings where no pack can be dropped while still covering the order are
+// listed, so there is a finite amount of them whatever the order.
+//
+// Like the branch and bound solver, a depth first search tries the biggest package sizes first and prunes any
+// branch whose lower bound can't beat the k-th packing found so far. For the cost objective, the packs of a size
+// more expensive per item than the smaller ones are tried from the least so that cheap packings are found early.
+func alternatives(order Order, k int, maxNodes int) ([][]model.PackageUnit, error) {
+	order, err := normaliseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	sizes := order.PackageSizes
+	var unitCost map[int]int64
+	if order.Objective == ObjectiveCost {
+		if unitCost, err = unitCosts(order); err != nil {
+			return nil, err
+		}
+		sizes = slices.Collect(maps.Keys(unitCost))
+	}
+	// sizes out of stock can't be used at all
+	available := map[int]int{}
+	for _, stock := range order.Stock {
+		available[stock.Size] = stock.Available
+	}
+	sizes = slices.DeleteFunc(slices.Clone(sizes), func(size int) bool {
+		stock, ok := available[size]
+		return ok && stock <= 0
+	})
+	if len(sizes) == 0 {
+		return nil, ErrNoFeasiblePacking
+	}
+
+	r, err := newReachability(sizes)
+	if err != nil {
+		return nil, order.limit(err.Error())
+	}
+	s := &alternativeSearch{
+		sizes:     r.sizes,
+		limits:    make([]int, len(r.sizes)),
+		costs:     make([]int, len(r.sizes)),
+		monotone:  make([]bool, len(r.sizes)),
+		ascending: make([]bool, len(r.sizes)),
+		objective: order.Objective,
+		k:         k,
+		counts:    make([]int, len(r.sizes)),
+		nodesLeft: maxNodes,
+	}
+	s.lo, s.hi = r.totalBounds(order)
+	// with a biggest pack worth of items over the order, one pack could be dropped
+	if s.lo <= math.MaxInt-r.largest {
+		s.hi = min(s.hi, s.lo+r.largest-1)
+	}
+	if s.minTotal = r.minTotal(s.lo); s.minTotal < 0 || s.minTotal > s.hi {
+		return nil, ErrNoFeasiblePacking
+	}
+
+	s.tiesLose = true
+	for i, size := range r.sizes {
+		s.limits[i] = -1
+		if stock, ok := available[size*r.divisor]; ok {
+			s.limits[i] = stock
+		}
+		s.monotone[i] = true
+		if unitCost == nil {
+			continue
+		}
+		if unitCost[size*r.divisor] > int64(math.MaxInt/maxTableSize) {
+			return nil, order.overflow("unit cost is too big for package sizes")
+		}
+		s.costs[i] = int(unitCost[size*r.divisor])
+		// fewer packs of a size only raise the cost bound when it is the cheapest per item so far, and more packs
+		// only raise it when it is the most expensive
+		expensive := i > 0
+		for j := range i {
+			if s.costs[i]*r.sizes[j] > s.costs[j]*size {
+				s.monotone[i] = false
+			} else if s.costs[i]*r.sizes[j] < s.costs[j]*size {
+				expensive = false
+			}
+		}
+		if !s.monotone[i] && expensive {
+			// searching the fewest packs first finds the cheap packings early
+			s.ascending[i] = true
+			s.tiesLose = false
+		}
+	}
+
+	s.visit(len(s.sizes)-1, 0, 0, 0)
+	if s.nodesLeft < 0 {
+		return nil, ErrSolverLimitExceeded
+	}
+	if len(s.found) == 0 {
+		return nil, ErrNoFeasiblePacking
+	}
+	res := make([][]model.PackageUnit, len(s.found))
+	for i, found := range s.found {
+		res[i] = r.packageUnits(found.counts)
+	}
+	return res, nil
+}
+
+type alternativeSearch struct {
+	sizes     []int  // normalised and ascending
+	limits    []int  // packs in stock per size, -1 for an unlimited supply
+	costs     []int  // unit cost per size, zero unless the objective is cost
+	monotone  []bool // whether fewer packs of a size can only raise the lower bound of a branch
+	ascending []bool // whether the packs of a size are tried from the least, more packs only raising the cost bound
+	tiesLose  bool   // whether packings found later lose ties, when every size is tried from the most
+	objective Objective
+	lo, hi    int // bounds of the total
+	minTotal  int // no packing ships less than this
+	k         int
+	counts    []int
+	found     []alternative // best first
+	nodesLeft int
+}
+
+type alternative struct {
+	rank   [3]int
+	counts []int
+}
+
+func (s *alternativeSearch) rank(items, packs, cost int) [3]int {
+	return rankPacking(s.objective, items, packs, cost)
+}
+
+// rankPacking orders packings by the objective, the lowest first.
+func rankPacking(objective Objective, items, packs, cost int) [3]int {
+	switch objective {
+	case ObjectivePacksFirst:
+		return [3]int{packs, items, 0}
+	case ObjectiveCost:
+		return [3]int{cost, items, packs}
+	}
+	return [3]int{items, packs, 0}
+}
+
+// visit picks the amount of packs of sizes[i], from the most to the least, given the items, packs and cost of
+// the bigger sizes.
+func (s *alternativeSearch) visit(i, items, packs, cost int) {
+	s.nodesLeft--
+	if s.nodesLeft < 0 {
+		return
+	}
+	if items >= s.lo {
+		// any smaller pack could be dropped
+		s.add(items, packs, cost)
+		return
+	}
+	size := s.sizes[i]
+	needed := ceilDiv(s.lo-items, size)
+	most := min(needed, (s.hi-items)/size)
+	if s.limits[i] >= 0 {
+		most = min(most, s.limits[i])
+	}
+
+	if i == 0 {
+		if most == needed {
+			s.counts[0] = needed
+			s.add(items+needed*size, packs+needed, saturatingAdd(cost, saturatingMul(needed, s.costs[0])))
+			s.counts[0] = 0
+		}
+		return
+	}
+
+	for n := range most + 1 {
+		count := most - n
+		if s.ascending[i] {
+			count = n
+		}
+		s.counts[i] = count
+		nextItems, nextPacks := items+count*size, packs+count
+		nextCost := saturatingAdd(cost, saturatingMul(count, s.costs[i]))
+		if len(s.found) == s.k {
+			bound, worst := s.bound(i-1, nextItems, nextPacks, nextCost), s.found[s.k-1].rank
+			if c := slices.Compare(bound[:], worst[:]); c > 0 || (c == 0 && s.tiesLose) {
+				if (s.ascending[i] && bound[0] > worst[0]) || (!s.ascending[i] && nextItems < s.lo && s.monotone[i]) {
+					break
+				}
+				continue
+			}
+		}
+		s.visit(i-1, nextItems, nextPacks, nextCost)
+		if s.nodesLeft < 0 {
+			break
+		}
+	}
+	s.counts[i] = 0
+}
+
+// bound returns the lowest rank a branch can reach with sizes[0] to sizes[i] given what was picked so far.
+func (s *alternativeSearch) bound(i, items, packs, cost int) [3]int {
+	if items < s.lo {
+		left := s.lo - items
+		items = s.minTotal
+		packs += ceilDiv(left, s.sizes[i])
+		cheapest := math.MaxInt
+		for j := range i + 1 {
+			cheapest = min(cheapest, ceilDiv(saturatingMul(left, s.costs[j]), s.sizes[j]))
+		}
+		cost = saturatingAdd(cost, cheapest)
+	}
+	return s.rank(items, packs, cost)
+}
+
+// add keeps the packing in s.counts when it is among the best k found so far.
+func (s *alternativeSearch) add(items, packs, cost int) {
+	candidate := alternative{rank: s.rank(items, packs, cost), counts: s.counts}
+	pos := len(s.found)
+	for pos > 0 && candidate.better(s.found[pos-1]) {
+		pos--
+	}
+	if pos >= s.k {
+		return
+	}
+	candidate.counts = slices.Clone(s.counts)
+	s.found = slices.Insert(s.found, pos, candidate)
+	if len(s.found) > s.k {
+		s.found = s.found[:s.k]
+	}
+}
+
+// better reports whether a ranks before o, ties preferring bigger package sizes.
+func (a alternative) better(o alternative) bool {
+	if c := slices.Compare(a.rank[:], o.rank[:]); c != 0 {
+		return c < 0
+	}
+	for i := len(a.counts) - 1; i >= 0; i-- {
+		if a.counts[i] != o.counts[i] {
+			return a.counts[i] > o.counts[i]
+		}
+	}
+	return false
+}
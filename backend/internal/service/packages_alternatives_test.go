@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// bruteForceAlternatives lists every packing of the order up to a biggest pack over it where no pack can be dropped,
+// ranked like alternatives: by the objective, then preferring bigger package sizes.
+func bruteForceAlternatives(order Order) [][]model.PackageUnit {
+	sizes := slices.Clone(order.PackageSizes)
+	if order.Objective == ObjectiveCost {
+		sizes = sizes[:0]
+		for _, price := range order.PackagePrices {
+			sizes = append(sizes, price.Size)
+		}
+	}
+	slices.Sort(sizes)
+	slices.Reverse(sizes)
+	hi := order.Units + sizes[0] - 1
+	if order.MaxOverfill != nil {
+		hi = min(hi, order.Units+*order.MaxOverfill)
+	}
+	if order.Objective == ObjectiveExact {
+		hi = order.Units
+	}
+
+	type packing struct {
+		rank  []int
+		units []model.PackageUnit
+	}
+	var packings []packing
+	counts := make([]int, len(sizes))
+	var visit func(i, items int)
+	visit = func(i, items int) {
+		if i == len(sizes) {
+			smallest, packs, cost := 0, 0, 0
+			var units []model.PackageUnit
+			for j := len(sizes) - 1; j >= 0; j-- {
+				if counts[j] == 0 {
+					continue
+				}
+				if smallest == 0 {
+					smallest = sizes[j]
+				}
+				packs += counts[j]
+				units = append(units, model.PackageUnit{Size: sizes[j], Amount: counts[j]})
+				if k := slices.IndexFunc(order.PackagePrices, func(p model.PackagePrice) bool { return p.Size == sizes[j] }); k >= 0 {
+					cost += counts[j] * int(order.PackagePrices[k].UnitCost)
+				}
+			}
+			if items < order.Units || items-smallest >= order.Units {
+				return
+			}
+			rank := []int{items, packs}
+			switch order.Objective {
+			case ObjectivePacksFirst:
+				rank = []int{packs, items}
+			case ObjectiveCost:
+				rank = []int{cost, items, packs}
+			}
+			packings = append(packings, packing{rank: rank, units: units})
+			return
+		}
+		limit := hi / sizes[i]
+		if k := slices.IndexFunc(order.Stock, func(s model.PackageStock) bool { return s.Size == sizes[i] }); k >= 0 {
+			limit = min(limit, order.Stock[k].Available)
+		}
+		for count := limit; count >= 0; count-- {
+			if items+count*sizes[i] > hi {
+				continue
+			}
+			counts[i] = count
+			visit(i+1, items+count*sizes[i])
+		}
+		counts[i] = 0
+	}
+	visit(0, 0)
+
+	slices.SortStableFunc(packings, func(a, b packing) int { return slices.Compare(a.rank, b.rank) })
+	res := make([][]model.PackageUnit, len(packings))
+	for i, p := range packings {
+		res[i] = p.units
+	}
+	return res
+}
+
+// given random package sizes, prices and stock - alternatives match a brute force search and start with the dp answer
+func TestAlternativesMatchBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 1000 {
+		order := Order{
+			Units:     1 + random.Intn(200),
+			Objective: []Objective{ObjectiveItemsFirst, ObjectivePacksFirst, ObjectiveExact, ObjectiveCost}[random.Intn(4)],
+		}
+		for range 1 + random.Intn(4) {
+			size := 1 + random.Intn(40)
+			if slices.Contains(order.PackageSizes, size) {
+				continue
+			}
+			order.PackageSizes = append(order.PackageSizes, size)
+			order.PackagePrices = append(order.PackagePrices, model.PackagePrice{Size: size, UnitCost: int64(random.Intn(100)), Currency: "GBP"})
+			if random.Intn(3) == 0 {
+				order.Stock = append(order.Stock, model.PackageStock{Size: size, Available: random.Intn(10)})
+			}
+		}
+		if random.Intn(2) == 0 {
+			maxOverfill := random.Intn(50)
+			order.MaxOverfill = &maxOverfill
+		}
+		k := 1 + random.Intn(MaxAlternatives)
+
+		want := bruteForceAlternatives(order)
+		res, err := alternatives(order, k, maxAlternativeNodes)
+		if len(want) == 0 {
+			if !errors.Is(err, ErrNoFeasiblePacking) {
+				t.Fatalf("%+v: want no feasible packing, got %v %v", order, res, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v: %v", order, err)
+		}
+		want = want[:min(k, len(want))]
+		if !slices.EqualFunc(res, want, slices.Equal) {
+			t.Fatalf("%+v: want %v got %v", order, want, res)
+		}
+
+		best, err := solvers[SolverDP].Solve(order)
+		if err != nil || !slices.Equal(best, res[0]) {
+			t.Fatalf("%+v: want %v first, the dp solver answered %v %v", order, res[0], best, err)
+		}
+	}
+}
+
+// given 250 500 1000 2000 5000 Items - test the next best packings are listed with the best one
+func TestCalculatePackagesAlternatives(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantRes: &model.Product{
+		ID:           uuid.NewString(),
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+	}})
+
+	res, err := service.CalculatePackages(context.TODO(), "ABC", 501, CalculateOptions{Alternatives: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]model.PackageUnit{
+		{{Size: 250, Amount: 1}, {Size: 500, Amount: 1}},
+		{{Size: 250, Amount: 3}},
+		{{Size: 1000, Amount: 1}},
+	}
+	if len(res.Alternatives) != len(want) || !slices.Equal(res.PackageUnits, want[0]) {
+		t.Fatalf("want %v got %+v", want, res)
+	}
+	for i, alternative := range res.Alternatives {
+		if !slices.Equal(alternative.PackageUnits, want[i]) {
+			t.Fatalf("alternative %d: want %v got %v", i, want[i], alternative.PackageUnits)
+		}
+	}
+
+	// big orders only look at packings close to the best one
+	res, err = service.CalculatePackages(context.TODO(), "ABC", 1_000_000_001, CalculateOptions{Alternatives: MaxAlternatives})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Alternatives) != MaxAlternatives || !slices.Equal(res.Alternatives[0].PackageUnits, res.PackageUnits) {
+		t.Fatalf("unexpected alternatives %+v", res.Alternatives)
+	}
+
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 501, CalculateOptions{Alternatives: MaxAlternatives + 1})
+	if !errors.Is(err, ErrInvalidAlternatives) {
+		t.Fatalf("want %v got %v", ErrInvalidAlternatives, err)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gymshark-interview/internal/model"
+	"math"
+	"slices"
+)
+
+// MaxAnalysisQuantities bounds the quantities an analysis measures the overfill over
+const MaxAnalysisQuantities = 1_000_000
+
+var (
+	ErrInvalidPackageSizes  = errors.New("package sizes must be positive")
+	ErrInvalidAnalysisRange = errors.New("invalid analysis range")
+)
+
+// AnalysePackSet analyses the package sizes of a product, or the proposed sizes instead when there are any.
+// See analysePackSet for the range of quantities the overfill is measured over.
+func (s *Packages) AnalysePackSet(ctx context.Context, productID string, proposed []int, from, to int) (*model.PackSetAnalysis, error) {
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	packageSizes := product.PackageSizes
+	if len(proposed) > 0 {
+		packageSizes = proposed
+	}
+	return analysePackSet(packageSizes, from, to)
+}
+
+// analysePackSet reports how well package sizes fulfil orders with the default objective: the quantities that
+// can't be shipped exactly, the overfill over the quantities from..to, and the sizes that change nothing over them.
+// A zero to ends the range one LCM of the sizes after from, or at the Frobenius number when it's later, so that
+// from 1 the worst overfill is the worst of any quantity. The range holds up to MaxAnalysisQuantities.
+func analysePackSet(packageSizes []int, from, to int) (*model.PackSetAnalysis, error) {
+	if len(packageSizes) == 0 {
+		return nil, ErrProductWithoutPackages
+	}
+	if slices.ContainsFunc(packageSizes, func(size int) bool { return size < 1 }) {
+		return nil, ErrInvalidPackageSizes
+	}
+	if from < 1 || (to != 0 && (to < from || to-from >= MaxAnalysisQuantities)) {
+		return nil, ErrInvalidAnalysisRange
+	}
+	sizes := slices.Clone(packageSizes)
+	slices.Sort(sizes)
+	sizes = slices.Compact(sizes)
+
+	p, err := newReachability(sizes)
+	if err != nil {
+		return nil, &LimitError{Reason: err.Error()}
+	}
+	res := &model.PackSetAnalysis{
+		PackageSizes:          sizes,
+		GreatestCommonDivisor: p.divisor,
+		From:                  from,
+	}
+
+	// every normalised class is reachable, the last one to be is the Frobenius number plus the biggest size
+	frobenius := slices.Max(p.minReachable) - p.largest
+	if frobenius >= 0 {
+		if frobenius > math.MaxInt/p.divisor {
+			return nil, &OverflowError{Reason: "the Frobenius number exceeds the maximum int"}
+		}
+		frobenius *= p.divisor
+		res.FrobeniusNumber = &frobenius
+	}
+	span := math.MaxInt
+	if multiple, ok := getLeastCommonMultiple(sizes); ok {
+		res.LeastCommonMultiple = &multiple
+		span = multiple
+	}
+	if to == 0 {
+		to = max(saturatingAdd(from-1, span), frobenius)
+		to = min(to, saturatingAdd(from-1, MaxAnalysisQuantities))
+	}
+	res.To = to
+
+	if err := measureOverfill(p, res); err != nil {
+		return nil, err
+	}
+	if res.RedundantSizes, err = redundantSizes(sizes, res.From, res.To); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// measureOverfill fills the worst and average overfill of the analysis range. It walks the quantities down from
+// the top of the range, keeping the smallest amount that can be shipped at or above the current quantity.
+func measureOverfill(p *reachability, res *model.PackSetAnalysis) error {
+	next := p.minTotal(ceilDiv(res.To, p.divisor))
+	if next < 0 || next > math.MaxInt/p.divisor {
+		return &OverflowError{Units: res.To, Reason: "the amount of items to ship exceeds the maximum int"}
+	}
+
+	total := 0.0
+	for units := res.To; units >= res.From; units-- {
+		if n := ceilDiv(units, p.divisor); n < next && p.reachable(n) {
+			next = n
+		}
+		overfill := next*p.divisor - units
+		total += float64(overfill)
+		if overfill >= res.WorstOverfill {
+			res.WorstOverfill, res.WorstUnits = overfill, units
+		}
+	}
+	res.AverageOverfill = total / float64(res.To-res.From+1)
+	return nil
+}
+
+// redundantSizes returns the sizes that can be removed on their own without changing what the items-first
+// objective ships for any quantity from..to, in items or in packs, with the fewest packs of the other sizes
+// replacing each when they ship it exactly.
+func redundantSizes(sizes []int, from, to int) ([]model.RedundantPackageSize, error) {
+	res := []model.RedundantPackageSize{}
+	if len(sizes) < 2 {
+		return res, nil
+	}
+	want, err := itemsFirstShipments(sizes, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i, size := range sizes {
+		others := slices.Delete(slices.Clone(sizes), i, i+1)
+		got, err := itemsFirstShipments(others, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Equal(got, want) {
+			continue
+		}
+
+		redundant := model.RedundantPackageSize{Size: size, ReplacedBy: []model.PackageUnit{}}
+		noOverfill := 0
+		if replacedBy, err := calculate(Order{Units: size, PackageSizes: others, MaxOverfill: &noOverfill}); err == nil {
+			redundant.ReplacedBy = replacedBy
+		}
+		res = append(res, redundant)
+	}
+	return res, nil
+}
+
+// shipment is the amount of items and packs shipped for a quantity.
+type shipment struct {
+	items, packs int
+}
+
+// itemsFirstShipments returns what the items-first objective ships for each quantity from..to. Like
+// measureOverfill, it walks the quantities down keeping the smallest amount that can be shipped.
+func itemsFirstShipments(sizes []int, from, to int) ([]shipment, error) {
+	p, err := newPacker(sizes)
+	if err != nil {
+		return nil, &LimitError{Reason: err.Error()}
+	}
+	next := p.minTotal(ceilDiv(to, p.divisor))
+	if next < 0 || next > math.MaxInt/p.divisor {
+		return nil, &OverflowError{Units: to, Reason: "the amount of items to ship exceeds the maximum int"}
+	}
+	// below the items of its label an amount is packed with a table, like pack does
+	var table *packTable
+	if limit := min(next, p.maxLabelItems()-1); limit >= 0 {
+		if limit > maxTableSize {
+			return nil, &LimitError{Units: to, Reason: fmt.Sprintf("package sizes need a table of %d entries, the limit is %d", limit, maxTableSize)}
+		}
+		table = p.newPackTable(limit)
+	}
+
+	res := make([]shipment, to-from+1)
+	for units := to; units >= from; units-- {
+		if n := ceilDiv(units, p.divisor); n < next && p.reachable(n) {
+			next = n
+		}
+		packs := 0
+		if label := p.fewestPacks[next%p.largest]; label.reached && label.items <= next {
+			packs = label.packs(p.largest) + (next-label.items)/p.largest
+		} else {
+			packs = int(table.packs[next])
+		}
+		res[units-from] = shipment{items: next * p.divisor, packs: packs}
+	}
+	return res, nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// bruteForceOverfill returns the overfill of every quantity up to units with a table of the exact sums of packs.
+func bruteForceOverfill(sizes []int, units int) []int {
+	hi := units + slices.Max(sizes)
+	exact := make([]bool, hi+1)
+	exact[0] = true
+	for amount := 1; amount <= hi; amount++ {
+		for _, size := range sizes {
+			if size <= amount && exact[amount-size] {
+				exact[amount] = true
+				break
+			}
+		}
+	}
+	res := make([]int, units+1)
+	next := hi
+	for amount := hi; amount >= 0; amount-- {
+		if exact[amount] {
+			next = amount
+		}
+		if amount <= units {
+			res[amount] = next - amount
+		}
+	}
+	return res
+}
+
+func TestAnalysePackSet(t *testing.T) {
+	ptr := func(n int) *int { return &n }
+	tests := []struct {
+		name     string
+		sizes    []int
+		from, to int
+		want     *model.PackSetAnalysis
+	}{
+		{
+			name:  "default sizes, one LCM",
+			sizes: []int{5000, 250, 1000, 500, 2000},
+			from:  1,
+			want: &model.PackSetAnalysis{
+				PackageSizes:          []int{250, 500, 1000, 2000, 5000},
+				GreatestCommonDivisor: 250,
+				LeastCommonMultiple:   ptr(10000),
+				From:                  1,
+				To:                    10000,
+				WorstOverfill:         249,
+				WorstUnits:            1,
+				AverageOverfill:       124.5,
+				RedundantSizes:        []model.RedundantPackageSize{},
+			},
+		},
+		{
+			name:  "range past the LCM",
+			sizes: []int{6, 10, 15},
+			from:  1,
+			to:    40,
+			want: &model.PackSetAnalysis{
+				PackageSizes:          []int{6, 10, 15},
+				GreatestCommonDivisor: 1,
+				LeastCommonMultiple:   ptr(30),
+				FrobeniusNumber:       ptr(29),
+				From:                  1,
+				To:                    40,
+				WorstOverfill:         5,
+				WorstUnits:            1,
+				AverageOverfill:       29.0 / 40,
+				RedundantSizes:        []model.RedundantPackageSize{},
+			},
+		},
+		{
+			name:  "redundant sizes over the range",
+			sizes: []int{3, 5, 15, 16},
+			from:  10,
+			to:    12,
+			want: &model.PackSetAnalysis{
+				PackageSizes:          []int{3, 5, 15, 16},
+				GreatestCommonDivisor: 1,
+				LeastCommonMultiple:   ptr(240),
+				FrobeniusNumber:       ptr(7),
+				From:                  10,
+				To:                    12,
+				WorstUnits:            10,
+				RedundantSizes: []model.RedundantPackageSize{
+					{Size: 15, ReplacedBy: []model.PackageUnit{{Size: 5, Amount: 3}}},
+					{Size: 16, ReplacedBy: []model.PackageUnit{{Size: 3, Amount: 2}, {Size: 5, Amount: 2}}},
+				},
+			},
+		},
+		{
+			name:  "redundant size the others can't replace",
+			sizes: []int{4, 6, 99},
+			from:  1,
+			to:    20,
+			want: &model.PackSetAnalysis{
+				PackageSizes:          []int{4, 6, 99},
+				GreatestCommonDivisor: 1,
+				LeastCommonMultiple:   ptr(396),
+				FrobeniusNumber:       ptr(101),
+				From:                  1,
+				To:                    20,
+				WorstOverfill:         3,
+				WorstUnits:            1,
+				AverageOverfill:       0.7,
+				RedundantSizes:        []model.RedundantPackageSize{{Size: 99, ReplacedBy: []model.PackageUnit{}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := analysePackSet(tt.sizes, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("want %+v got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// given random package sizes - test the default range reaches the worst overfill of any quantity
+func TestAnalysePackSetMatchesBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(12))
+	for range 300 {
+		sizes := make([]int, 1+rnd.Intn(4))
+		for i := range sizes {
+			sizes[i] = 2 + rnd.Intn(40)
+		}
+
+		got, err := analysePackSet(sizes, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		overfill := bruteForceOverfill(sizes, max(got.To, 3000))
+		worst, worstUnits, total := 0, 0, 0
+		for units := 1; units <= got.To; units++ {
+			total += overfill[units]
+			if overfill[units] > worst {
+				worst, worstUnits = overfill[units], units
+			}
+		}
+		if got.WorstOverfill != worst || got.WorstUnits != worstUnits {
+			t.Fatalf("sizes %v: want worst overfill %d at %d, got %d at %d", sizes, worst, worstUnits, got.WorstOverfill, got.WorstUnits)
+		}
+		if math.Abs(got.AverageOverfill-float64(total)/float64(got.To)) > 1e-9 {
+			t.Fatalf("sizes %v: want average overfill %v, got %v", sizes, float64(total)/float64(got.To), got.AverageOverfill)
+		}
+		if got.To < 3000 && slices.Max(overfill[got.To:]) > worst {
+			t.Fatalf("sizes %v: worse overfill past %d", sizes, got.To)
+		}
+		frobenius := -1
+		for units, over := range overfill[:3000] {
+			if units%got.GreatestCommonDivisor == 0 && over > 0 {
+				frobenius = units
+			}
+		}
+		if (got.FrobeniusNumber == nil) != (frobenius < 0) || (got.FrobeniusNumber != nil && *got.FrobeniusNumber != frobenius) {
+			t.Fatalf("sizes %v: want Frobenius number %d, got %v", sizes, frobenius, got.FrobeniusNumber)
+		}
+	}
+}
+
+// given random package sizes and ranges - test a size is redundant exactly when shipping without it changes nothing
+func TestRedundantSizesMatchBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	for range 300 {
+		sizes := make([]int, 2+rnd.Intn(3))
+		for i := range sizes {
+			sizes[i] = 2 + rnd.Intn(40)
+		}
+		slices.Sort(sizes)
+		sizes = slices.Compact(sizes)
+		from := 1 + rnd.Intn(200)
+		to := from + rnd.Intn(100)
+
+		got, err := analysePackSet(sizes, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []int
+		for i, size := range sizes {
+			others := slices.Delete(slices.Clone(sizes), i, i+1)
+			if len(others) == 0 {
+				continue
+			}
+			unchanged := true
+			for units := from; units <= to && unchanged; units++ {
+				items, packs, _ := bruteForce(Order{Units: units, PackageSizes: sizes}, sizes[len(sizes)-1])
+				otherItems, otherPacks, _ := bruteForce(Order{Units: units, PackageSizes: others}, sizes[len(sizes)-1])
+				unchanged = items == otherItems && packs == otherPacks
+			}
+			if unchanged {
+				want = append(want, size)
+			}
+		}
+		var redundant []int
+		for _, size := range got.RedundantSizes {
+			redundant = append(redundant, size.Size)
+		}
+		if !slices.Equal(redundant, want) {
+			t.Fatalf("sizes %v from %d to %d: want redundant %v, got %v", sizes, from, to, want, redundant)
+		}
+	}
+}
+
+func TestAnalysePackSetFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []int
+		from, to int
+		wantErr  error
+	}{
+		{name: "no sizes", from: 1, wantErr: ErrProductWithoutPackages},
+		{name: "zero size", sizes: []int{0, 250}, from: 1, wantErr: ErrInvalidPackageSizes},
+		{name: "zero from", sizes: []int{250}, wantErr: ErrInvalidAnalysisRange},
+		{name: "to before from", sizes: []int{250}, from: 10, to: 9, wantErr: ErrInvalidAnalysisRange},
+		{name: "too many quantities", sizes: []int{250}, from: 1, to: MaxAnalysisQuantities + 1, wantErr: ErrInvalidAnalysisRange},
+		{name: "too many residue classes", sizes: []int{3, maxResidueClasses + 1}, from: 1, wantErr: ErrCalculationLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := analysePackSet(tt.sizes, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// given proposed sizes - test they are analysed instead of the product's
+func TestAnalysePackSetProposed(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500}}}
+	service := NewPackageService(mockStorage)
+
+	got, err := service.AnalysePackSet(context.TODO(), "ABC", nil, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.PackageSizes, []int{250, 500}) || got.To != 500 || got.FrobeniusNumber != nil {
+		t.Fatalf("unexpected analysis %+v", got)
+	}
+
+	got, err = service.AnalysePackSet(context.TODO(), "ABC", []int{250, 400}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.PackageSizes, []int{250, 400}) || got.GreatestCommonDivisor != 50 || *got.FrobeniusNumber != 1350 {
+		t.Fatalf("unexpected analysis %+v", got)
+	}
+
+	_, err = NewPackageService(&mockPackageStorage{wantErr: errors.New("db is unhealthy")}).AnalysePackSet(context.TODO(), "ABC", nil, 1, 0)
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -1,16 +1,86 @@
 package service
 
 import (
+	"container/heap"
 	"context"
 	"errors"
+	"fmt"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/storage"
 	"math"
 	"slices"
 )
 
+const (
+	// maxResidueClasses bounds the tables built per package sizes (biggest size divided by the common divisor)
+	maxResidueClasses = 1 << 20
+	// maxTableSize bounds the dynamic programming table used for orders too small for the residue shortcut
+	maxTableSize = 1 << 24
+)
+
+var ErrCalculationOverflow = errors.New("calculation overflow")
+
+var ErrCalculationLimit = errors.New("calculation exceeds the solver limits")
+
+// errTooManyResidueClasses is returned by newReachability when the package sizes are too spread for its tables.
+var errTooManyResidueClasses = errors.New("package sizes need too many residue classes")
+
+// OverflowError is returned when the packs for an order can't be represented or calculated within the bounds of an int.
+type OverflowError struct {
+	// Units is zero when the package sizes can't be calculated whatever the order.
+	Units  int
+	Reason string
+}
+
+func (e *OverflowError) Error() string {
+	if e.Units == 0 {
+		return "can't calculate packages: " + e.Reason
+	}
+	return fmt.Sprintf("can't calculate packages for %d units: %s", e.Units, e.Reason)
+}
+
+func (e *OverflowError) Unwrap() error {
+	return ErrCalculationOverflow
+}
+
+// LimitError is returned when the packs for an order fit in an int, but calculating them needs bigger tables than
+// maxResidueClasses or maxTableSize allow.
+type LimitError struct {
+	// Units is zero when the package sizes can't be calculated whatever the order.
+	Units  int
+	Reason string
+}
+
+func (e *LimitError) Error() string {
+	if e.Units == 0 {
+		return "can't calculate packages within the solver limits: " + e.Reason
+	}
+	return fmt.Sprintf("can't calculate packages for %d units within the solver limits: %s", e.Units, e.Reason)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrCalculationLimit
+}
+
+// CalculateOptions tunes a single calculation.
+type CalculateOptions struct {
+	// Solver overrides the solver configured for the product when set.
+	Solver string
+	// Objective ranks the packings, the default objective when empty.
+	Objective Objective
+	// MaxOverfill caps the items shipped over the order, nil means no cap.
+	MaxOverfill *int
+	// MaxOverfillPercent caps the items shipped over the order to a share of its units, nil means no cap.
+	// Both caps apply on top of the product's own limit, the smallest one wins.
+	MaxOverfillPercent *int
+	// Alternatives is how many packings to list in Package.Alternatives, up to MaxAlternatives.
+	Alternatives int
+	// Explain asks for the packings rejected in favour of the answer, see Package.Explanation.
+	Explain bool
+}
+
 // CalculatePackages calculates the minimum amount of package units required to satisfy the requested amount of units.
-func (s *Packages) CalculatePackages(ctx context.Context, productID string, units int) (*model.Package, error) {
+func (s *Packages) CalculatePackages(ctx context.Context, productID string, units int, opts CalculateOptions) (*model.Package, error) {
 	product, err := s.storage.GetProductWithPackageSizes(ctx, productID)
 	if err != nil {
 		if errors.Is(err, storage.ErrProductNotFound) {
@@ -18,14 +88,119 @@ func (s *Packages) CalculatePackages(ctx context.Context, productID string, unit
 		}
 		return nil, err
 	}
+	return calculateProduct(product, units, opts)
+}
+
+// calculateProduct calculates the packages of a product already loaded from storage.
+func calculateProduct(product *model.Product, units int, opts CalculateOptions) (*model.Package, error) {
+	calculate, err := prepareProduct(product, opts)
+	if err != nil {
+		return nil, err
+	}
+	return calculate(units)
+}
+
+// prepareProduct sets up the solver of a product once. The returned function calculates the packages for any
+// amount of units and is safe for concurrent use.
+func prepareProduct(product *model.Product, opts CalculateOptions) (func(units int) (*model.Package, error), error) {
 	if len(product.PackageSizes) == 0 {
 		return nil, ErrProductWithoutPackages
 	}
-	return &model.Package{
-		PackageUnits: calculate(units, product.PackageSizes),
+	if opts.Alternatives < 0 || opts.Alternatives > MaxAlternatives {
+		return nil, ErrInvalidAlternatives
+	}
+	limits := []model.OverfillLimit{product.MaxOverfill, {Items: opts.MaxOverfill, Percent: opts.MaxOverfillPercent}}
+	for _, limit := range limits {
+		if err := validateOverfillLimit(limit); err != nil {
+			return nil, err
+		}
+	}
+
+	solverName := product.Solver
+	if opts.Solver != "" {
+		solverName = opts.Solver
+	}
+	solver, err := GetSolver(solverName)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := normaliseOrder(Order{
+		PackageSizes:  product.PackageSizes,
+		Objective:     opts.Objective,
+		PackagePrices: product.PackagePrices,
+		Stock:         product.PackageStock,
+	})
+	if err != nil {
+		return nil, err
+	}
+	solve, err := prepareSolver(solver, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(units int) (*model.Package, error) {
+		unitsOrder := order
+		unitsOrder.Units, unitsOrder.MaxOverfill = units, maxOverfill(units, limits)
+		packageUnits, err := solve(units, unitsOrder.MaxOverfill)
+		if err != nil {
+			if errors.Is(err, ErrNoFeasiblePacking) {
+				return nil, noFeasiblePacking(unitsOrder)
+			}
+			return nil, err
+		}
+		res := &model.Package{
+			PackageUnits: packageUnits,
+			Solver:       solver.Name(),
+			Objective:    string(order.Objective),
+		}
+		res.TotalCost, res.Currency = totalCost(packageUnits, product.PackagePrices)
+
+		if opts.Explain {
+			if res.Explanation, err = explain(unitsOrder, solver.Name(), packageUnits); err != nil {
+				return nil, err
+			}
+		}
+		if opts.Alternatives == 0 {
+			return res, nil
+		}
+
+		packings, err := alternatives(unitsOrder, opts.Alternatives, maxAlternativeNodes)
+		if err != nil {
+			return nil, err
+		}
+		for _, packing := range packings {
+			alternative := model.Package{PackageUnits: packing}
+			alternative.TotalCost, alternative.Currency = totalCost(packing, product.PackagePrices)
+			res.Alternatives = append(res.Alternatives, alternative)
+		}
+		return res, nil
 	}, nil
 }
 
+// totalCost returns the cost of the package units, or nil when a package size has no price, the prices are in
+// different currencies or the cost doesn't fit in an int64.
+func totalCost(packageUnits []model.PackageUnit, prices []model.PackagePrice) (*int64, string) {
+	var total int64
+	currency := ""
+	for _, packageUnit := range packageUnits {
+		i := slices.IndexFunc(prices, func(price model.PackagePrice) bool { return price.Size == packageUnit.Size })
+		if i < 0 || (currency != "" && prices[i].Currency != currency) {
+			return nil, ""
+		}
+		currency = prices[i].Currency
+		amount := int64(packageUnit.Amount)
+		if prices[i].UnitCost > 0 && amount > (math.MaxInt64-total)/prices[i].UnitCost {
+			return nil, ""
+		}
+		total += amount * prices[i].UnitCost
+	}
+	if currency == "" {
+		return nil, ""
+	}
+	return &total, currency
+}
+
 func gcd(a, b int) int {
 	for b != 0 {
 		a, b = b, a%b
@@ -33,104 +208,546 @@ func gcd(a, b int) int {
 	return a
 }
 
-func lcm(a, b int) int {
-	return a * b / gcd(a, b)
+// lcm returns the least common multiple of a and b, and false when it doesn't fit in an int
+func lcm(a, b int) (int, bool) {
+	res := a / gcd(a, b)
+	if res > math.MaxInt/b {
+		return 0, false
+	}
+	return res * b, true
+}
+
+// ceilDiv divides rounding up, without overflowing close to math.MaxInt
+func ceilDiv(a, b int) int {
+	res := a / b
+	if a%b != 0 {
+		res++
+	}
+	return res
 }
 
-// get the Least Common Multiple
-func getLeastCommonMultiple(nums []int) int {
+// get the Greatest Common Divisor
+func getGreatestCommonDivisor(nums []int) int {
 	result := nums[0]
 	for _, num := range nums[1:] {
-		result = lcm(result, num)
+		result = gcd(result, num)
 	}
 	return result
 }
 
-func calculate(units int, packageSizes []int) []model.PackageUnit {
-	slices.Sort(packageSizes) // sort ascending
-	maxSize := packageSizes[len(packageSizes)-1]
+// get the Least Common Multiple, and false when it doesn't fit in an int
+func getLeastCommonMultiple(nums []int) (int, bool) {
+	result := nums[0]
+	for _, num := range nums[1:] {
+		var ok bool
+		if result, ok = lcm(result, num); !ok {
+			return 0, false
+		}
+	}
+	return result, true
+}
 
-	leastCommonMultiple := getLeastCommonMultiple(packageSizes)
+// calculate returns the packs to ship for the order. With the default objective it follows the rules in
+// docs/REQUIREMENTS.md: only whole packs, then the least amount of items, then the least amount of packs.
+// Remaining ties are broken by preferring bigger package sizes.
+//
+// Memory and time depend on the package sizes only: every sum of packs is classified by its remainder modulo
+// the biggest package size, and any order big enough is the cheapest remainder class topped up with biggest packs.
+func calculate(order Order) ([]model.PackageUnit, error) {
+	solve, err := prepareCalculation(order)
+	if err != nil {
+		return nil, err
+	}
+	return solve(order.Units, order.MaxOverfill)
+}
 
-	// if units is bigger than at least 2 times lcm, then let's calculate an offset by using the biggest packageSize
-	biggestPackageOffset := 0
-	if units > leastCommonMultiple*2 {
-		biggestPackageInLCM := leastCommonMultiple / maxSize
+// prepareCalculation builds the tables used by calculate once for the package sizes, objective, prices and stock
+// of the template order. The returned function solves the template for any amount of units and maximum overfill
+// and is safe for concurrent use.
+func prepareCalculation(template Order) (solveFunc, error) {
+	template, err := normaliseOrder(template)
+	if err != nil {
+		return nil, err
+	}
 
-		rest := units % leastCommonMultiple
-		division := units / leastCommonMultiple
+	var solve func(order Order) ([]model.PackageUnit, error)
+	if template.Objective == ObjectiveCost {
+		p, err := newCostPacker(template)
+		if err != nil {
+			return nil, err
+		}
+		solve = p.solve
+	} else {
+		p, err := newPacker(template.PackageSizes)
+		switch {
+		case errors.Is(err, errTooManyResidueClasses):
+			// small orders can still be searched directly
+			solve = newTablePacker(template.PackageSizes).solve
+		case err != nil:
+			return nil, template.limit(err.Error())
+		default:
+			solve = p.solve
+		}
+	}
+	if len(template.Stock) > 0 {
+		unlimited := solve
+		solve = func(order Order) ([]model.PackageUnit, error) {
+			return calculateWithStock(order, unlimited)
+		}
+	}
+
+	return func(units int, maxOverfill *int) ([]model.PackageUnit, error) {
+		order := template
+		order.Units, order.MaxOverfill = units, maxOverfill
+		return solve(order)
+	}, nil
+}
 
-		units = rest + leastCommonMultiple*2
-		biggestPackageOffset = (division - 2) * biggestPackageInLCM
+// solve returns the packs to ship for an order of the package sizes of the packer.
+func (p *packer) solve(order Order) ([]model.PackageUnit, error) {
+	var total int
+	var err error
+	if order.Objective == ObjectivePacksFirst {
+		total, err = p.packsFirstTotal(order)
+	} else {
+		total, err = p.itemsFirstTotal(order)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	maxUnits := units + maxSize // we may need to overfill a bit
+	packageUnits, err := p.pack(total)
+	if err != nil {
+		return nil, order.limit(err.Error())
+	}
+	return packageUnits, nil
+}
+
+// reachability knows which amounts of items can be shipped with whole packs. Sizes are divided by their greatest
+// common divisor so that the tables are as small as possible.
+type reachability struct {
+	divisor int
+	sizes   []int // normalised, ascending and unique
+	largest int   // normalised biggest size, the modulus of the tables
+
+	// minReachable[r] is the smallest sum of packs congruent to r modulo largest, or -1 if there isn't one.
+	minReachable []int
+	// allReachable is the smallest amount from which every amount can be shipped, one over the Frobenius number.
+	allReachable int
+}
+
+func newReachability(packageSizes []int) (*reachability, error) {
+	p := normaliseSizes(packageSizes)
+	if p.largest > maxResidueClasses {
+		return nil, fmt.Errorf("%w: %d, the limit is %d", errTooManyResidueClasses, p.largest, maxResidueClasses)
+	}
+	p.minReachable = p.shortestSums()
+	p.allReachable = max(slices.Max(p.minReachable)-p.largest+1, 0)
+	return p, nil
+}
 
-	type dpEntry struct {
-		totalItems int
-		packCount  int
-		path       []int // indices of packageSizes
+// normaliseSizes returns a reachability without its tables.
+func normaliseSizes(packageSizes []int) *reachability {
+	divisor := getGreatestCommonDivisor(packageSizes)
+	sizes := make([]int, 0, len(packageSizes))
+	for _, size := range packageSizes {
+		sizes = append(sizes, size/divisor)
 	}
+	slices.Sort(sizes) // sort ascending
+	sizes = slices.Compact(sizes)
 
-	const maxInt = math.MaxInt64
-	dp := make([]dpEntry, maxUnits+1)
-	for i := range dp {
-		dp[i] = dpEntry{totalItems: maxInt, packCount: maxInt}
+	return &reachability{
+		divisor: divisor,
+		sizes:   sizes,
+		largest: sizes[len(sizes)-1],
 	}
-	dp[0] = dpEntry{totalItems: 0, packCount: 0, path: []int{}}
+}
 
-	for i := 1; i <= maxUnits; i++ {
-		for idx, size := range packageSizes {
-			if i < size {
-				continue
-			}
-			prev := dp[i-size]
-			if prev.totalItems == maxInt {
-				continue
-			}
-			newTotal := prev.totalItems + size
-			newCount := prev.packCount + 1
-			newPath := append([]int{}, prev.path...)
-			newPath = append(newPath, idx)
-
-			if newTotal < dp[i].totalItems || (newTotal == dp[i].totalItems && newCount < dp[i].packCount) {
-				dp[i] = dpEntry{
-					totalItems: newTotal,
-					packCount:  newCount,
-					path:       newPath,
-				}
+// packer holds the per package sizes tables used by calculate.
+type packer struct {
+	*reachability
+
+	// fewestPacks[r] is the best combination of packs, other than the biggest, congruent to r modulo largest.
+	fewestPacks []residueLabel
+}
+
+func newPacker(packageSizes []int) (*packer, error) {
+	r, err := newReachability(packageSizes)
+	if err != nil {
+		return nil, err
+	}
+	p := &packer{reachability: r}
+	p.fewestPacks = p.shortestLabels(len(p.sizes)-1, func(i int) (int, int) {
+		return p.largest - p.sizes[i], p.sizes[i]
+	})
+	return p, nil
+}
+
+// minTotal returns the smallest normalised amount of items, at least lo, that can be shipped with whole packs.
+// It returns -1 when that amount doesn't fit in an int. A smallest pack on top of the biggest amount below lo
+// that can be shipped reaches lo, so the amount is less than a smallest size away from lo.
+func (p *reachability) minTotal(lo int) int {
+	if lo >= p.allReachable {
+		return lo
+	}
+	for total := max(lo, 0); total >= 0; total++ {
+		if p.reachable(total) {
+			return total
+		}
+	}
+	return -1
+}
+
+// maxTotal returns the biggest normalised amount of items, at most hi, that can be shipped with whole packs.
+func (p *reachability) maxTotal(hi int) int {
+	best := 0
+	for r, reachable := range p.minReachable {
+		if reachable < 0 {
+			continue
+		}
+		if total := hi - ((hi-r)%p.largest+p.largest)%p.largest; total >= reachable {
+			best = max(best, total)
+		}
+	}
+	return best
+}
+
+// minTotalInClass returns the smallest normalised amount of items, at least lo and congruent to r modulo largest,
+// that can be shipped with whole packs. It returns -1 when there isn't one or it doesn't fit in an int.
+func (p *reachability) minTotalInClass(lo, r int) int {
+	if p.minReachable[r] < 0 {
+		return -1
+	}
+	total := max(lo, p.minReachable[r])
+	step := (r - total%p.largest + p.largest) % p.largest
+	if total > math.MaxInt-step {
+		return -1
+	}
+	return total + step
+}
+
+// reachable reports whether a normalised amount of items can be shipped exactly with whole packs.
+func (p *reachability) reachable(total int) bool {
+	least := p.minReachable[total%p.largest]
+	return least >= 0 && total >= least
+}
+
+// packsFirstTotal returns the normalised amount of items to ship for the packs-first objective: the amount
+// within the order bounds that takes the fewest packs, then the smallest one.
+func (p *packer) packsFirstTotal(order Order) (int, error) {
+	lo, hi := p.totalBounds(order)
+	best, bestPacks := -1, 0
+	consider := func(total, packs int) {
+		if best < 0 || packs < bestPacks || (packs == bestPacks && total < best) {
+			best, bestPacks = total, packs
+		}
+	}
+
+	// above the items of its label, a remainder class takes one more pack per biggest size
+	for r := range p.fewestPacks {
+		label := p.fewestPacks[r]
+		if !label.reached {
+			continue
+		}
+		total := p.minTotalInClass(max(lo, label.items), r)
+		if total < 0 || total > hi {
+			continue
+		}
+		consider(total, (total-label.items)/p.largest+label.packs(p.largest))
+	}
+
+	// below the items of its label a bigger amount can take fewer packs, so look at every amount
+	if limit := min(hi, p.maxLabelItems()-1); lo <= limit {
+		if limit > maxTableSize {
+			return 0, order.limit(fmt.Sprintf("package sizes need a table of %d entries, the limit is %d", limit, maxTableSize))
+		}
+		table := p.newPackTable(limit)
+		for total := lo; total <= limit; total++ {
+			if packs := table.packs[total]; packs != unreachablePacks {
+				consider(total, int(packs))
 			}
 		}
 	}
 
-	// Find the best valid entry from units upwards
-	best := dpEntry{totalItems: maxInt, packCount: maxInt}
-	for i := units; i <= maxUnits; i++ {
-		entry := dp[i]
-		if entry.totalItems < best.totalItems || (entry.totalItems == best.totalItems && entry.packCount < best.packCount) {
-			best = entry
+	if best < 0 {
+		if order.MaxOverfill != nil {
+			return 0, ErrNoFeasiblePacking
 		}
+		return 0, order.overflow("the amount of items to ship exceeds the maximum int")
 	}
+	return best, nil
+}
+
+// maxLabelItems returns the biggest amount of items among the labels, below it the residue shortcut may not apply.
+func (p *packer) maxLabelItems() int {
+	res := 0
+	for _, label := range p.fewestPacks {
+		if label.reached {
+			res = max(res, label.items)
+		}
+	}
+	return res
+}
+
+// pack returns the fewest packs summing exactly to the (normalised) total, which must be reachable.
+func (p *packer) pack(total int) ([]model.PackageUnit, error) {
+	counts := make([]int, len(p.sizes))
+	label := p.fewestPacks[total%p.largest]
+	if label.reached && label.items <= total {
+		// top up the cheapest remainder class with biggest packs
+		copy(counts, label.counts)
+		counts[len(counts)-1] = (total - label.items) / p.largest
+	} else {
+		// the order is too small for the shortcut, search it directly
+		if total > maxTableSize {
+			return nil, fmt.Errorf("package sizes need a table of %d entries, the limit is %d", total, maxTableSize)
+		}
+		counts = p.newPackTable(total).counts(total)
+	}
+	return p.packageUnits(counts), nil
+}
+
+// tablePacker solves the orders of package sizes too spread for the residue tables with a packTable bounded by
+// the order, so only orders up to maxTableSize (normalised) items can be calculated.
+type tablePacker struct {
+	*reachability // without the tables
+}
+
+func newTablePacker(packageSizes []int) *tablePacker {
+	return &tablePacker{reachability: normaliseSizes(packageSizes)}
+}
 
-	// Count usage of each pack size
-	counts := map[int]int{}
-	for _, idx := range best.path {
-		counts[packageSizes[idx]]++
+// solve returns the packs to ship for an items-first, packs-first or exact order.
+func (p *tablePacker) solve(order Order) ([]model.PackageUnit, error) {
+	lo, hi := p.totalBounds(order)
+	// a multiple of the biggest size is less than a biggest size over the order, so no answer is any further
+	limit := hi
+	if lo <= math.MaxInt-p.largest {
+		limit = min(hi, lo+p.largest-1)
 	}
 
-	// Use biggestPackageOffset if not zero
-	if biggestPackageOffset != 0 {
-		counts[maxSize] += biggestPackageOffset
+	best, bestPacks := -1, unreachablePacks
+	var table *packTable
+	if lo <= limit {
+		if limit > maxTableSize {
+			return nil, order.limit(fmt.Sprintf("package sizes need a table of %d entries, the limit is %d", limit, maxTableSize))
+		}
+		table = p.newPackTable(limit)
+		for total := lo; total <= limit; total++ {
+			packs := table.packs[total]
+			if packs == unreachablePacks {
+				continue
+			}
+			if order.Objective != ObjectivePacksFirst {
+				best = total
+				break
+			}
+			if packs < bestPacks {
+				best, bestPacks = total, packs
+			}
+		}
 	}
 
-	// Build result slice
+	if best < 0 {
+		if order.MaxOverfill != nil || order.Objective == ObjectiveExact {
+			return nil, ErrNoFeasiblePacking
+		}
+		return nil, order.overflow("the amount of items to ship exceeds the maximum int")
+	}
+	return p.packageUnits(table.counts(best)), nil
+}
+
+// packageUnits converts the packs per normalised size back to the original package sizes.
+func (p *reachability) packageUnits(counts []int) []model.PackageUnit {
 	res := []model.PackageUnit{}
-	for size, count := range counts {
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
 		res = append(res, model.PackageUnit{
-			Size:   size,
+			Size:   p.sizes[i] * p.divisor,
 			Amount: count,
 		})
 	}
-
 	return res
 }
+
+// shortestSums runs Dijkstra over the remainders modulo the biggest size, using the pack sizes as edges.
+func (p *reachability) shortestSums() []int {
+	dist := make([]int, p.largest)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[0] = 0
+
+	queue := &residueQueue[int]{less: func(a, b int) bool { return a < b }}
+	heap.Push(queue, queued[int]{residue: 0, key: 0})
+	done := make([]bool, p.largest)
+	for queue.Len() > 0 {
+		r := heap.Pop(queue).(queued[int]).residue
+		if done[r] {
+			continue
+		}
+		done[r] = true
+		for _, size := range p.sizes[:len(p.sizes)-1] {
+			next := (r + size) % p.largest
+			if dist[next] < 0 || dist[r]+size < dist[next] {
+				dist[next] = dist[r] + size
+				heap.Push(queue, queued[int]{residue: next, key: dist[next]})
+			}
+		}
+	}
+	return dist
+}
+
+// residueLabel ranks a combination of packs, excluding the modulus size, within its remainder class.
+// For the fewest packs tables the modulus is the biggest size: topping a label up to a total T takes
+// (T+weight)/largest packs, so a lower weight means fewer packs, and a lower tie weight (the items) leaves
+// room for more of the biggest packs.
+type residueLabel struct {
+	reached   bool
+	weight    int   // ranks the labels first
+	tieWeight int   // ranks the labels with the same weight
+	items     int   // sum of the packs
+	counts    []int // packs per size, the modulus size is always zero
+}
+
+// packs returns the amount of packs of a label of the fewest packs tables.
+func (l residueLabel) packs(largest int) int {
+	return (l.weight + l.items) / largest
+}
+
+func (l residueLabel) less(o residueLabel) bool {
+	if l.weight != o.weight {
+		return l.weight < o.weight
+	}
+	if l.tieWeight != o.tieWeight {
+		return l.tieWeight < o.tieWeight
+	}
+	// prefer bigger package sizes
+	for i := len(l.counts) - 1; i >= 0; i-- {
+		if l.counts[i] != o.counts[i] {
+			return l.counts[i] > o.counts[i]
+		}
+	}
+	return false
+}
+
+// shortestLabels runs Dijkstra over the remainders modulo sizes[modulus], ranking paths with residueLabel.
+// edge returns the weight and tie weight added by a pack of sizes[i]. They must make every edge rank above
+// zero, so that the lexicographic ranking is preserved along the paths.
+func (p *reachability) shortestLabels(modulus int, edge func(i int) (int, int)) []residueLabel {
+	classes := p.sizes[modulus]
+	labels := make([]residueLabel, classes)
+	labels[0] = residueLabel{reached: true, counts: make([]int, len(p.sizes))}
+
+	queue := &residueQueue[residueLabel]{less: residueLabel.less}
+	heap.Push(queue, queued[residueLabel]{residue: 0, key: labels[0]})
+	done := make([]bool, classes)
+	for queue.Len() > 0 {
+		r := heap.Pop(queue).(queued[residueLabel]).residue
+		if done[r] {
+			continue
+		}
+		done[r] = true
+		for i, size := range p.sizes {
+			if i == modulus {
+				continue
+			}
+			next := (r + size) % classes
+			if done[next] {
+				continue
+			}
+			weight, tieWeight := edge(i)
+			candidate := residueLabel{
+				reached:   true,
+				weight:    labels[r].weight + weight,
+				tieWeight: labels[r].tieWeight + tieWeight,
+				items:     labels[r].items + size,
+				counts:    slices.Clone(labels[r].counts),
+			}
+			candidate.counts[i]++
+			if !labels[next].reached || candidate.less(labels[next]) {
+				labels[next] = candidate
+				heap.Push(queue, queued[residueLabel]{residue: next, key: candidate})
+			}
+		}
+	}
+	return labels
+}
+
+// unreachablePacks marks the amounts of a packTable that can't be shipped with whole packs
+const unreachablePacks = ^uint32(0)
+
+// packTable solves small totals with a dynamic programming table bounded by the total.
+// Sizes are added from the smallest to the biggest and a tie always goes to the size being added,
+// so only one bit per size and amount is needed to rebuild the winning combination.
+type packTable struct {
+	sizes []int
+	packs []uint32   // fewest packs per amount
+	took  [][]uint64 // took[i] has a bit set for the amounts that took sizes[i] last
+}
+
+func (p *reachability) newPackTable(total int) *packTable {
+	packs := make([]uint32, total+1)
+	for i := range packs {
+		packs[i] = unreachablePacks
+	}
+	packs[0] = 0
+
+	words := total/64 + 1
+	took := make([][]uint64, len(p.sizes))
+	for i, size := range p.sizes {
+		took[i] = make([]uint64, words)
+		for amount := size; amount <= total; amount++ {
+			prev := packs[amount-size]
+			if prev == unreachablePacks || prev+1 > packs[amount] {
+				continue
+			}
+			packs[amount] = prev + 1
+			took[i][amount/64] |= 1 << (amount % 64)
+		}
+	}
+	return &packTable{sizes: p.sizes, packs: packs, took: took}
+}
+
+// counts rebuilds the packs per size for an amount of the table.
+func (t *packTable) counts(amount int) []int {
+	return rebuildCounts(t.sizes, t.took, amount)
+}
+
+// rebuildCounts walks back the bits of a table from an amount, starting with the biggest size.
+func rebuildCounts(sizes []int, took [][]uint64, amount int) []int {
+	counts := make([]int, len(sizes))
+	for i := len(sizes) - 1; amount > 0 && i >= 0; {
+		if took[i][amount/64]&(1<<(amount%64)) != 0 {
+			counts[i]++
+			amount -= sizes[i]
+		} else {
+			i--
+		}
+	}
+	return counts
+}
+
+// queued is a remainder waiting in a residueQueue, keyed by the best distance known when it was pushed.
+type queued[K any] struct {
+	residue int
+	key     K
+}
+
+// residueQueue is a min-heap of remainders ordered by their keys.
+type residueQueue[K any] struct {
+	items []queued[K]
+	less  func(a, b K) bool
+}
+
+func (q *residueQueue[K]) Len() int           { return len(q.items) }
+func (q *residueQueue[K]) Less(i, j int) bool { return q.less(q.items[i].key, q.items[j].key) }
+func (q *residueQueue[K]) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *residueQueue[K]) Push(x any)         { q.items = append(q.items, x.(queued[K])) }
+func (q *residueQueue[K]) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gymshark-interview/internal/model"
+	"math"
+	"slices"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// InsufficientStockError is returned when the packs in stock can't fulfil an order that could be fulfilled with
+// unlimited supply.
+type InsufficientStockError struct {
+	Units int
+	// InStock is the amount of items in stock, or -1 when a package size has unlimited supply.
+	InStock int
+}
+
+func (e *InsufficientStockError) Error() string {
+	if e.InStock >= 0 {
+		return fmt.Sprintf("can't fulfil %d units from stock: %d items in stock and no packing of them satisfies the order", e.Units, e.InStock)
+	}
+	return fmt.Sprintf("can't fulfil %d units from stock: no packing of the packs in stock satisfies the order", e.Units)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// calculateWithStock returns the packs to ship for an order when some package sizes have a limited stock,
+// ranked like calculate does. unlimited solves the order as if every size had unlimited supply.
+//
+// The packing calculated with unlimited supply is returned whenever it is in stock. Otherwise the search runs on
+// a dynamic programming table with a bounded amount of packs per size: the unlimited sizes cover any part of the
+// order above what the limited sizes can ship, so that part is filled with their residue shortcut and the table
+// only depends on the package sizes and the stock.
+func calculateWithStock(order Order, unlimited func(order Order) ([]model.PackageUnit, error)) ([]model.PackageUnit, error) {
+	packageUnits, err := unlimited(order)
+	if err != nil || inStock(packageUnits, order.Stock) {
+		return packageUnits, err
+	}
+
+	s, err := newStockSearch(order)
+	if err != nil {
+		return nil, err
+	}
+	return s.search()
+}
+
+// maxTotalInStock returns the most items, at most the order, that the packs in stock ship.
+func maxTotalInStock(order Order) (int, error) {
+	s, err := prepareStockSearch(order)
+	if errors.Is(err, ErrInsufficientStock) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if err := s.bound(0, max(order.Units, 0)/s.divisor); err != nil {
+		return 0, err
+	}
+	// a multiple of an unlimited size is less than one of them below the order, so look no further
+	for i := len(s.sizes) - 1; i >= 0; i-- {
+		if s.limits[i] < 0 {
+			s.lo = max(0, s.hi-s.sizes[i]+1)
+			break
+		}
+	}
+	total, err := s.maxTotal()
+	if err != nil {
+		return 0, err
+	}
+	return max(total, 0) * s.divisor, nil
+}
+
+// inStock reports whether the package units don't take more packs of any size than its stock.
+func inStock(packageUnits []model.PackageUnit, stock []model.PackageStock) bool {
+	for _, packageUnit := range packageUnits {
+		i := slices.IndexFunc(stock, func(s model.PackageStock) bool { return s.Size == packageUnit.Size })
+		if i >= 0 && packageUnit.Amount > stock[i].Available {
+			return false
+		}
+	}
+	return true
+}
+
+// stockSearch is the state of calculateWithStock, in normalised sizes.
+type stockSearch struct {
+	*reachability
+	order   Order
+	limits  []int // packs in stock per size, -1 for an unlimited supply
+	costs   []int // unit cost per size, zero unless the objective is cost
+	inStock int   // items in stock, -1 when a size has unlimited supply
+	lo, hi  int   // bounds of the total
+}
+
+func newStockSearch(order Order) (*stockSearch, error) {
+	s, err := prepareStockSearch(order)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := s.totalBounds(order)
+
+	// the items-first amount is never more than the cover size over the order, the smallest size with enough
+	// packs for the whole order
+	if order.Objective == ObjectiveItemsFirst || order.Objective == ObjectiveExact {
+		for i, size := range s.sizes {
+			if s.limits[i] < 0 || s.limits[i] >= ceilDiv(lo, size) {
+				if lo <= math.MaxInt-size {
+					hi = min(hi, lo+size-1)
+				}
+				break
+			}
+		}
+	}
+	if err := s.bound(lo, hi); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// prepareStockSearch sets up the stock of the sizes an order can use, leaving the bounds of the total to the caller.
+func prepareStockSearch(order Order) (*stockSearch, error) {
+	sizes := order.PackageSizes
+	var unitCost map[int]int64
+	if order.Objective == ObjectiveCost {
+		var err error
+		if unitCost, err = unitCosts(order); err != nil {
+			return nil, err
+		}
+		sizes = nil
+		for size := range unitCost {
+			sizes = append(sizes, size)
+		}
+	}
+
+	// sizes out of stock can't be used at all
+	available := map[int]int{}
+	for _, stock := range order.Stock {
+		available[stock.Size] = stock.Available
+	}
+	sizes = slices.DeleteFunc(slices.Clone(sizes), func(size int) bool {
+		stock, ok := available[size]
+		return ok && stock <= 0
+	})
+	if len(sizes) == 0 {
+		return nil, &InsufficientStockError{Units: order.Units, InStock: 0}
+	}
+
+	r, err := newReachability(sizes)
+	if err != nil {
+		return nil, order.limit(err.Error())
+	}
+	s := &stockSearch{
+		reachability: r,
+		order:        order,
+		limits:       make([]int, len(r.sizes)),
+		costs:        make([]int, len(r.sizes)),
+	}
+	s.inStock = 0
+	for i, size := range r.sizes {
+		s.limits[i] = -1
+		if stock, ok := available[size*r.divisor]; ok {
+			s.limits[i] = stock
+			if s.inStock >= 0 {
+				s.inStock = saturatingAdd(s.inStock, saturatingMul(stock, size*r.divisor))
+			}
+		} else {
+			s.inStock = -1
+		}
+		if unitCost != nil {
+			if unitCost[size*r.divisor] > int64(math.MaxInt/maxTableSize) {
+				return nil, order.overflow(fmt.Sprintf("unit cost %d is too big for package sizes", unitCost[size*r.divisor]))
+			}
+			s.costs[i] = int(unitCost[size*r.divisor])
+		}
+	}
+	return s, nil
+}
+
+// bound sets the bounds of the total, from lo to hi.
+func (s *stockSearch) bound(lo, hi int) error {
+	s.lo, s.hi = lo, hi
+	// a stock that can't run out within the bounds is as good as unlimited, the others bound the total
+	limited := 0
+	for i, size := range s.sizes {
+		if s.limits[i] < 0 {
+			continue
+		}
+		if s.limits[i] >= s.hi/size {
+			s.limits[i] = -1
+			continue
+		}
+		limited = saturatingAdd(limited, s.limits[i]*size)
+	}
+	if !slices.Contains(s.limits, -1) {
+		s.hi = min(s.hi, limited)
+	}
+	if s.lo > s.hi {
+		return &InsufficientStockError{Units: s.order.Units, InStock: s.inStock}
+	}
+	return nil
+}
+
+// search returns the best packing within the stock.
+func (s *stockSearch) search() ([]model.PackageUnit, error) {
+	table, bulk, prefilled, err := s.prefilledTable()
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := s.lo-prefilled*s.sizes[max(bulk, 0)], s.hi-prefilled*s.sizes[max(bulk, 0)]
+	best := -1
+	for total := lo; total <= hi; total++ {
+		if table.packs[total] == unreachablePacks {
+			continue
+		}
+		if best < 0 {
+			best = total
+			if s.order.Objective == ObjectiveItemsFirst || s.order.Objective == ObjectiveExact {
+				break
+			}
+			continue
+		}
+		if (s.order.Objective == ObjectivePacksFirst && table.packs[total] < table.packs[best]) ||
+			(s.order.Objective == ObjectiveCost && table.cost[total] < table.cost[best]) {
+			best = total
+		}
+	}
+	if best < 0 {
+		return nil, &InsufficientStockError{Units: s.order.Units, InStock: s.inStock}
+	}
+
+	counts := table.counts(best)
+	if bulk >= 0 {
+		counts[bulk] += prefilled
+	}
+	return s.packageUnits(counts), nil
+}
+
+// maxTotal returns the biggest normalised amount of items within the bounds that the packs in stock ship, or -1
+// when there isn't one.
+func (s *stockSearch) maxTotal() (int, error) {
+	table, bulk, prefilled, err := s.prefilledTable()
+	if err != nil {
+		return 0, err
+	}
+	bulkItems := prefilled * s.sizes[max(bulk, 0)]
+	for total := s.hi - bulkItems; total >= s.lo-bulkItems; total-- {
+		if table.packs[total] != unreachablePacks {
+			return total + bulkItems, nil
+		}
+	}
+	return -1, nil
+}
+
+// prefilledTable returns the stock table of the bounds less the packs of the bulk size any best packing takes,
+// see prefill.
+func (s *stockSearch) prefilledTable() (*stockTable, int, int, error) {
+	bulk, prefilled, err := s.prefill()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	hi := s.hi - prefilled*s.sizes[max(bulk, 0)]
+	if hi > maxTableSize {
+		return nil, 0, 0, s.order.limit(fmt.Sprintf("stock needs a table of %d entries, the limit is %d", hi, maxTableSize))
+	}
+	return s.newStockTable(hi), bulk, prefilled, nil
+}
+
+// prefill returns how many packs of the bulk size (an unlimited size, -1 if there is none) any best packing
+// takes. Above the items the limited sizes can ship, the unlimited sizes ship the rest with the residue shortcut,
+// topping up their labels with the bulk size.
+func (s *stockSearch) prefill() (int, int, error) {
+	var unlimited []int
+	limited := 0
+	for i, size := range s.sizes {
+		if s.limits[i] < 0 {
+			unlimited = append(unlimited, size)
+		} else {
+			limited += s.limits[i] * size
+		}
+	}
+	if len(unlimited) == 0 {
+		return -1, 0, nil
+	}
+
+	var bulk, labelItems int
+	if s.order.Objective == ObjectiveCost {
+		prices := make([]model.PackagePrice, 0, len(unlimited))
+		for _, size := range unlimited {
+			prices = append(prices, model.PackagePrice{Size: size, UnitCost: int64(s.costs[slices.Index(s.sizes, size)])})
+		}
+		p, err := newCostPacker(Order{Units: s.order.Units, PackageSizes: unlimited, PackagePrices: prices})
+		if err != nil {
+			return 0, 0, err
+		}
+		bulk, labelItems = p.sizes[p.modulus]*p.divisor, p.maxLabelItems()*p.divisor
+	} else {
+		p, err := newPacker(unlimited)
+		if err != nil {
+			return 0, 0, s.order.limit(err.Error())
+		}
+		bulk, labelItems = p.largest*p.divisor, p.maxLabelItems()*p.divisor
+	}
+
+	// keep a bulk pack of margin so the table still sees the labels of every remainder class
+	packs := (s.lo-limited-labelItems)/bulk - 1
+	return slices.Index(s.sizes, bulk), max(packs, 0), nil
+}
+
+// stockTable is a dynamic programming table like packTable where the limited sizes are split in groups of
+// 1, 2, 4... packs that can each be taken once. Amounts are ranked by cost, then by packs.
+type stockTable struct {
+	groups []stockGroup
+	cost   []int
+	packs  []uint32
+	took   [][]uint64 // took[j] has a bit set for the amounts that took groups[j] last
+	sizes  int
+}
+
+type stockGroup struct {
+	index     int // of the size
+	packs     int
+	items     int
+	cost      int
+	unlimited bool
+}
+
+func (s *stockSearch) newStockTable(total int) *stockTable {
+	t := &stockTable{
+		cost:  make([]int, total+1),
+		packs: make([]uint32, total+1),
+		sizes: len(s.sizes),
+	}
+	for i := range t.packs {
+		t.packs[i] = unreachablePacks
+	}
+	t.packs[0] = 0
+
+	for i, size := range s.sizes {
+		if s.limits[i] < 0 {
+			t.groups = append(t.groups, stockGroup{index: i, packs: 1, items: size, cost: s.costs[i], unlimited: true})
+			continue
+		}
+		for packs, left := 1, s.limits[i]; left > 0; packs *= 2 {
+			packs = min(packs, left)
+			t.groups = append(t.groups, stockGroup{index: i, packs: packs, items: packs * size, cost: packs * s.costs[i]})
+			left -= packs
+		}
+	}
+
+	words := total/64 + 1
+	t.took = make([][]uint64, len(t.groups))
+	for j, group := range t.groups {
+		t.took[j] = make([]uint64, words)
+		if group.items > total {
+			continue
+		}
+		add := func(amount int) {
+			prev := amount - group.items
+			if t.packs[prev] == unreachablePacks {
+				return
+			}
+			cost, packs := t.cost[prev]+group.cost, t.packs[prev]+uint32(group.packs)
+			if t.packs[amount] != unreachablePacks &&
+				(cost > t.cost[amount] || (cost == t.cost[amount] && packs > t.packs[amount])) {
+				return
+			}
+			t.cost[amount], t.packs[amount] = cost, packs
+			t.took[j][amount/64] |= 1 << (amount % 64)
+		}
+		// an unlimited size can be taken again at any amount, a group of limited packs only once
+		if group.unlimited {
+			for amount := group.items; amount <= total; amount++ {
+				add(amount)
+			}
+		} else {
+			for amount := total; amount >= group.items; amount-- {
+				add(amount)
+			}
+		}
+	}
+	return t
+}
+
+// counts rebuilds the packs per size for an amount of the table.
+func (t *stockTable) counts(amount int) []int {
+	counts := make([]int, t.sizes)
+	for j := len(t.groups) - 1; amount > 0 && j >= 0; {
+		group := t.groups[j]
+		if t.took[j][amount/64]&(1<<(amount%64)) == 0 {
+			j--
+			continue
+		}
+		counts[group.index] += group.packs
+		amount -= group.items
+		if !group.unlimited {
+			j--
+		}
+	}
+	return counts
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// bruteForceStock ranks every amount below units+window packed within the stock, like bruteForce and bruteForceCost.
+func bruteForceStock(order Order, window int) (cost, items, packs int, ok bool) {
+	type rank struct{ cost, packs int }
+	best := make([]*rank, order.Units+window)
+	best[0] = &rank{}
+	for _, size := range order.PackageSizes {
+		limit := len(best)
+		if i := slices.IndexFunc(order.Stock, func(s model.PackageStock) bool { return s.Size == size }); i >= 0 {
+			limit = order.Stock[i].Available
+		}
+		unitCost := 0
+		if i := slices.IndexFunc(order.PackagePrices, func(p model.PackagePrice) bool { return p.Size == size }); i >= 0 && order.Objective == ObjectiveCost {
+			unitCost = int(order.PackagePrices[i].UnitCost)
+		}
+
+		// with unlimited supply, reuse the amounts already updated for this size
+		next := slices.Clone(best)
+		if limit == len(best) {
+			best = next
+			limit = 1
+		}
+		for amount := range best {
+			for count := 1; count <= limit && count*size <= amount; count++ {
+				prev := best[amount-count*size]
+				if prev == nil {
+					continue
+				}
+				candidate := rank{cost: prev.cost + count*unitCost, packs: prev.packs + count}
+				if next[amount] == nil || candidate.cost < next[amount].cost ||
+					(candidate.cost == next[amount].cost && candidate.packs < next[amount].packs) {
+					next[amount] = &candidate
+				}
+			}
+		}
+		best = next
+	}
+
+	for amount := order.Units; amount < len(best); amount++ {
+		if best[amount] == nil || (order.MaxOverfill != nil && amount-order.Units > *order.MaxOverfill) {
+			continue
+		}
+		if order.Objective == ObjectiveExact && amount != order.Units {
+			continue
+		}
+		better := !ok
+		switch order.Objective {
+		case ObjectivePacksFirst:
+			better = better || best[amount].packs < packs
+		case ObjectiveCost:
+			better = better || best[amount].cost < cost
+		}
+		if better {
+			cost, items, packs, ok = best[amount].cost, amount, best[amount].packs, true
+		}
+	}
+	return cost, items, packs, ok
+}
+
+// given random package sizes and stock - the dp solver ranks packings like a brute force search for every objective
+func TestStockMatchesBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 2000 {
+		order := Order{
+			Units:     1 + random.Intn(5000),
+			Objective: []Objective{ObjectiveItemsFirst, ObjectivePacksFirst, ObjectiveExact, ObjectiveCost}[random.Intn(4)],
+		}
+		for range 1 + random.Intn(4) {
+			size := 1 + random.Intn(40)
+			if slices.Contains(order.PackageSizes, size) {
+				continue
+			}
+			order.PackageSizes = append(order.PackageSizes, size)
+			order.PackagePrices = append(order.PackagePrices, model.PackagePrice{Size: size, UnitCost: int64(random.Intn(100)), Currency: "GBP"})
+			if random.Intn(4) != 0 {
+				order.Stock = append(order.Stock, model.PackageStock{Size: size, Available: random.Intn(20)})
+			}
+		}
+		if random.Intn(3) == 0 {
+			maxOverfill := random.Intn(50)
+			order.MaxOverfill = &maxOverfill
+		}
+
+		wantCost, wantItems, wantPacks, ok := bruteForceStock(order, slices.Max(order.PackageSizes))
+		res, err := solvers[SolverDP].Solve(order)
+		if !ok {
+			if !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, ErrNoFeasiblePacking) {
+				t.Fatalf("%+v: want no feasible packing, got %v %v", order, res, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v: %v", order, err)
+		}
+		items, packs := countItemsAndPacks(res)
+		cost, _ := totalCost(res, order.PackagePrices)
+		if !inStock(res, order.Stock) || items != wantItems || packs != wantPacks ||
+			(order.Objective == ObjectiveCost && int(*cost) != wantCost) {
+			t.Fatalf("%+v: want %d items in %d packs costing %d, got %v", order, wantItems, wantPacks, wantCost, res)
+		}
+	}
+}
+
+// given 23 31 53 Items with ten 53 packs in stock - test that a big order stays within the stock
+func TestStockBigOrder(t *testing.T) {
+	res, err := solvers[SolverDP].Solve(Order{
+		Units:        5_000_000,
+		PackageSizes: []int{23, 31, 53},
+		Stock:        []model.PackageStock{{Size: 53, Available: 10}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, _ := countItemsAndPacks(res)
+	if items != 5_000_000 || !inStock(res, []model.PackageStock{{Size: 53, Available: 10}}) {
+		t.Fatalf("want 5000000 items with at most ten 53 packs, got %v", res)
+	}
+}
+
+// given 250 500 1000 2000 5000 Items with three 5000 packs in stock - test calculating from stock
+func TestCalculatePackagesWithStock(t *testing.T) {
+	product := &model.Product{
+		ID:           uuid.NewString(),
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+		PackageStock: []model.PackageStock{{Size: 5000, Available: 3}, {Size: 2000, Available: 0}},
+	}
+	service := NewPackageService(&mockPackageStorage{wantRes: product})
+
+	res, err := service.CalculatePackages(context.TODO(), "ABC", 20001, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 1000, Amount: 5}, {Size: 5000, Amount: 3}}
+	if !slices.Equal(res.PackageUnits, want) {
+		t.Fatalf("want %v got %v", want, res.PackageUnits)
+	}
+
+	product.PackageStock = []model.PackageStock{{Size: 250, Available: 1}, {Size: 500, Available: 1}, {Size: 1000, Available: 0},
+		{Size: 2000, Available: 1}, {Size: 5000, Available: 3}}
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 20001, CalculateOptions{})
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.InStock != 17750 {
+		t.Fatalf("want an insufficient stock error with 17750 items in stock, got %v", err)
+	}
+
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 20001, CalculateOptions{Solver: SolverGreedy})
+	if !errors.Is(err, ErrStockNotSupported) {
+		t.Fatalf("want %v got %v", ErrStockNotSupported, err)
+	}
+}
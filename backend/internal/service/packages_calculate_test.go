@@ -5,6 +5,8 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/storage"
+	"math"
+	"math/rand"
 	"slices"
 	"testing"
 
@@ -86,7 +88,7 @@ func TestCalculatePackages(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity)
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
 		if err != nil {
 			t.Fail()
 		}
@@ -142,7 +144,7 @@ func TestCalculatePackagesMoreScenarios(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity)
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
 		if err != nil {
 			t.Fail()
 		}
@@ -158,7 +160,7 @@ func TestCalculatePackagesMoreScenarios(t *testing.T) {
 	}
 }
 
-// given 23 31 53 Items - test 500000
+// given 23 31 53 Items - test 500000, 5000000
 func TestCalculatePackagesMoreRequirementsExamples(t *testing.T) {
 	mockStorage := &mockPackageStorage{
 		wantRes: &model.Product{
@@ -177,10 +179,14 @@ func TestCalculatePackagesMoreRequirementsExamples(t *testing.T) {
 			quantity:    500000,
 			expectedRes: []model.PackageUnit{{Size: 23, Amount: 2}, {Size: 31, Amount: 7}, {Size: 53, Amount: 9429}},
 		},
+		{
+			quantity:    5000000,
+			expectedRes: []model.PackageUnit{{Size: 23, Amount: 2}, {Size: 31, Amount: 3}, {Size: 53, Amount: 94337}},
+		},
 	}
 
 	for _, testCase := range tests {
-		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity)
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
 		if err != nil {
 			t.Fail()
 		}
@@ -265,7 +271,7 @@ func TestCalculatePackagesRequirementsExamples(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity)
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
 		if err != nil {
 			t.Fail()
 		}
@@ -287,7 +293,7 @@ func TestCalculatePackagesOnInvalidProduct(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantErr: storage.ErrProductNotFound}
 	service := NewPackageService(mockStorage)
 
-	_, err := service.CalculatePackages(context.TODO(), "ABC", 100)
+	_, err := service.CalculatePackages(context.TODO(), "ABC", 100, CalculateOptions{})
 	if err == nil || !errors.Is(err, ErrProductNotFound) {
 		t.Fail()
 	}
@@ -297,8 +303,160 @@ func TestCalculatePackagesOnProductWithoutPackageSizes(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC"}}
 	service := NewPackageService(mockStorage)
 
-	_, err := service.CalculatePackages(context.TODO(), "ABC", 100)
+	_, err := service.CalculatePackages(context.TODO(), "ABC", 100, CalculateOptions{})
 	if err == nil || !errors.Is(err, ErrProductWithoutPackages) {
 		t.Fail()
 	}
 }
+
+// given package sizes too spread for the residue tables - test small orders are still calculated
+func TestCalculatePackagesSpreadSizes(t *testing.T) {
+	tests := []struct {
+		packageSizes []int
+		quantity     int
+		objective    Objective
+		want         []model.PackageUnit
+	}{
+		{packageSizes: []int{3, 2000000}, quantity: 100, want: []model.PackageUnit{{Size: 3, Amount: 34}}},
+		{packageSizes: []int{3, 2000000}, quantity: 2000002, want: []model.PackageUnit{{Size: 3, Amount: 1}, {Size: 2000000, Amount: 1}}},
+		{packageSizes: []int{3, 2000000}, quantity: 100, objective: ObjectivePacksFirst, want: []model.PackageUnit{{Size: 2000000, Amount: 1}}},
+		{packageSizes: []int{4999999, 5000000}, quantity: 100, want: []model.PackageUnit{{Size: 4999999, Amount: 1}}},
+		{packageSizes: []int{4999999, 5000000}, quantity: 5000001, want: []model.PackageUnit{{Size: 4999999, Amount: 2}}},
+	}
+
+	for _, testCase := range tests {
+		mockStorage := &mockPackageStorage{
+			wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: testCase.packageSizes},
+		}
+		service := NewPackageService(mockStorage)
+
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{Objective: testCase.objective})
+		if err != nil {
+			t.Fatalf("sizes %v quantity %d: %v", testCase.packageSizes, testCase.quantity, err)
+		}
+		if !slices.Equal(res.PackageUnits, testCase.want) {
+			t.Fatalf("sizes %v quantity %d: want %v, got %v", testCase.packageSizes, testCase.quantity, testCase.want, res.PackageUnits)
+		}
+	}
+}
+
+// given adversarial package sizes - test orders close to the maximum int
+func TestCalculatePackagesOverflow(t *testing.T) {
+	tests := []struct {
+		packageSizes []int
+		quantity     int
+	}{
+		{packageSizes: []int{250, 500, 1000, 2000, 5000}, quantity: math.MaxInt},
+		{packageSizes: []int{math.MaxInt/2 + 1}, quantity: math.MaxInt/2 + 2},
+	}
+
+	for _, testCase := range tests {
+		mockStorage := &mockPackageStorage{
+			wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: testCase.packageSizes},
+		}
+		service := NewPackageService(mockStorage)
+
+		_, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
+		var overflowErr *OverflowError
+		if !errors.As(err, &overflowErr) || !errors.Is(err, ErrCalculationOverflow) {
+			t.Fatalf("sizes %v quantity %d: want overflow error, got %v", testCase.packageSizes, testCase.quantity, err)
+		}
+	}
+}
+
+// given package sizes too spread for the solver tables - test the answer fitting in an int isn't reported as an overflow
+func TestCalculatePackagesLimit(t *testing.T) {
+	tests := []struct {
+		packageSizes []int
+		quantity     int
+	}{
+		{packageSizes: []int{math.MaxInt - 1, math.MaxInt}, quantity: math.MaxInt - 1},
+		{packageSizes: []int{3, maxResidueClasses + 1}, quantity: maxTableSize},
+	}
+
+	for _, testCase := range tests {
+		mockStorage := &mockPackageStorage{
+			wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: testCase.packageSizes},
+		}
+		service := NewPackageService(mockStorage)
+
+		_, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || !errors.Is(err, ErrCalculationLimit) || errors.Is(err, ErrCalculationOverflow) {
+			t.Fatalf("sizes %v quantity %d: want limit error, got %v", testCase.packageSizes, testCase.quantity, err)
+		}
+	}
+}
+
+// given adversarial package sizes - test the packs cover the order and shipping is exact when it can be
+func TestCalculatePackagesAdversarialSizes(t *testing.T) {
+	tests := []struct {
+		packageSizes []int
+		quantity     int
+		wantItems    int
+	}{
+		{packageSizes: []int{1, 2}, quantity: math.MaxInt, wantItems: math.MaxInt},
+		{packageSizes: []int{3, 5}, quantity: math.MaxInt - 1, wantItems: math.MaxInt - 1},
+		{packageSizes: []int{math.MaxInt / 4}, quantity: 1, wantItems: math.MaxInt / 4},
+		{packageSizes: []int{999983, 1000003, 1000033}, quantity: 1000003*7 + 999983*5, wantItems: 1000003*7 + 999983*5},
+		{packageSizes: []int{999983, 1000003, 1000033}, quantity: 1000000000000, wantItems: 1000000000000},
+		{packageSizes: []int{65521, 65519, 65497, 65479}, quantity: math.MaxInt - 12345, wantItems: math.MaxInt - 12345},
+		{packageSizes: []int{1 << 40, 3 << 40}, quantity: 1<<40 + 1, wantItems: 2 << 40},
+	}
+
+	for _, testCase := range tests {
+		mockStorage := &mockPackageStorage{
+			wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: testCase.packageSizes},
+		}
+		service := NewPackageService(mockStorage)
+
+		res, err := service.CalculatePackages(context.TODO(), "ABC", testCase.quantity, CalculateOptions{})
+		if err != nil {
+			t.Fatalf("sizes %v quantity %d: %v", testCase.packageSizes, testCase.quantity, err)
+		}
+		items := 0
+		for _, packageUnit := range res.PackageUnits {
+			items += packageUnit.Amount * packageUnit.Size
+		}
+		if items != testCase.wantItems {
+			t.Fatalf("sizes %v quantity %d: want %d items, got %d", testCase.packageSizes, testCase.quantity, testCase.wantItems, items)
+		}
+	}
+}
+
+// given random package sizes - test minTotal finds the amount of the cheapest remainder class
+func TestMinTotalMatchesResidueClasses(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	for range 300 {
+		sizes := make([]int, 1+rnd.Intn(4))
+		for i := range sizes {
+			sizes[i] = 1 + rnd.Intn(1000)
+		}
+		r, err := newReachability(sizes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 20 {
+			lo := rnd.Intn(100_000)
+			want := -1
+			for class := range r.minReachable {
+				if total := r.minTotalInClass(lo, class); total >= 0 && (want < 0 || total < want) {
+					want = total
+				}
+			}
+			if got := r.minTotal(lo); got != want {
+				t.Fatalf("sizes %v from %d: want %d got %d", sizes, lo, want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkMinTotal(b *testing.B) {
+	r, err := newReachability([]int{52_000, 485_667, 871_113})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; b.Loop(); i++ {
+		r.minTotal(i % 1_000_000)
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"gymshark-interview/internal/model"
+	"maps"
+	"math"
+	"slices"
+)
+
+// solve returns the packs to ship for the cost objective: the lowest total cost, then the fewest items, then the
+// fewest packs. Only the package sizes with a price are used.
+//
+// It works like calculate with the remainder classes taken modulo the cheapest size per item: any order big
+// enough is the cheapest remainder class topped up with packs of that size.
+func (p *costPacker) solve(order Order) ([]model.PackageUnit, error) {
+	var err error
+	lo, hi := p.totalBounds(order)
+	best, bestCost := -1, 0
+	consider := func(total, cost int) {
+		if best < 0 || cost < bestCost || (cost == bestCost && total < best) {
+			best, bestCost = total, cost
+		}
+	}
+
+	// above the items of its label, a remainder class costs one more cheapest pack per cheapest size
+	for r, label := range p.cheapest {
+		if !label.reached {
+			continue
+		}
+		total := p.minTotalInModulus(max(lo, label.items), r)
+		if total < 0 || total > hi {
+			continue
+		}
+		if cost, ok := p.topUpCost(label, total); ok {
+			consider(total, cost)
+		}
+	}
+
+	// below the items of its label a bigger amount can cost less, so look at every amount
+	var table *costTable
+	if limit := min(hi, p.maxLabelItems()-1); lo <= limit {
+		if limit > maxTableSize {
+			return nil, order.limit(fmt.Sprintf("package sizes need a table of %d entries, the limit is %d", limit, maxTableSize))
+		}
+		if table, err = p.newCostTable(limit); err != nil {
+			return nil, order.overflow(err.Error())
+		}
+		for total := lo; total <= limit; total++ {
+			if table.packs[total] != unreachablePacks {
+				consider(total, table.cost[total])
+			}
+		}
+	}
+
+	if best < 0 {
+		if order.MaxOverfill != nil {
+			return nil, ErrNoFeasiblePacking
+		}
+		return nil, order.overflow("the amount of items to ship exceeds the maximum int")
+	}
+
+	var counts []int
+	if label := p.cheapest[best%p.sizes[p.modulus]]; label.reached && label.items <= best {
+		counts = slices.Clone(label.counts)
+		counts[p.modulus] = (best - label.items) / p.sizes[p.modulus]
+	} else {
+		counts = table.counts(best)
+	}
+	return p.packageUnits(counts), nil
+}
+
+// costPacker holds the per package sizes tables used by calculate for the cost objective.
+type costPacker struct {
+	*reachability
+
+	costs   []int // unit cost per normalised size
+	modulus int   // index of the cheapest size per item, the modulus of the tables
+
+	// cheapest[r] is the cheapest combination of packs, other than the modulus size, congruent to r modulo
+	// the modulus size.
+	cheapest []residueLabel
+}
+
+// unitCosts returns the unit cost of the package sizes of the order that have a price. The prices must share a
+// currency.
+func unitCosts(order Order) (map[int]int64, error) {
+	costs := map[int]int64{}
+	currency := ""
+	for _, price := range order.PackagePrices {
+		if !slices.Contains(order.PackageSizes, price.Size) {
+			continue
+		}
+		if currency != "" && price.Currency != currency {
+			return nil, ErrMixedCurrencies
+		}
+		if price.UnitCost < 0 {
+			return nil, ErrInvalidPrice
+		}
+		currency = price.Currency
+		costs[price.Size] = price.UnitCost
+	}
+	if len(costs) == 0 {
+		return nil, ErrMissingPackagePrices
+	}
+	return costs, nil
+}
+
+func newCostPacker(order Order) (*costPacker, error) {
+	unitCost, err := unitCosts(order)
+	if err != nil {
+		return nil, err
+	}
+	sizes := slices.Collect(maps.Keys(unitCost))
+
+	r, err := newReachability(sizes)
+	if err != nil {
+		return nil, order.limit(err.Error())
+	}
+	p := &costPacker{reachability: r, costs: make([]int, len(r.sizes))}
+	for i, size := range r.sizes {
+		cost := unitCost[size*r.divisor]
+		if cost > int64(math.MaxInt/p.largest) {
+			return nil, order.overflow(fmt.Sprintf("unit cost %d is too big for package sizes", cost))
+		}
+		p.costs[i] = int(cost)
+		// compare cost per item, ties go to the bigger size
+		if p.costs[i]*p.sizes[p.modulus] <= p.costs[p.modulus]*size {
+			p.modulus = i
+		}
+	}
+
+	// topping a label up to a total T costs (costs[modulus]*T+weight)/sizes[modulus], and the tie weight
+	// counts the packs the same way
+	classes := p.sizes[p.modulus]
+	weights := make([]int, len(p.sizes))
+	for i, size := range p.sizes {
+		weights[i] = p.costs[i]*classes - size*p.costs[p.modulus]
+		if weights[i] > math.MaxInt/classes {
+			return nil, order.overflow(fmt.Sprintf("unit cost %d is too big for package sizes", p.costs[i]))
+		}
+	}
+	p.cheapest = p.shortestLabels(p.modulus, func(i int) (int, int) {
+		return weights[i], classes - p.sizes[i]
+	})
+	return p, nil
+}
+
+// minTotalInModulus returns the smallest normalised amount of items, at least lo and congruent to r modulo the
+// modulus size. It returns -1 when it doesn't fit in an int.
+func (p *costPacker) minTotalInModulus(lo, r int) int {
+	classes := p.sizes[p.modulus]
+	step := (r - lo%classes + classes) % classes
+	if lo > math.MaxInt-step {
+		return -1
+	}
+	return lo + step
+}
+
+// topUpCost returns the cost of a label topped up to total with packs of the modulus size.
+func (p *costPacker) topUpCost(label residueLabel, total int) (int, bool) {
+	cost := 0
+	for i, count := range label.counts {
+		cost += count * p.costs[i]
+	}
+	extra := (total - label.items) / p.sizes[p.modulus]
+	if unitCost := p.costs[p.modulus]; unitCost > 0 && extra > (math.MaxInt-cost)/unitCost {
+		return 0, false
+	}
+	return cost + extra*p.costs[p.modulus], true
+}
+
+// maxLabelItems returns the biggest amount of items among the labels, below it the residue shortcut may not apply.
+func (p *costPacker) maxLabelItems() int {
+	res := 0
+	for _, label := range p.cheapest {
+		if label.reached {
+			res = max(res, label.items)
+		}
+	}
+	return res
+}
+
+// costTable solves small totals like packTable, ranking the amounts by cost and then by packs.
+type costTable struct {
+	sizes []int
+	cost  []int
+	packs []uint32
+	took  [][]uint64
+}
+
+func (p *costPacker) newCostTable(total int) (*costTable, error) {
+	if slices.Max(p.costs) > math.MaxInt/(total+1) {
+		return nil, fmt.Errorf("the cost of %d items exceeds the maximum int", total)
+	}
+
+	cost := make([]int, total+1)
+	packs := make([]uint32, total+1)
+	for i := range packs {
+		packs[i] = unreachablePacks
+	}
+	packs[0] = 0
+
+	words := total/64 + 1
+	took := make([][]uint64, len(p.sizes))
+	for i, size := range p.sizes {
+		took[i] = make([]uint64, words)
+		for amount := size; amount <= total; amount++ {
+			prev := amount - size
+			if packs[prev] == unreachablePacks {
+				continue
+			}
+			candidateCost, candidatePacks := cost[prev]+p.costs[i], packs[prev]+1
+			if packs[amount] != unreachablePacks &&
+				(candidateCost > cost[amount] || (candidateCost == cost[amount] && candidatePacks > packs[amount])) {
+				continue
+			}
+			cost[amount], packs[amount] = candidateCost, candidatePacks
+			took[i][amount/64] |= 1 << (amount % 64)
+		}
+	}
+	return &costTable{sizes: p.sizes, cost: cost, packs: packs, took: took}, nil
+}
+
+// counts rebuilds the packs per size for an amount of the table.
+func (t *costTable) counts(amount int) []int {
+	return rebuildCounts(t.sizes, t.took, amount)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// bruteForceCost ranks every amount from units up to units+window by cost, then items, then packs.
+func bruteForceCost(order Order, window int) (cost, items, packs int, ok bool) {
+	type rank struct{ cost, packs int }
+	best := make([]*rank, order.Units+window+1)
+	best[0] = &rank{}
+	for amount := 1; amount < len(best); amount++ {
+		for _, price := range order.PackagePrices {
+			prev := amount - price.Size
+			if prev < 0 || best[prev] == nil {
+				continue
+			}
+			candidate := rank{cost: best[prev].cost + int(price.UnitCost), packs: best[prev].packs + 1}
+			if best[amount] == nil || candidate.cost < best[amount].cost ||
+				(candidate.cost == best[amount].cost && candidate.packs < best[amount].packs) {
+				best[amount] = &candidate
+			}
+		}
+	}
+
+	for amount := order.Units; amount < len(best); amount++ {
+		if best[amount] == nil || (order.MaxOverfill != nil && amount-order.Units > *order.MaxOverfill) {
+			continue
+		}
+		if !ok || best[amount].cost < cost {
+			cost, items, packs, ok = best[amount].cost, amount, best[amount].packs, true
+		}
+	}
+	return cost, items, packs, ok
+}
+
+// given random priced package sizes - the dp solver ranks packings like a brute force search
+func TestCostObjectiveMatchesBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 1000 {
+		order := Order{Units: 1 + random.Intn(2000), Objective: ObjectiveCost}
+		for range 1 + random.Intn(4) {
+			size := 1 + random.Intn(40)
+			if slices.Contains(order.PackageSizes, size) {
+				continue
+			}
+			order.PackageSizes = append(order.PackageSizes, size)
+			order.PackagePrices = append(order.PackagePrices, model.PackagePrice{
+				Size:     size,
+				UnitCost: int64(random.Intn(100)),
+				Currency: "GBP",
+			})
+		}
+		if random.Intn(2) == 0 {
+			maxOverfill := random.Intn(50)
+			order.MaxOverfill = &maxOverfill
+		}
+
+		wantCost, wantItems, wantPacks, ok := bruteForceCost(order, slices.Max(order.PackageSizes))
+		res, err := solvers[SolverDP].Solve(order)
+		if !ok {
+			if !errors.Is(err, ErrNoFeasiblePacking) {
+				t.Fatalf("%+v: want no feasible packing, got %v %v", order, res, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%+v: %v", order, err)
+		}
+		items, packs := countItemsAndPacks(res)
+		cost, _ := totalCost(res, order.PackagePrices)
+		if cost == nil || int(*cost) != wantCost || items != wantItems || packs != wantPacks {
+			t.Fatalf("%+v: want %d items in %d packs costing %d, got %v", order, wantItems, wantPacks, wantCost, res)
+		}
+	}
+}
+
+// given 250 500 1000 2000 5000 Items where only some sizes are priced - test the cost objective
+func TestCalculatePackagesCost(t *testing.T) {
+	product := &model.Product{
+		ID:           uuid.NewString(),
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+		PackagePrices: []model.PackagePrice{
+			{Size: 250, UnitCost: 100, Currency: "GBP"},
+			{Size: 1000, UnitCost: 300, Currency: "GBP"},
+			{Size: 5000, UnitCost: 2000, Currency: "GBP"},
+		},
+	}
+	service := NewPackageService(&mockPackageStorage{wantRes: product})
+
+	// 5x1000 is cheaper than 1x5000, the 500 and 2000 sizes have no price
+	res, err := service.CalculatePackages(context.TODO(), "ABC", 5001, CalculateOptions{Objective: ObjectiveCost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 1000, Amount: 5}}
+	if !slices.Equal(res.PackageUnits, want) || res.TotalCost == nil || *res.TotalCost != 1600 || res.Currency != "GBP" {
+		t.Fatalf("want %v costing 1600 GBP, got %+v", want, res)
+	}
+
+	// the default objective reports a cost only when every size used has a price
+	res, err = service.CalculatePackages(context.TODO(), "ABC", 501, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalCost != nil {
+		t.Fatalf("want no total cost, got %+v", res)
+	}
+
+	product.PackagePrices[0].Currency = "EUR"
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 5001, CalculateOptions{Objective: ObjectiveCost})
+	if !errors.Is(err, ErrMixedCurrencies) {
+		t.Fatalf("want %v got %v", ErrMixedCurrencies, err)
+	}
+
+	product.PackagePrices = nil
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 5001, CalculateOptions{Objective: ObjectiveCost})
+	if !errors.Is(err, ErrMissingPackagePrices) {
+		t.Fatalf("want %v got %v", ErrMissingPackagePrices, err)
+	}
+
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 5001, CalculateOptions{Solver: SolverBranchAndBound, Objective: ObjectiveCost})
+	if !errors.Is(err, ErrObjectiveNotSupported) {
+		t.Fatalf("want %v got %v", ErrObjectiveNotSupported, err)
+	}
+}
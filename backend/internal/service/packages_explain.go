@@ -0,0 +1,186 @@
+package service
+
+import (
+	"gymshark-interview/internal/model"
+	"maps"
+	"slices"
+)
+
+// Rules a rejected packing can lose on, see docs/REQUIREMENTS.md for the default objective.
+const (
+	RuleWholePacks         = "whole-packs"
+	RuleFewestItems        = "fewest-items"
+	RuleFewestPacks        = "fewest-packs"
+	RuleLowestCost         = "lowest-cost"
+	RuleBiggerPackageSizes = "bigger-package-sizes"
+)
+
+// explainAlternatives is how many of the next best packings an explanation lists
+const explainAlternatives = 5
+
+// explain tells why the packing was chosen for the order. The rejected packings are:
+//   - the order shipped exactly with a partial pack, when the packing ships more items than ordered;
+//   - the answers of the other objectives, such as the fewest packs for the default objective;
+//   - the next best packings ranked by the objective.
+//
+// Each one is reported with the first rule of the objective it loses on.
+func explain(order Order, solver string, packing []model.PackageUnit) (*model.Explanation, error) {
+	order, err := normaliseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	sizes := order.PackageSizes
+	if order.Objective == ObjectiveCost {
+		unitCost, err := unitCosts(order)
+		if err != nil {
+			return nil, err
+		}
+		sizes = slices.Collect(maps.Keys(unitCost))
+	}
+
+	res := &model.Explanation{}
+	if solver == SolverDP {
+		if res.ResidueShortcut, err = residueShortcut(order, packing); err != nil {
+			return nil, err
+		}
+	}
+
+	if whole, ok := wholePacksCandidate(order, sizes, packing); ok {
+		res.Rejected = append(res.Rejected, whole)
+	}
+
+	var candidates [][]model.PackageUnit
+	var others []Objective
+	switch order.Objective {
+	case ObjectiveItemsFirst, ObjectiveExact:
+		others = []Objective{ObjectivePacksFirst}
+	case ObjectivePacksFirst:
+		others = []Objective{ObjectiveItemsFirst}
+	case ObjectiveCost:
+		others = []Objective{ObjectiveItemsFirst, ObjectivePacksFirst}
+	}
+	for _, objective := range others {
+		other := order
+		other.Objective, other.PackageSizes = objective, sizes
+		if candidate, err := calculate(other); err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	next, err := alternatives(order, explainAlternatives+1, maxAlternativeNodes)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, next...)
+
+	// only keep the distinct packings ranked after the chosen one
+	rank := func(packageUnits []model.PackageUnit) [3]int {
+		items, packs := countItemsAndPacks(packageUnits)
+		cost := 0
+		if order.Objective == ObjectiveCost {
+			if total, _ := totalCost(packageUnits, order.PackagePrices); total != nil {
+				cost = int(*total)
+			}
+		}
+		return rankPacking(order.Objective, items, packs, cost)
+	}
+	chosen := rank(packing)
+	var rejected [][]model.PackageUnit
+	for _, candidate := range candidates {
+		seen := func(p []model.PackageUnit) bool { return slices.Equal(p, candidate) }
+		if r := rank(candidate); slices.Compare(r[:], chosen[:]) < 0 || seen(packing) || slices.ContainsFunc(rejected, seen) {
+			continue
+		}
+		rejected = append(rejected, candidate)
+	}
+	slices.SortStableFunc(rejected, func(a, b []model.PackageUnit) int {
+		ra, rb := rank(a), rank(b)
+		return slices.Compare(ra[:], rb[:])
+	})
+
+	rules := []string{RuleFewestItems, RuleFewestPacks}
+	switch order.Objective {
+	case ObjectivePacksFirst:
+		rules = []string{RuleFewestPacks, RuleFewestItems}
+	case ObjectiveCost:
+		rules = []string{RuleLowestCost, RuleFewestItems, RuleFewestPacks}
+	}
+	for _, candidate := range rejected {
+		rule := RuleBiggerPackageSizes
+		r := rank(candidate)
+		for i := range rules {
+			if r[i] != chosen[i] {
+				rule = rules[i]
+				break
+			}
+		}
+		rejectedPackage := model.RejectedPackage{Package: model.Package{PackageUnits: candidate}, Rule: rule}
+		rejectedPackage.TotalCost, rejectedPackage.Currency = totalCost(candidate, order.PackagePrices)
+		res.Rejected = append(res.Rejected, rejectedPackage)
+	}
+	return res, nil
+}
+
+// wholePacksCandidate returns the packing shipping exactly the order with a partial pack: the most items that whole
+// packs of the sizes ship without going over the order, and the rest taken out of a pack of the smallest size.
+func wholePacksCandidate(order Order, sizes []int, packing []model.PackageUnit) (model.RejectedPackage, bool) {
+	items, _ := countItemsAndPacks(packing)
+	if items <= order.Units {
+		return model.RejectedPackage{}, false
+	}
+	r, err := newReachability(sizes)
+	if err != nil {
+		return model.RejectedPackage{}, false
+	}
+	total := r.maxTotal(order.Units / r.divisor)
+
+	var whole []model.PackageUnit
+	if total > 0 {
+		exact := order
+		exact.Units, exact.PackageSizes, exact.Objective = total*r.divisor, sizes, ObjectiveExact
+		if order.Objective == ObjectiveCost {
+			noOverfill := 0
+			exact.Objective, exact.MaxOverfill = ObjectiveCost, &noOverfill
+		}
+		// the stock may not ship that many items exactly
+		if whole, err = calculate(exact); err != nil {
+			return model.RejectedPackage{}, false
+		}
+	}
+	res := model.RejectedPackage{
+		Package:      model.Package{PackageUnits: whole},
+		Rule:         RuleWholePacks,
+		PartialItems: order.Units - total*r.divisor,
+	}
+	res.TotalCost, res.Currency = totalCost(whole, order.PackagePrices)
+	return res, true
+}
+
+// residueShortcut reports whether calculate packed the order with the residue shortcut: the best packing of its
+// remainder class topped up with packs of the modulus size, rather than a dynamic programming table.
+func residueShortcut(order Order, packing []model.PackageUnit) (bool, error) {
+	// a packing calculated within the stock comes from the stock table
+	unlimited := order
+	unlimited.Stock = nil
+	best, err := calculate(unlimited)
+	if err != nil || !slices.Equal(best, packing) {
+		return false, nil
+	}
+
+	items, _ := countItemsAndPacks(packing)
+	if order.Objective == ObjectiveCost {
+		p, err := newCostPacker(order)
+		if err != nil {
+			return false, err
+		}
+		total := items / p.divisor
+		label := p.cheapest[total%p.sizes[p.modulus]]
+		return label.reached && label.items <= total, nil
+	}
+	p, err := newPacker(order.PackageSizes)
+	if err != nil {
+		return false, order.limit(err.Error())
+	}
+	total := items / p.divisor
+	label := p.fewestPacks[total%p.largest]
+	return label.reached && label.items <= total, nil
+}
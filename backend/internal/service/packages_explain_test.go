@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"gymshark-interview/internal/model"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// given 250 500 1000 2000 5000 Items and 12001 Units - test the rejected packings and the rule they lost on
+func TestCalculatePackagesExplain(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantRes: &model.Product{
+		ID:           uuid.NewString(),
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+	}})
+
+	res, err := service.CalculatePackages(context.TODO(), "ABC", 12001, CalculateOptions{Explain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation := res.Explanation
+	if explanation == nil || !explanation.ResidueShortcut || len(explanation.Rejected) < 3 {
+		t.Fatalf("unexpected explanation %+v", explanation)
+	}
+
+	// shipping exactly 12001 items needs 1 item out of a pack
+	whole := explanation.Rejected[0]
+	want := []model.PackageUnit{{Size: 2000, Amount: 1}, {Size: 5000, Amount: 2}}
+	if whole.Rule != RuleWholePacks || whole.PartialItems != 1 || !slices.Equal(whole.PackageUnits, want) {
+		t.Fatalf("want %v and a partial pack rejected on %s, got %+v", want, RuleWholePacks, whole)
+	}
+	// 3x5000 takes fewer packs but more items
+	fewestPacks := explanation.Rejected[len(explanation.Rejected)-1]
+	want = []model.PackageUnit{{Size: 5000, Amount: 3}}
+	if fewestPacks.Rule != RuleFewestItems || !slices.Equal(fewestPacks.PackageUnits, want) {
+		t.Fatalf("want %v rejected on %s, got %+v", want, RuleFewestItems, fewestPacks)
+	}
+	// the others ship as many items in more packs
+	for _, rejected := range explanation.Rejected[1 : len(explanation.Rejected)-1] {
+		items, packs := countItemsAndPacks(rejected.PackageUnits)
+		if rejected.Rule != RuleFewestPacks || items != 12250 || packs <= 4 {
+			t.Fatalf("want 12250 items in more than 4 packs rejected on %s, got %+v", RuleFewestPacks, rejected)
+		}
+	}
+
+	res, err = service.CalculatePackages(context.TODO(), "ABC", 12001, CalculateOptions{})
+	if err != nil || res.Explanation != nil {
+		t.Fatalf("want no explanation, got %+v %v", res, err)
+	}
+}
+
+// given 2 5 6 Items and 3 Units - test the order is too small for the residue shortcut
+func TestExplainWithoutResidueShortcut(t *testing.T) {
+	order := Order{Units: 3, PackageSizes: []int{2, 5, 6}}
+	packing, err := calculate(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err := explain(order, SolverDP, packing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.ResidueShortcut {
+		t.Fatalf("want no residue shortcut for %v", packing)
+	}
+
+	order = Order{Units: 12001, PackageSizes: []int{250, 500, 1000, 2000, 5000}}
+	packing, err = solvers[SolverGreedy].Solve(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err = explain(order, SolverGreedy, packing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.ResidueShortcut {
+		t.Fatal("want no residue shortcut for the greedy solver")
+	}
+}
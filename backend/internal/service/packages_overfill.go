@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"maps"
+	"math"
+	"slices"
+)
+
+// NoFeasiblePackingError is returned when no packing ships an order within its maximum overfill, an exact order
+// having a maximum overfill of 0. Below and Above are the nearest amounts of items that whole packs, within the
+// stock, can ship.
+type NoFeasiblePackingError struct {
+	Units       int
+	MaxOverfill int
+	Below       int
+	// Above is -1 when it doesn't fit in an int or the stock can't ship that many items.
+	Above int
+}
+
+func (e *NoFeasiblePackingError) Error() string {
+	if e.Above < 0 {
+		return fmt.Sprintf("no packing ships %d units within a maximum overfill of %d items, the nearest quantity that can be shipped is %d",
+			e.Units, e.MaxOverfill, e.Below)
+	}
+	return fmt.Sprintf("no packing ships %d units within a maximum overfill of %d items, the nearest quantities that can be shipped are %d and %d",
+		e.Units, e.MaxOverfill, e.Below, e.Above)
+}
+
+func (e *NoFeasiblePackingError) Unwrap() error {
+	return ErrNoFeasiblePacking
+}
+
+// noFeasiblePacking returns the NoFeasiblePackingError of an order, with the nearest quantities whole packs of its
+// package sizes can ship within its stock, the priced ones for the cost objective.
+func noFeasiblePacking(order Order) error {
+	sizes := order.PackageSizes
+	if order.Objective == ObjectiveCost {
+		if unitCost, err := unitCosts(order); err == nil {
+			sizes = slices.Collect(maps.Keys(unitCost))
+		}
+	}
+
+	res := &NoFeasiblePackingError{
+		Units: order.Units,
+		Above: -1,
+	}
+	if order.MaxOverfill != nil && order.Objective != ObjectiveExact {
+		res.MaxOverfill = *order.MaxOverfill
+	}
+	if len(order.Stock) > 0 {
+		nearest := Order{Units: order.Units, PackageSizes: sizes, Stock: order.Stock}
+		below, err := maxTotalInStock(nearest)
+		if err != nil {
+			return ErrNoFeasiblePacking
+		}
+		res.Below = below
+		if packageUnits, err := calculate(nearest); err == nil {
+			res.Above, _ = countItemsAndPacks(packageUnits)
+		}
+		return res
+	}
+
+	r, err := newReachability(sizes)
+	if err != nil {
+		return ErrNoFeasiblePacking
+	}
+	res.Below = r.maxTotal(order.Units/r.divisor) * r.divisor
+	if above := r.minTotal(ceilDiv(order.Units, r.divisor)); above >= 0 && above <= math.MaxInt/r.divisor {
+		res.Above = above * r.divisor
+	}
+	return res
+}
+
+// maxOverfill returns the smallest cap of the limits for an order of units, nil when none of them caps it.
+func maxOverfill(units int, limits []model.OverfillLimit) *int {
+	var res *int
+	for _, limit := range limits {
+		caps := []*int{limit.Items}
+		if limit.Percent != nil {
+			items := saturatingMul(max(units, 0), *limit.Percent) / 100
+			caps = append(caps, &items)
+		}
+		for _, c := range caps {
+			if c != nil && (res == nil || *c < *res) {
+				res = c
+			}
+		}
+	}
+	return res
+}
+
+func validateOverfillLimit(limit model.OverfillLimit) error {
+	if (limit.Items != nil && *limit.Items < 0) || (limit.Percent != nil && *limit.Percent < 0) {
+		return ErrInvalidMaxOverfill
+	}
+	return nil
+}
+
+// SetMaxOverfill sets the overfill limit applied to every calculation of a product, an empty limit removes it.
+func (s *Packages) SetMaxOverfill(ctx context.Context, productID string, limit model.OverfillLimit, ifVersion int) (*model.Product, error) {
+	if err := validateOverfillLimit(limit); err != nil {
+		return nil, err
+	}
+	err := s.storage.SetProductMaxOverfill(ctx, productID, limit, ifVersion)
+	if err != nil {
+		if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+	return s.getProduct(ctx, productID)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// overfillLimit returns a limit with the caps that aren't negative.
+func overfillLimit(items, percent int) model.OverfillLimit {
+	var limit model.OverfillLimit
+	if items >= 0 {
+		limit.Items = &items
+	}
+	if percent >= 0 {
+		limit.Percent = &percent
+	}
+	return limit
+}
+
+// given limits from the product and the request - test the smallest cap applies
+func TestMaxOverfill(t *testing.T) {
+	testCases := []struct {
+		units  int
+		limits []model.OverfillLimit
+		want   int
+	}{
+		{units: 12001, limits: []model.OverfillLimit{overfillLimit(500, -1), overfillLimit(-1, 1)}, want: 120},
+		{units: 12001, limits: []model.OverfillLimit{overfillLimit(100, 1), overfillLimit(-1, -1)}, want: 100},
+		{units: 99, limits: []model.OverfillLimit{overfillLimit(-1, 1)}, want: 0},
+		{units: 12001, limits: []model.OverfillLimit{overfillLimit(-1, -1), overfillLimit(0, 10)}, want: 0},
+	}
+	for _, testCase := range testCases {
+		got := maxOverfill(testCase.units, testCase.limits)
+		if got == nil || *got != testCase.want {
+			t.Fatalf("%d units: want %d got %v", testCase.units, testCase.want, got)
+		}
+	}
+	if got := maxOverfill(12001, []model.OverfillLimit{overfillLimit(-1, -1)}); got != nil {
+		t.Fatalf("want no cap, got %d", *got)
+	}
+}
+
+// given 250 500 1000 2000 5000 Items and a product limit of 1% - test the nearest quantities are reported
+func TestCalculatePackagesOverProductMaxOverfill(t *testing.T) {
+	product := &model.Product{
+		ID:           uuid.NewString(),
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+		MaxOverfill:  overfillLimit(-1, 1),
+	}
+	service := NewPackageService(&mockPackageStorage{wantRes: product})
+
+	_, err := service.CalculatePackages(context.TODO(), "ABC", 12001, CalculateOptions{})
+	var noPacking *NoFeasiblePackingError
+	if !errors.As(err, &noPacking) || !errors.Is(err, ErrNoFeasiblePacking) {
+		t.Fatalf("want %T got %v", noPacking, err)
+	}
+	if noPacking.MaxOverfill != 120 || noPacking.Below != 12000 || noPacking.Above != 12250 {
+		t.Fatalf("unexpected error %+v", noPacking)
+	}
+
+	// 1% of 100001 allows shipping 100250
+	if _, err = service.CalculatePackages(context.TODO(), "ABC", 100001, CalculateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the exact objective reports the nearest quantities as well
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 501, CalculateOptions{Objective: ObjectiveExact})
+	if !errors.As(err, &noPacking) || noPacking.MaxOverfill != 0 || noPacking.Below != 500 || noPacking.Above != 750 {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	negative := -5
+	product.MaxOverfill = model.OverfillLimit{Items: &negative}
+	_, err = service.CalculatePackages(context.TODO(), "ABC", 501, CalculateOptions{})
+	if !errors.Is(err, ErrInvalidMaxOverfill) {
+		t.Fatalf("want %v got %v", ErrInvalidMaxOverfill, err)
+	}
+}
+
+// given 250 500 1000 Items with some sizes short of stock and a limit of 50 items - test the nearest quantities
+// are the ones the stock ships
+func TestCalculatePackagesOverMaxOverfillWithStock(t *testing.T) {
+	testCases := []struct {
+		stock        []model.PackageStock
+		below, above int
+	}{
+		{stock: []model.PackageStock{{Size: 250, Available: 1}, {Size: 500, Available: 0}}, below: 12250, above: 13000},
+		{stock: []model.PackageStock{{Size: 250, Available: 0}, {Size: 500, Available: 1}}, below: 12000, above: 12500},
+		{stock: []model.PackageStock{{Size: 250, Available: 0}, {Size: 500, Available: 0}, {Size: 1000, Available: 12}}, below: 12000, above: -1},
+	}
+	for _, testCase := range testCases {
+		product := &model.Product{
+			ID:           uuid.NewString(),
+			Name:         "ABC",
+			PackageSizes: []int{250, 500, 1000},
+			PackageStock: testCase.stock,
+			MaxOverfill:  overfillLimit(50, -1),
+		}
+		service := NewPackageService(&mockPackageStorage{wantRes: product})
+
+		_, err := service.CalculatePackages(context.TODO(), "ABC", 12401, CalculateOptions{})
+		var noPacking *NoFeasiblePackingError
+		if !errors.As(err, &noPacking) {
+			t.Fatalf("stock %v: want %T got %v", testCase.stock, noPacking, err)
+		}
+		if noPacking.Below != testCase.below || noPacking.Above != testCase.above {
+			t.Fatalf("stock %v: unexpected error %+v", testCase.stock, noPacking)
+		}
+	}
+}
+
+func TestSetMaxOverfillFailsOnNegativeLimit(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{})
+
+	negative := -5
+	_, err := service.SetMaxOverfill(context.TODO(), "ABC", model.OverfillLimit{Percent: &negative}, 0)
+	if !errors.Is(err, ErrInvalidMaxOverfill) {
+		t.Fatalf("want %v got %v", ErrInvalidMaxOverfill, err)
+	}
+}
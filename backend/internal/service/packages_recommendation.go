@@ -0,0 +1,295 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"maps"
+	"math"
+	"slices"
+)
+
+const (
+	// MaxRecommendedSizes bounds the package sizes of a recommendation
+	MaxRecommendedSizes = 10
+	// recommendationCandidates bounds the sizes the search picks from, half of them the most ordered quantities
+	// and half quantiles of the orders
+	recommendationCandidates = 32
+	// maxRecommendationEvaluations bounds the candidate sets scored by a search
+	maxRecommendationEvaluations = 1000
+	// maxRecommendationWork bounds the solver work of a search, see scoreWork
+	maxRecommendationWork = 1 << 32
+)
+
+var ErrInvalidRecommendation = errors.New("invalid recommendation constraints")
+
+// RecommendOptions constrains a recommendation.
+type RecommendOptions struct {
+	// Sizes is how many package sizes to recommend, up to MaxRecommendedSizes.
+	Sizes int
+	// MinSize and MaxSize bound the recommended sizes, 0 means no bound.
+	MinSize, MaxSize int
+	// Keep are sizes the recommendation must include.
+	Keep []int
+}
+
+// RecommendPackSet searches the package sizes that ship the orders of a product best: fewest failed orders, then
+// least overfill, then fewest packs, calculated with the product's solver and overfill limits like
+// SimulatePackSet does. The product's order history is used when no quantities are given.
+//
+// The search picks from candidate sizes taken from the orders, see candidateSizes. It adds the best candidate one
+// size at a time, then swaps sizes for candidates while that improves the score. The current sizes are
+// recommended when they meet the constraints and the search found nothing better.
+func (s *Packages) RecommendPackSet(ctx context.Context, productID string, quantities []int, opts RecommendOptions) (*model.Recommendation, error) {
+	keep, err := validateRecommendOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(quantities) > MaxSimulationOrders {
+		return nil, ErrTooManyOrders
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(quantities) == 0 {
+		if quantities, err = s.GetOrderHistory(ctx, productID); err != nil {
+			return nil, err
+		}
+		if len(quantities) == 0 {
+			return nil, ErrNoOrderHistory
+		}
+	}
+
+	orders := map[int]int{}
+	for _, units := range quantities {
+		orders[units]++
+	}
+	search := &recommendationSearch{
+		product:  *product,
+		distinct: slices.Sorted(maps.Keys(orders)),
+		orders:   orders,
+		total:    len(quantities),
+	}
+	search.product.PackageStock, search.product.PackagePrices = nil, nil
+
+	res := &model.Recommendation{Orders: len(quantities)}
+	if res.Current, err = search.score(ctx, product.PackageSizes); err != nil {
+		return nil, err
+	}
+	if res.Recommended, err = search.run(ctx, keep, candidateSizes(product.PackageSizes, search.distinct, orders, opts), opts.Sizes); err != nil {
+		return nil, err
+	}
+	if meetsRecommendOptions(res.Current.PackageSizes, keep, opts) && betterTotals(res.Current, res.Recommended) {
+		res.Recommended = res.Current
+	}
+	res.Evaluated = search.evaluated
+	return res, nil
+}
+
+// validateRecommendOptions checks the constraints and returns the sizes to keep, sorted and unique.
+func validateRecommendOptions(opts RecommendOptions) ([]int, error) {
+	if opts.Sizes < 1 || opts.Sizes > MaxRecommendedSizes || opts.MinSize < 0 || opts.MaxSize < 0 {
+		return nil, ErrInvalidRecommendation
+	}
+	if opts.MaxSize != 0 && opts.MaxSize < opts.MinSize {
+		return nil, ErrInvalidRecommendation
+	}
+	keep := slices.Sorted(slices.Values(opts.Keep))
+	keep = slices.Compact(keep)
+	if len(keep) > opts.Sizes || slices.ContainsFunc(keep, func(size int) bool { return !sizeWithin(size, opts) }) {
+		return nil, ErrInvalidRecommendation
+	}
+	return keep, nil
+}
+
+// meetsRecommendOptions reports whether a set of sizes could be recommended.
+func meetsRecommendOptions(sizes, keep []int, opts RecommendOptions) bool {
+	if len(sizes) == 0 || len(sizes) > opts.Sizes || slices.ContainsFunc(sizes, func(size int) bool { return !sizeWithin(size, opts) }) {
+		return false
+	}
+	return !slices.ContainsFunc(keep, func(size int) bool { return !slices.Contains(sizes, size) })
+}
+
+func sizeWithin(size int, opts RecommendOptions) bool {
+	return size >= max(opts.MinSize, 1) && (opts.MaxSize == 0 || size <= opts.MaxSize)
+}
+
+// candidateSizes returns the sizes a recommendation picks from: the current sizes within the bounds, the
+// quantities ordered most often and evenly spaced quantiles of the orders rounded by roundSize, the last two
+// clamped to the bounds.
+func candidateSizes(current []int, distinct []int, orders map[int]int, opts RecommendOptions) []int {
+	clamp := func(units int) int {
+		units = max(units, opts.MinSize, 1)
+		if opts.MaxSize != 0 {
+			units = min(units, opts.MaxSize)
+		}
+		return units
+	}
+
+	var res []int
+	for _, size := range current {
+		if sizeWithin(size, opts) {
+			res = append(res, size)
+		}
+	}
+
+	mostOrdered := slices.Clone(distinct)
+	slices.SortStableFunc(mostOrdered, func(a, b int) int { return cmp.Compare(orders[b], orders[a]) })
+	for _, units := range mostOrdered[:min(len(mostOrdered), recommendationCandidates/2)] {
+		if orders[units] > 1 {
+			res = append(res, clamp(units))
+		}
+	}
+
+	total := 0
+	for _, units := range distinct {
+		total += orders[units]
+	}
+	quantiles := recommendationCandidates / 2
+	seen, k := 0, 1
+	for _, units := range distinct {
+		seen += orders[units]
+		for ; k <= quantiles && seen*(quantiles+1) >= k*total; k++ {
+			res = append(res, clamp(roundSize(units)))
+		}
+	}
+
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+// roundSize rounds a quantity to two significant digits, eg. 1234 to 1200. Round sizes share bigger divisors,
+// which keeps the tables of the solver small, and are the kind of sizes merchandising picks.
+func roundSize(units int) int {
+	step := 1
+	for units/step >= 100 {
+		step *= 10
+	}
+	res := units / step * step
+	if units-res >= (step+1)/2 && res <= math.MaxInt-step {
+		res += step
+	}
+	return res
+}
+
+// recommendationSearch scores candidate sets of package sizes against the distinct quantities of the orders.
+type recommendationSearch struct {
+	product   model.Product
+	distinct  []int
+	orders    map[int]int
+	total     int // orders of all the quantities
+	evaluated int
+	work      int // estimated solver work of the sets scored so far
+}
+
+// score calculates every distinct quantity with the sizes and totals them, weighed by their orders.
+func (r *recommendationSearch) score(ctx context.Context, sizes []int) (model.SimulationTotals, error) {
+	product := r.product
+	product.PackageSizes = sizes
+	results, err := simulateQuantities(ctx, &product, r.distinct, CalculateOptions{})
+	if errors.Is(err, ErrProductWithoutPackages) {
+		return model.SimulationTotals{PackageSizes: []int{}, FailedOrders: r.total}, nil
+	} else if err != nil {
+		return model.SimulationTotals{}, err
+	}
+	r.evaluated++
+	r.work = saturatingAdd(r.work, r.scoreWork(sizes))
+	return simulationTotals(sizes, results, r.orders), nil
+}
+
+// scoreWork estimates the solver work of scoring a set, in table entries per package size. Its tables hold a
+// remainder class per unit of the normalised biggest size, and every order costs about a thousand entries, more
+// when it's too small for the residue shortcut and packs with a table of its own, up to a biggest size per
+// package size.
+func (r *recommendationSearch) scoreWork(sizes []int) int {
+	if len(sizes) == 0 {
+		return 0
+	}
+	divisor := getGreatestCommonDivisor(sizes)
+	largest := slices.Max(sizes) / divisor
+	work := largest
+	for _, units := range r.distinct {
+		work = saturatingAdd(work, 1024+min(ceilDiv(max(units, 1), divisor), saturatingMul(largest, len(sizes))))
+	}
+	return saturatingMul(work, len(sizes))
+}
+
+// run adds the best candidate to the kept sizes until there are enough or none improves the score, then swaps
+// them for candidates while it improves.
+func (r *recommendationSearch) run(ctx context.Context, keep, candidates []int, sizes int) (model.SimulationTotals, error) {
+	set := slices.Clone(keep)
+	best, err := r.score(ctx, set)
+	if err != nil {
+		return best, err
+	}
+
+	// try set with its i-th size replaced by each unused candidate, or with each candidate added when i is past
+	// the end, keeping the best improvement
+	try := func(i int) (bool, error) {
+		improved := false
+		for _, candidate := range candidates {
+			if slices.Contains(set, candidate) {
+				continue
+			}
+			if r.evaluated >= maxRecommendationEvaluations {
+				break
+			}
+			next := slices.Clone(set)
+			if i == len(next) {
+				next = append(next, candidate)
+			} else {
+				next[i] = candidate
+			}
+			slices.Sort(next)
+			// skip the sets that would take the search over its work, cheaper ones may still fit
+			if saturatingAdd(r.work, r.scoreWork(next)) > maxRecommendationWork {
+				continue
+			}
+			totals, err := r.score(ctx, next)
+			if err != nil {
+				return false, err
+			}
+			if betterTotals(totals, best) {
+				best, improved = totals, true
+			}
+		}
+		if improved {
+			set = best.PackageSizes
+		}
+		return improved, nil
+	}
+
+	for len(set) < sizes {
+		added, err := try(len(set))
+		if err != nil || !added {
+			return best, err
+		}
+	}
+	for improved := true; improved; {
+		improved = false
+		for i := range set {
+			if slices.Contains(keep, set[i]) {
+				continue
+			}
+			swapped, err := try(i)
+			if err != nil {
+				return best, err
+			}
+			improved = improved || swapped
+		}
+	}
+	return best, nil
+}
+
+// betterTotals ranks the scores of two sets: fewest failed orders, then least overfill, then fewest packs.
+func betterTotals(a, b model.SimulationTotals) bool {
+	if a.FailedOrders != b.FailedOrders {
+		return a.FailedOrders < b.FailedOrders
+	}
+	if a.Overfill != b.Overfill {
+		return a.Overfill < b.Overfill
+	}
+	return a.Packs < b.Packs
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRecommendPackSet(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{5}}
+	tests := []struct {
+		name         string
+		quantities   []int
+		opts         RecommendOptions
+		wantSizes    []int
+		wantOverfill int
+		wantPacks    int
+	}{
+		{
+			name:         "two sizes",
+			quantities:   []int{4, 4, 4, 6, 6, 9},
+			opts:         RecommendOptions{Sizes: 2},
+			wantSizes:    []int{4, 6},
+			wantOverfill: 1,
+			wantPacks:    7,
+		},
+		{
+			name:         "kept size",
+			quantities:   []int{4, 4, 4, 6, 6, 9},
+			opts:         RecommendOptions{Sizes: 2, Keep: []int{9}},
+			wantSizes:    []int{4, 9},
+			wantOverfill: 4,
+			wantPacks:    8,
+		},
+		{
+			name:         "bounded sizes",
+			quantities:   []int{4, 4, 4, 6, 6, 9},
+			opts:         RecommendOptions{Sizes: 3, MinSize: 5, MaxSize: 8},
+			wantSizes:    []int{5, 6},
+			wantOverfill: 4,
+			wantPacks:    7,
+		},
+		{
+			name:         "current sizes are best",
+			quantities:   []int{5, 10, 15},
+			opts:         RecommendOptions{Sizes: 1},
+			wantSizes:    []int{5},
+			wantOverfill: 0,
+			wantPacks:    6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPackageService(&mockPackageStorage{wantRes: product})
+			got, err := service.RecommendPackSet(context.TODO(), "ABC", tt.quantities, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Orders != len(tt.quantities) || !slices.Equal(got.Current.PackageSizes, []int{5}) {
+				t.Fatalf("unexpected recommendation %+v", got)
+			}
+			recommended := got.Recommended
+			if !slices.Equal(recommended.PackageSizes, tt.wantSizes) || recommended.Overfill != tt.wantOverfill || recommended.Packs != tt.wantPacks {
+				t.Fatalf("want %v with overfill %d and %d packs, got %+v", tt.wantSizes, tt.wantOverfill, tt.wantPacks, recommended)
+			}
+		})
+	}
+}
+
+// given the default package sizes and their orders - test the recommendation is at least as good
+func TestRecommendPackSetOrderHistory(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500, 1000, 2000, 5000}}
+	var history []int
+	for units := 1; units <= 15000; units += 37 {
+		history = append(history, units)
+	}
+	service := NewPackageService(&mockPackageStorage{wantRes: product, orderHistory: history})
+
+	got, err := service.RecommendPackSet(context.TODO(), "ABC", nil, RecommendOptions{Sizes: 5, MinSize: 250, Keep: []int{250}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Recommended.PackageSizes) != 5 || !slices.Contains(got.Recommended.PackageSizes, 250) || betterTotals(got.Current, got.Recommended) {
+		t.Fatalf("unexpected recommendation %+v", got)
+	}
+	if got.Evaluated > maxRecommendationEvaluations {
+		t.Fatalf("evaluated %d sets, the limit is %d", got.Evaluated, maxRecommendationEvaluations)
+	}
+}
+
+// given thousands of orders of big, scattered quantities - test the search stays within its work
+func TestRecommendPackSetManyOrders(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500, 1000, 2000, 5000}}
+	rnd := rand.New(rand.NewSource(3))
+	quantities := make([]int, 2000)
+	for i := range quantities {
+		quantities[i] = 1 + rnd.Intn(900_000)
+	}
+	service := NewPackageService(&mockPackageStorage{wantRes: product})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	got, err := service.RecommendPackSet(ctx, "ABC", quantities, RecommendOptions{Sizes: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Recommended.PackageSizes) == 0 || betterTotals(got.Current, got.Recommended) {
+		t.Fatalf("unexpected recommendation %+v", got)
+	}
+}
+
+func TestRecommendPackSetFails(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250}}
+	tests := []struct {
+		name    string
+		opts    RecommendOptions
+		wantErr error
+	}{
+		{name: "no sizes", opts: RecommendOptions{}, wantErr: ErrInvalidRecommendation},
+		{name: "too many sizes", opts: RecommendOptions{Sizes: MaxRecommendedSizes + 1}, wantErr: ErrInvalidRecommendation},
+		{name: "maximum below minimum", opts: RecommendOptions{Sizes: 2, MinSize: 500, MaxSize: 250}, wantErr: ErrInvalidRecommendation},
+		{name: "kept size out of bounds", opts: RecommendOptions{Sizes: 2, MinSize: 500, Keep: []int{250}}, wantErr: ErrInvalidRecommendation},
+		{name: "too many kept sizes", opts: RecommendOptions{Sizes: 1, Keep: []int{250, 500}}, wantErr: ErrInvalidRecommendation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPackageService(&mockPackageStorage{wantRes: product})
+			_, err := service.RecommendPackSet(context.TODO(), "ABC", []int{1}, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	service := NewPackageService(&mockPackageStorage{wantRes: product, orderHistory: []int{}})
+	_, err := service.RecommendPackSet(context.TODO(), "ABC", nil, RecommendOptions{Sizes: 1})
+	if !errors.Is(err, ErrNoOrderHistory) {
+		t.Fatalf("want %v got %v", ErrNoOrderHistory, err)
+	}
+}
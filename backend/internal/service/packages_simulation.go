@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"maps"
+	"slices"
+)
+
+// MaxSimulationOrders bounds the orders of a simulation and of the order history of a product
+const MaxSimulationOrders = 100_000
+
+var (
+	ErrNoOrderHistory = errors.New("no order history to simulate")
+	ErrTooManyOrders  = errors.New("too many orders")
+)
+
+// SetOrderHistory replaces the historical order quantities of a product, which simulations run when given none.
+func (s *Packages) SetOrderHistory(ctx context.Context, productID string, quantities []int) error {
+	if len(quantities) > MaxSimulationOrders {
+		return ErrTooManyOrders
+	}
+	if slices.ContainsFunc(quantities, func(units int) bool { return units < 1 }) {
+		return ErrInvalidUnits
+	}
+	err := s.storage.SetOrderHistory(ctx, productID, quantities)
+	if errors.Is(err, storage.ErrProductNotFound) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// GetOrderHistory returns the historical order quantities of a product.
+func (s *Packages) GetOrderHistory(ctx context.Context, productID string) ([]int, error) {
+	quantities, err := s.storage.GetOrderHistory(ctx, productID)
+	if err != nil {
+		if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return quantities, nil
+}
+
+// SimulatePackSet calculates orders with the current package sizes of a product and with candidate sizes, and
+// compares the outcomes. The product's order history is simulated when no quantities are given.
+// Both pack sets get unlimited supply, and the candidate sizes keep the price they have in the product, if any.
+func (s *Packages) SimulatePackSet(ctx context.Context, productID string, candidate []int, quantities []int, opts CalculateOptions) (*model.Simulation, error) {
+	if slices.ContainsFunc(candidate, func(size int) bool { return size < 1 }) {
+		return nil, ErrInvalidPackageSizes
+	}
+	if len(quantities) > MaxSimulationOrders {
+		return nil, ErrTooManyOrders
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(quantities) == 0 {
+		if quantities, err = s.GetOrderHistory(ctx, productID); err != nil {
+			return nil, err
+		}
+		if len(quantities) == 0 {
+			return nil, ErrNoOrderHistory
+		}
+	}
+
+	current := *product
+	current.PackageStock = nil
+	next := current
+	next.PackageSizes = slices.Clone(candidate)
+	next.PackagePrices = nil
+	for _, price := range product.PackagePrices {
+		if slices.Contains(candidate, price.Size) {
+			next.PackagePrices = append(next.PackagePrices, price)
+		}
+	}
+
+	// each distinct quantity is calculated once and weighs as many orders as it has
+	orders := map[int]int{}
+	for _, units := range quantities {
+		orders[units]++
+	}
+	distinct := slices.Sorted(maps.Keys(orders))
+
+	opts.Alternatives, opts.Explain = 0, false
+	currentResults, err := simulateQuantities(ctx, &current, distinct, opts)
+	if err != nil {
+		return nil, err
+	}
+	candidateResults, err := simulateQuantities(ctx, &next, distinct, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.Simulation{
+		Orders:    len(quantities),
+		Current:   simulationTotals(current.PackageSizes, currentResults, orders),
+		Candidate: simulationTotals(next.PackageSizes, candidateResults, orders),
+		Changed:   []model.SimulatedQuantity{},
+	}
+	for i, units := range distinct {
+		if packingChanged(currentResults[i], candidateResults[i]) {
+			res.Changed = append(res.Changed, model.SimulatedQuantity{
+				Units:     units,
+				Orders:    orders[units],
+				Current:   currentResults[i],
+				Candidate: candidateResults[i],
+			})
+		}
+	}
+	return res, nil
+}
+
+func simulateQuantities(ctx context.Context, product *model.Product, quantities []int, opts CalculateOptions) ([]model.BatchResult, error) {
+	calculate, err := prepareProduct(product, opts)
+	if err != nil {
+		return nil, err
+	}
+	return calculateAll(ctx, calculate, quantities)
+}
+
+// simulationTotals totals the results of the distinct quantities, weighed by their orders.
+func simulationTotals(packageSizes []int, results []model.BatchResult, orders map[int]int) model.SimulationTotals {
+	res := model.SimulationTotals{PackageSizes: slices.Sorted(slices.Values(packageSizes))}
+	for _, result := range results {
+		count := orders[result.Units]
+		if result.Err != nil {
+			res.FailedOrders += count
+			continue
+		}
+		items, packs := countItemsAndPacks(result.Package.PackageUnits)
+		res.Items = saturatingAdd(res.Items, saturatingMul(items, count))
+		res.Packs = saturatingAdd(res.Packs, saturatingMul(packs, count))
+		res.Overfill = saturatingAdd(res.Overfill, saturatingMul(items-result.Units, count))
+	}
+	return res
+}
+
+// packingChanged reports whether a quantity ships differently, or only fails, with the candidate pack set.
+func packingChanged(current, candidate model.BatchResult) bool {
+	if (current.Err == nil) != (candidate.Err == nil) {
+		return true
+	}
+	return current.Err == nil && !slices.Equal(current.Package.PackageUnits, candidate.Package.PackageUnits)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"testing"
+)
+
+// given a candidate without the biggest size - test the totals and the changed quantities, ignoring the stock
+func TestSimulatePackSet(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{
+		ID:           "ABC",
+		Name:         "ABC",
+		PackageSizes: []int{250, 500, 1000, 2000, 5000},
+		PackageStock: []model.PackageStock{{Size: 5000, Available: 0}},
+	}}
+	service := NewPackageService(mockStorage)
+
+	got, err := service.SimulatePackSet(context.TODO(), "ABC", []int{2000, 1000, 500, 250}, []int{12001, 251, 5000, 12001}, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Orders != 4 {
+		t.Fatalf("want 4 orders got %d", got.Orders)
+	}
+	wantCurrent := model.SimulationTotals{Items: 30000, Packs: 10, Overfill: 747}
+	wantCandidate := model.SimulationTotals{Items: 30000, Packs: 18, Overfill: 747}
+	if got.Current.Items != wantCurrent.Items || got.Current.Packs != wantCurrent.Packs || got.Current.Overfill != wantCurrent.Overfill {
+		t.Fatalf("want current %+v got %+v", wantCurrent, got.Current)
+	}
+	if got.Candidate.Items != wantCandidate.Items || got.Candidate.Packs != wantCandidate.Packs || got.Candidate.Overfill != wantCandidate.Overfill {
+		t.Fatalf("want candidate %+v got %+v", wantCandidate, got.Candidate)
+	}
+	if len(got.Candidate.PackageSizes) != 4 || got.Candidate.PackageSizes[0] != 250 {
+		t.Fatalf("unexpected candidate package sizes %v", got.Candidate.PackageSizes)
+	}
+	if len(got.Changed) != 2 || got.Changed[0].Units != 5000 || got.Changed[1].Units != 12001 || got.Changed[1].Orders != 2 {
+		t.Fatalf("unexpected changed quantities %+v", got.Changed)
+	}
+}
+
+// given no quantities - test the order history is simulated
+func TestSimulatePackSetOrderHistory(t *testing.T) {
+	product := &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250, 500}}
+	service := NewPackageService(&mockPackageStorage{wantRes: product, orderHistory: []int{750, 100}})
+
+	got, err := service.SimulatePackSet(context.TODO(), "ABC", []int{250, 750}, nil, CalculateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Orders != 2 || len(got.Changed) != 1 || got.Changed[0].Units != 750 || got.Candidate.Packs != 2 {
+		t.Fatalf("unexpected simulation %+v", got)
+	}
+
+	service = NewPackageService(&mockPackageStorage{wantRes: product, orderHistory: []int{}})
+	_, err = service.SimulatePackSet(context.TODO(), "ABC", []int{250, 750}, nil, CalculateOptions{})
+	if !errors.Is(err, ErrNoOrderHistory) {
+		t.Fatalf("want %v got %v", ErrNoOrderHistory, err)
+	}
+}
+
+func TestSimulatePackSetFails(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantRes: &model.Product{ID: "ABC", Name: "ABC", PackageSizes: []int{250}}})
+
+	_, err := service.SimulatePackSet(context.TODO(), "ABC", []int{0, 250}, []int{1}, CalculateOptions{})
+	if !errors.Is(err, ErrInvalidPackageSizes) {
+		t.Fatalf("want %v got %v", ErrInvalidPackageSizes, err)
+	}
+	_, err = service.SimulatePackSet(context.TODO(), "ABC", []int{250}, make([]int, MaxSimulationOrders+1), CalculateOptions{})
+	if !errors.Is(err, ErrTooManyOrders) {
+		t.Fatalf("want %v got %v", ErrTooManyOrders, err)
+	}
+	err = service.SetOrderHistory(context.TODO(), "ABC", []int{250, 0})
+	if !errors.Is(err, ErrInvalidUnits) {
+		t.Fatalf("want %v got %v", ErrInvalidUnits, err)
+	}
+
+	service = NewPackageService(&mockPackageStorage{wantErr: errors.New("db is unhealthy")})
+	_, err = service.SimulatePackSet(context.TODO(), "ABC", []int{250}, []int{1}, CalculateOptions{})
+	if err == nil {
+		t.Fail()
+	}
+}
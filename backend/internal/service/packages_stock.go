@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+)
+
+var (
+	ErrInvalidStock    = errors.New("stock can't be negative")
+	ErrStockNotTracked = errors.New("package size stock isn't tracked")
+	ErrNegativeStock   = errors.New("package size stock can't go below zero")
+)
+
+// SetPackageSizeStock sets the packs in stock of an existing package size, a nil stock gives it unlimited supply.
+func (s *Packages) SetPackageSizeStock(ctx context.Context, productID string, size int, stock *int, ifVersion int) (*model.Product, error) {
+	if stock != nil && *stock < 0 {
+		return nil, ErrInvalidStock
+	}
+	err := s.storage.SetPackageSizeStock(ctx, productID, size, stock, ifVersion)
+	if err != nil {
+		if errors.Is(err, storage.ErrPackageSizeNotFound) {
+			return nil, ErrPackageSizeNotFound
+		} else if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+	return s.getProduct(ctx, productID)
+}
+
+// AdjustPackageSizeStock adds delta packs, or removes them when negative, to the stock of a tracked package size.
+func (s *Packages) AdjustPackageSizeStock(ctx context.Context, productID string, size int, delta int, ifVersion int) (*model.Product, error) {
+	err := s.storage.AdjustPackageSizeStock(ctx, productID, size, delta, ifVersion)
+	if err != nil {
+		if errors.Is(err, storage.ErrPackageSizeNotFound) {
+			return nil, ErrPackageSizeNotFound
+		} else if errors.Is(err, storage.ErrStockNotTracked) {
+			return nil, ErrStockNotTracked
+		} else if errors.Is(err, storage.ErrNegativeStock) {
+			return nil, ErrNegativeStock
+		} else if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		} else if errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+	return s.getProduct(ctx, productID)
+}
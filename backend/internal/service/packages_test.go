@@ -5,6 +5,7 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/storage"
+	"slices"
 	"testing"
 )
 
@@ -12,7 +13,7 @@ func TestAddPackageFailsOnStorageConstraint(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantErr: storage.ErrConstraintViolation}
 	service := NewPackageService(mockStorage)
 
-	_, err := service.AddPackageSize(context.TODO(), "ABC", 100)
+	_, err := service.AddPackageSize(context.TODO(), "ABC", 100, nil, 0)
 	if err == nil || !errors.Is(err, ErrConstraintViolation) {
 		t.Fail()
 	}
@@ -23,7 +24,7 @@ func TestAddPackageOK(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantRes: wantProduct}
 	service := NewPackageService(mockStorage)
 
-	product, err := service.AddPackageSize(context.TODO(), "ABC", 100)
+	product, err := service.AddPackageSize(context.TODO(), "ABC", 100, nil, 0)
 	if err != nil {
 		t.Fail()
 	}
@@ -36,7 +37,7 @@ func TestRemovePackageFailsWithError(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantErr: errors.New("db is unhealthy")}
 	service := NewPackageService(mockStorage)
 
-	_, err := service.AddPackageSize(context.TODO(), "ABC", 100)
+	_, err := service.AddPackageSize(context.TODO(), "ABC", 100, nil, 0)
 	if err == nil {
 		t.Fail()
 	}
@@ -47,7 +48,7 @@ func TestRemovePackageOK(t *testing.T) {
 	mockStorage := &mockPackageStorage{wantRes: wantProduct}
 	service := NewPackageService(mockStorage)
 
-	product, err := service.AddPackageSize(context.TODO(), "ABC", 3)
+	product, err := service.AddPackageSize(context.TODO(), "ABC", 3, nil, 0)
 	if err != nil {
 		t.Fail()
 	}
@@ -55,3 +56,114 @@ func TestRemovePackageOK(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestAddPackageFailsOnInvalidPrice(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "123", Name: "ABC"}}
+	service := NewPackageService(mockStorage)
+
+	for _, price := range []model.PackagePrice{
+		{Size: 100, UnitCost: -1, Currency: "GBP"},
+		{Size: 100, UnitCost: MaxUnitCost + 1, Currency: "GBP"},
+		{Size: 100, UnitCost: 499, Currency: "gbp"},
+		{Size: 100, UnitCost: 499},
+	} {
+		_, err := service.AddPackageSize(context.TODO(), "ABC", 100, &price, 0)
+		if !errors.Is(err, ErrInvalidPrice) {
+			t.Fatalf("%+v: want %v got %v", price, ErrInvalidPrice, err)
+		}
+	}
+}
+
+func TestSetPackageSizePriceFailsOnMissingSize(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantErr: storage.ErrPackageSizeNotFound}
+	service := NewPackageService(mockStorage)
+
+	_, err := service.SetPackageSizePrice(context.TODO(), "ABC", 100, &model.PackagePrice{Size: 100, UnitCost: 499, Currency: "GBP"}, 0)
+	if !errors.Is(err, ErrPackageSizeNotFound) {
+		t.Fail()
+	}
+}
+
+func TestSetPackageSizePriceOK(t *testing.T) {
+	wantProduct := &model.Product{ID: "123", Name: "ABC", PackageSizes: []int{100}}
+	mockStorage := &mockPackageStorage{wantRes: wantProduct}
+	service := NewPackageService(mockStorage)
+
+	product, err := service.SetPackageSizePrice(context.TODO(), "ABC", 100, nil, 0)
+	if err != nil {
+		t.Fail()
+	}
+	if product != wantProduct {
+		t.Fail()
+	}
+}
+
+func TestSetPackageSizeStockFailsOnNegativeStock(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "123", Name: "ABC"}}
+	service := NewPackageService(mockStorage)
+
+	stock := -1
+	_, err := service.SetPackageSizeStock(context.TODO(), "ABC", 100, &stock, 0)
+	if !errors.Is(err, ErrInvalidStock) {
+		t.Fail()
+	}
+}
+
+func TestAdjustPackageSizeStockFailsBelowZero(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantErr: storage.ErrNegativeStock}
+	service := NewPackageService(mockStorage)
+
+	_, err := service.AdjustPackageSizeStock(context.TODO(), "ABC", 100, -1, 0)
+	if !errors.Is(err, ErrNegativeStock) {
+		t.Fail()
+	}
+}
+
+func TestReplacePackageSizes(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "123", Name: "ABC", PackageSizes: []int{250, 500}}}
+	service := NewPackageService(mockStorage)
+
+	product, err := service.ReplacePackageSizes(context.TODO(), "123", []int{1000, 300}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(product.PackageSizes, []int{300, 1000}) {
+		t.Fatalf("unexpected package sizes %v", product.PackageSizes)
+	}
+
+	if _, err = service.ReplacePackageSizes(context.TODO(), "123", []int{300, 0}, 0); !errors.Is(err, ErrInvalidPackageSizes) {
+		t.Fatalf("want %v got %v", ErrInvalidPackageSizes, err)
+	}
+}
+
+// given a size twice - test it's rejected like on product creation rather than dropped
+func TestReplacePackageSizesWithDuplicates(t *testing.T) {
+	mockStorage := &mockPackageStorage{wantRes: &model.Product{ID: "123", Name: "ABC", PackageSizes: []int{250, 500}}}
+	service := NewPackageService(mockStorage)
+
+	if _, err := service.ReplacePackageSizes(context.TODO(), "123", []int{1000, 300, 1000}, 0); !errors.Is(err, ErrConstraintViolation) {
+		t.Fatalf("want %v got %v", ErrConstraintViolation, err)
+	}
+	if !slices.Equal(mockStorage.wantRes.(*model.Product).PackageSizes, []int{250, 500}) {
+		t.Fatalf("unexpected package sizes %v", mockStorage.wantRes.(*model.Product).PackageSizes)
+	}
+}
+
+func TestReplacePackageSizesOfInexistentProduct(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantErr: storage.ErrProductNotFound})
+
+	if _, err := service.ReplacePackageSizes(context.TODO(), "123", []int{300}, 0); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("want %v got %v", ErrProductNotFound, err)
+	}
+}
+
+func TestChangePackageSizesOfChangedProduct(t *testing.T) {
+	service := NewPackageService(&mockPackageStorage{wantErr: storage.ErrVersionMismatch})
+
+	if _, err := service.AddPackageSize(context.TODO(), "123", 300, nil, 2); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("want %v got %v", ErrPreconditionFailed, err)
+	}
+	if _, err := service.ReplacePackageSizes(context.TODO(), "123", []int{300}, 2); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("want %v got %v", ErrPreconditionFailed, err)
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"gymshark-interview/internal/model"
 	"gymshark-interview/internal/storage"
@@ -18,21 +20,129 @@ type Products struct {
 }
 
 type ProductsStorage interface {
-	ListProducts(ctx context.Context) ([]model.Product, error)
+	ListProducts(ctx context.Context, query model.ProductQuery) ([]model.Product, error)
 	CreateProduct(ctx context.Context, product model.Product) (*model.Product, error)
-	DeleteProduct(ctx context.Context, id string) error
+	DeleteProduct(ctx context.Context, id string, ifVersion int) error
+	GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error)
+	UpdateProductName(ctx context.Context, id string, name string, ifVersion int) error
 }
 
 var (
 	ErrConstraintViolation    = errors.New("constraint violation")
 	ErrProductWithoutPackages = errors.New("product has no available package sizes")
+	ErrInvalidCursor          = errors.New("invalid cursor")
+	ErrInvalidProductSort     = errors.New("invalid product sort")
+	ErrInvalidPageSize        = errors.New("invalid page size")
 )
 
-func (s *Products) List(ctx context.Context) ([]model.Product, error) {
-	return s.storage.ListProducts(ctx)
+const (
+	// DefaultProductsPageSize is the size of a page of products when none is requested
+	DefaultProductsPageSize = 20
+	// MaxProductsPageSize bounds the size of a page of products
+	MaxProductsPageSize = 100
+)
+
+// ListProductsOptions selects a page of products.
+type ListProductsOptions struct {
+	// SortBy is model.ProductSortName or model.ProductSortCreated, the latter when empty.
+	SortBy     string
+	Descending bool
+	NamePrefix string
+	// Cursor is the NextCursor of the previous page, empty for the first page. It must come from a listing with
+	// the same sort and filter.
+	Cursor string
+	// Limit is the size of the page, DefaultProductsPageSize when 0.
+	Limit int
+}
+
+// productCursor is encoded in the opaque cursors of the product listing, with the sort and filter it was listed
+// with so it can't be reused for another listing.
+type productCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	NamePrefix string `json:"p,omitempty"`
+	Name       string `json:"n,omitempty"`
+	ID         string `json:"i"`
+}
+
+// List returns a page of products, with the cursor of the next page when there are more.
+func (s *Products) List(ctx context.Context, opts ListProductsOptions) (*model.ProductPage, error) {
+	if opts.SortBy == "" {
+		opts.SortBy = model.ProductSortCreated
+	}
+	if opts.SortBy != model.ProductSortName && opts.SortBy != model.ProductSortCreated {
+		return nil, ErrInvalidProductSort
+	}
+	if opts.Limit == 0 {
+		opts.Limit = DefaultProductsPageSize
+	}
+	if opts.Limit < 0 || opts.Limit > MaxProductsPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	query := model.ProductQuery{
+		SortBy:     opts.SortBy,
+		Descending: opts.Descending,
+		NamePrefix: opts.NamePrefix,
+		// one more product tells whether there is a next page
+		Limit: opts.Limit + 1,
+	}
+	if opts.Cursor != "" {
+		cursor, err := decodeProductCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != opts.SortBy || cursor.Descending != opts.Descending || cursor.NamePrefix != opts.NamePrefix {
+			return nil, ErrInvalidCursor
+		}
+		query.After = &model.ProductCursor{Name: cursor.Name, ID: cursor.ID}
+	}
+
+	products, err := s.storage.ListProducts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.ProductPage{Products: products}
+	if len(products) > opts.Limit {
+		page.Products = products[:opts.Limit]
+		last := page.Products[opts.Limit-1]
+		page.NextCursor = encodeProductCursor(productCursor{
+			SortBy:     opts.SortBy,
+			Descending: opts.Descending,
+			NamePrefix: opts.NamePrefix,
+			Name:       last.Name,
+			ID:         last.ID,
+		})
+	}
+	return page, nil
+}
+
+func encodeProductCursor(cursor productCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeProductCursor(value string) (productCursor, error) {
+	var cursor productCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
 }
 
 func (s *Products) Create(ctx context.Context, product model.Product) (*model.Product, error) {
+	if product.Solver != "" {
+		if _, err := GetSolver(product.Solver); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateOverfillLimit(product.MaxOverfill); err != nil {
+		return nil, err
+	}
 	res, err := s.storage.CreateProduct(ctx, product)
 	if err != nil {
 		if errors.Is(err, storage.ErrConstraintViolation) {
@@ -43,6 +153,51 @@ func (s *Products) Create(ctx context.Context, product model.Product) (*model.Pr
 	return res, nil
 }
 
-func (s *Products) DeleteByID(ctx context.Context, id string) error {
-	return s.storage.DeleteProduct(ctx, id)
+func (s *Products) Get(ctx context.Context, id string) (*model.Product, error) {
+	product, err := s.storage.GetProductWithPackageSizes(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrProductNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// Update renames a product and returns it. A nil name leaves it unchanged. A non-zero ifVersion makes it fail with
+// ErrPreconditionFailed unless the product is at that version.
+func (s *Products) Update(ctx context.Context, id string, name *string, ifVersion int) (*model.Product, error) {
+	if name != nil {
+		err := s.storage.UpdateProductName(ctx, id, *name, ifVersion)
+		if err != nil {
+			if errors.Is(err, storage.ErrConstraintViolation) {
+				return nil, ErrConstraintViolation
+			} else if errors.Is(err, storage.ErrProductNotFound) {
+				return nil, ErrProductNotFound
+			} else if errors.Is(err, storage.ErrVersionMismatch) {
+				return nil, ErrPreconditionFailed
+			}
+			return nil, err
+		}
+		return s.Get(ctx, id)
+	}
+
+	product, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != 0 && product.Version != ifVersion {
+		return nil, ErrPreconditionFailed
+	}
+	return product, nil
+}
+
+// DeleteByID deletes a product. A non-zero ifVersion makes it fail with ErrPreconditionFailed unless the product
+// is at that version.
+func (s *Products) DeleteByID(ctx context.Context, id string, ifVersion int) error {
+	err := s.storage.DeleteProduct(ctx, id, ifVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		return ErrPreconditionFailed
+	}
+	return err
 }
@@ -47,24 +47,77 @@ func TestListProductsOK(t *testing.T) {
 	mockStorage := &mockProductStorage{wantRes: wantRes}
 	service := NewProductService(mockStorage)
 
-	products, err := service.List(context.TODO())
+	page, err := service.List(context.TODO(), ListProductsOptions{})
 	if err != nil {
 		t.Fail()
 	}
-	if !slices.EqualFunc(wantRes, products, func(a, b model.Product) bool {
+	if page.NextCursor != "" || mockStorage.query.SortBy != model.ProductSortCreated || mockStorage.query.Limit != DefaultProductsPageSize+1 {
+		t.Fatalf("unexpected page %+v for query %+v", page, mockStorage.query)
+	}
+	if !slices.EqualFunc(wantRes, page.Products, func(a, b model.Product) bool {
 		return a.ID == b.ID && a.Name == b.Name && slices.Equal(a.PackageSizes, b.PackageSizes)
 	}) {
 		t.Fail()
 	}
 }
 
+// given more products than the page - test the next cursor continues after the last product of the page
+func TestListProductsNextCursor(t *testing.T) {
+	mockStorage := &mockProductStorage{wantRes: []model.Product{
+		{ID: "1", Name: "one"},
+		{ID: "2", Name: "two"},
+		{ID: "3", Name: "three"},
+	}}
+	service := NewProductService(mockStorage)
+
+	opts := ListProductsOptions{SortBy: model.ProductSortName, NamePrefix: "t", Limit: 2}
+	page, err := service.List(context.TODO(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Products) != 2 || page.NextCursor == "" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	opts.Cursor = page.NextCursor
+	if _, err = service.List(context.TODO(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if mockStorage.query.After == nil || *mockStorage.query.After != (model.ProductCursor{Name: "two", ID: "2"}) {
+		t.Fatalf("unexpected query %+v", mockStorage.query)
+	}
+
+	opts.NamePrefix = "o"
+	if _, err = service.List(context.TODO(), opts); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("want %v got %v", ErrInvalidCursor, err)
+	}
+}
+
+func TestListProductsInvalidOptions(t *testing.T) {
+	service := NewProductService(&mockProductStorage{wantRes: []model.Product{}})
+
+	tests := []struct {
+		opts    ListProductsOptions
+		wantErr error
+	}{
+		{opts: ListProductsOptions{SortBy: "price"}, wantErr: ErrInvalidProductSort},
+		{opts: ListProductsOptions{Limit: MaxProductsPageSize + 1}, wantErr: ErrInvalidPageSize},
+		{opts: ListProductsOptions{Cursor: "not a cursor"}, wantErr: ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		if _, err := service.List(context.TODO(), tt.opts); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("want %v got %v", tt.wantErr, err)
+		}
+	}
+}
+
 func TestListProductsStorageError(t *testing.T) {
 	mockStorage := &mockProductStorage{
 		wantErr: errors.New("storage failed"),
 	}
 	service := NewProductService(mockStorage)
 
-	_, err := service.List(context.TODO())
+	_, err := service.List(context.TODO(), ListProductsOptions{})
 	if err == nil {
 		t.Fail()
 	}
@@ -74,7 +127,7 @@ func TestDeleteProductOK(t *testing.T) {
 	mockStorage := &mockProductStorage{}
 	service := NewProductService(mockStorage)
 
-	err := service.DeleteByID(context.TODO(), "ABC")
+	err := service.DeleteByID(context.TODO(), "ABC", 0)
 	if err != nil {
 		t.Fail()
 	}
@@ -86,8 +139,71 @@ func TestDeleteProductStorageError(t *testing.T) {
 	}
 	service := NewProductService(mockStorage)
 
-	err := service.DeleteByID(context.TODO(), "ABC")
+	err := service.DeleteByID(context.TODO(), "ABC", 0)
 	if err == nil {
 		t.Fail()
 	}
 }
+
+func TestUpdateProductName(t *testing.T) {
+	mockStorage := &mockProductStorage{wantRes: &model.Product{ID: "ABC", Name: "one"}}
+	service := NewProductService(mockStorage)
+
+	name := "renamed"
+	product, err := service.Update(context.TODO(), "ABC", &name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Name != name {
+		t.Fatalf("want name %s got %s", name, product.Name)
+	}
+}
+
+func TestUpdateProductStorageErrors(t *testing.T) {
+	tests := []struct {
+		storageErr error
+		wantErr    error
+	}{
+		{storageErr: storage.ErrConstraintViolation, wantErr: ErrConstraintViolation},
+		{storageErr: storage.ErrProductNotFound, wantErr: ErrProductNotFound},
+		{storageErr: storage.ErrVersionMismatch, wantErr: ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		service := NewProductService(&mockProductStorage{wantErr: tt.storageErr})
+		name := "renamed"
+		if _, err := service.Update(context.TODO(), "ABC", &name, 0); !errors.Is(err, tt.wantErr) {
+			t.Fatalf("want %v got %v", tt.wantErr, err)
+		}
+	}
+}
+
+func TestGetProductNotFound(t *testing.T) {
+	service := NewProductService(&mockProductStorage{wantErr: storage.ErrProductNotFound})
+
+	if _, err := service.Get(context.TODO(), "ABC"); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("want %v got %v", ErrProductNotFound, err)
+	}
+}
+
+func TestUpdateProductWithoutChangesChecksVersion(t *testing.T) {
+	service := NewProductService(&mockProductStorage{wantRes: &model.Product{ID: "ABC", Name: "one", Version: 3}})
+
+	if _, err := service.Update(context.TODO(), "ABC", nil, 2); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("want %v got %v", ErrPreconditionFailed, err)
+	}
+	product, err := service.Update(context.TODO(), "ABC", nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Version != 3 {
+		t.Fatalf("want version 3 got %d", product.Version)
+	}
+}
+
+func TestDeleteChangedProduct(t *testing.T) {
+	service := NewProductService(&mockProductStorage{wantErr: storage.ErrVersionMismatch})
+
+	if err := service.DeleteByID(context.TODO(), "ABC", 2); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("want %v got %v", ErrPreconditionFailed, err)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"gymshark-interview/internal/model"
+	"slices"
+)
+
+const (
+	SolverDP             = "dp"
+	SolverBranchAndBound = "branch-and-bound"
+	SolverGreedy         = "greedy"
+
+	DefaultSolver = SolverDP
+)
+
+var (
+	ErrUnknownSolver       = errors.New("unknown solver")
+	ErrSolverLimitExceeded = errors.New("solver exceeded its search limit")
+	ErrStockNotSupported   = errors.New("solver doesn't support limited stock")
+)
+
+// Solver picks the packs to ship for an order out of the available package sizes.
+type Solver interface {
+	Name() string
+	Solve(order Order) ([]model.PackageUnit, error)
+}
+
+// solveFunc solves a prepared order for an amount of units and a maximum overfill, nil meaning no cap.
+type solveFunc func(units int, maxOverfill *int) ([]model.PackageUnit, error)
+
+// preparer is implemented by the solvers that can set up their tables once for many orders that only differ in
+// their units and maximum overfill.
+type preparer interface {
+	Prepare(template Order) (solveFunc, error)
+}
+
+// prepareSolver returns a function solving the template order for any amount of units and maximum overfill,
+// sharing the set up of the solver when it supports it.
+func prepareSolver(solver Solver, template Order) (solveFunc, error) {
+	if p, ok := solver.(preparer); ok {
+		return p.Prepare(template)
+	}
+	return func(units int, maxOverfill *int) ([]model.PackageUnit, error) {
+		order := template
+		order.Units, order.MaxOverfill = units, maxOverfill
+		return solver.Solve(order)
+	}, nil
+}
+
+var solvers = map[string]Solver{
+	SolverDP:             dpSolver{},
+	SolverBranchAndBound: branchAndBoundSolver{maxNodes: maxBranchAndBoundNodes},
+	SolverGreedy:         greedySolver{},
+}
+
+// GetSolver returns the solver registered with the given name, or the default solver when name is empty.
+func GetSolver(name string) (Solver, error) {
+	if name == "" {
+		name = DefaultSolver
+	}
+	solver, ok := solvers[name]
+	if !ok {
+		return nil, ErrUnknownSolver
+	}
+	return solver, nil
+}
+
+// SolverNames returns the names of the registered solvers, sorted.
+func SolverNames() []string {
+	names := make([]string, 0, len(solvers))
+	for name := range solvers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// dpSolver is the residue based dynamic programming solver implemented by calculate.
+type dpSolver struct{}
+
+func (dpSolver) Name() string {
+	return SolverDP
+}
+
+func (dpSolver) Solve(order Order) ([]model.PackageUnit, error) {
+	return calculate(order)
+}
+
+func (dpSolver) Prepare(template Order) (solveFunc, error) {
+	return prepareCalculation(template)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"gymshark-interview/internal/model"
+)
+
+// maxBranchAndBoundNodes bounds the search of the branch and bound solver so adversarial package sizes can't hang a request
+const maxBranchAndBoundNodes = 10_000_000
+
+// branchAndBoundSolver is an exact solver. It looks for the fewest packs, then the fewest items, whose sum falls
+// within a window of amounts: the amount picked from the reachability tables for the items-first and exact
+// objectives, or the order bounds for the packs-first objective. A depth first search tries the biggest package
+// sizes first and prunes any branch that can't beat the best packing found so far. The search order makes the
+// first packing found for a rank the one that prefers bigger package sizes, so its answers match the dp solver.
+// The cost objective and limited stock are left to the dp solver.
+type branchAndBoundSolver struct {
+	maxNodes int
+}
+
+func (branchAndBoundSolver) Name() string {
+	return SolverBranchAndBound
+}
+
+func (s branchAndBoundSolver) Solve(order Order) ([]model.PackageUnit, error) {
+	order, err := normaliseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	if order.Objective == ObjectiveCost {
+		return nil, ErrObjectiveNotSupported
+	}
+	if len(order.Stock) > 0 {
+		return nil, ErrStockNotSupported
+	}
+	r, err := newReachability(order.PackageSizes)
+	if err != nil {
+		return nil, order.limit(err.Error())
+	}
+
+	lo, hi := r.totalBounds(order)
+	if order.Objective != ObjectivePacksFirst {
+		total, err := r.itemsFirstTotal(order)
+		if err != nil {
+			return nil, err
+		}
+		lo, hi = total, total
+	}
+
+	search := &branchAndBound{
+		sizes:     r.sizes,
+		minItems:  lo,
+		counts:    make([]int, len(r.sizes)),
+		bestPacks: -1,
+		nodesLeft: s.maxNodes,
+	}
+	search.visit(len(r.sizes)-1, lo, hi, 0, 0)
+	if search.nodesLeft < 0 {
+		return nil, ErrSolverLimitExceeded
+	}
+	if search.best == nil {
+		if order.MaxOverfill != nil {
+			return nil, ErrNoFeasiblePacking
+		}
+		return nil, order.overflow("the amount of items to ship exceeds the maximum int")
+	}
+	return r.packageUnits(search.best), nil
+}
+
+type branchAndBound struct {
+	sizes     []int // normalised and ascending
+	minItems  int   // no packing can ship less than this
+	counts    []int
+	best      []int
+	bestPacks int
+	bestItems int
+	nodesLeft int
+}
+
+// visit picks the amount of packs of sizes[i], from the most to the least. lo and hi are the items still needed
+// and still allowed, packs and items what was picked so far.
+func (b *branchAndBound) visit(i, lo, hi, packs, items int) {
+	b.nodesLeft--
+	if b.nodesLeft < 0 {
+		return
+	}
+	size := b.sizes[i]
+	needed := 0
+	if lo > 0 {
+		needed = ceilDiv(lo, size)
+	}
+
+	if i == 0 {
+		if needed > hi/size {
+			return
+		}
+		packs += needed
+		items += needed * size
+		if b.bestPacks < 0 || packs < b.bestPacks || (packs == b.bestPacks && items < b.bestItems) {
+			b.counts[0] = needed
+			b.best = append(b.best[:0], b.counts...)
+			b.bestPacks = packs
+			b.bestItems = items
+		}
+		return
+	}
+
+	// packing more than needed only adds packs and items
+	next := b.sizes[i-1]
+	for count := min(needed, hi/size); count >= 0; count-- {
+		left := lo - count*size
+		// the smaller sizes need at least this many packs for what is left
+		lowerBound := packs + count
+		if left > 0 {
+			lowerBound += ceilDiv(left, next)
+		}
+		if b.bestPacks >= 0 && (lowerBound > b.bestPacks || (lowerBound == b.bestPacks && b.bestItems <= b.minItems)) {
+			// fewer packs of this size only raise the bound
+			b.counts[i] = 0
+			return
+		}
+		b.counts[i] = count
+		b.visit(i-1, left, hi-count*size, packs+count, items+count*size)
+		if b.nodesLeft < 0 {
+			return
+		}
+	}
+	b.counts[i] = 0
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"gymshark-interview/internal/model"
+	"math"
+	"slices"
+)
+
+// maxGreedyRepairRounds bounds the repair loop of the greedy solver
+const maxGreedyRepairRounds = 100
+
+// greedySolver is a fast path that doesn't guarantee the fewest items. It fills the order with the biggest
+// package sizes first, covers what is left with one smallest pack and then repairs the result: packs that
+// aren't needed are dropped, packs are swapped for smaller ones while the order stays covered and several
+// packs adding up to a bigger size are merged into it. Being a heuristic, it only supports the items-first
+// objective without a maximum overfill nor limited stock.
+type greedySolver struct{}
+
+func (greedySolver) Name() string {
+	return SolverGreedy
+}
+
+func (greedySolver) Solve(order Order) ([]model.PackageUnit, error) {
+	order, err := normaliseOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	if order.Objective != ObjectiveItemsFirst || order.MaxOverfill != nil {
+		return nil, ErrObjectiveNotSupported
+	}
+	if len(order.Stock) > 0 {
+		return nil, ErrStockNotSupported
+	}
+
+	sizes := slices.Clone(order.PackageSizes)
+	slices.Sort(sizes) // sort ascending
+	sizes = slices.Compact(sizes)
+	units := max(order.Units, 0)
+
+	counts := make([]int, len(sizes))
+	remaining := units
+	for i := len(sizes) - 1; i >= 0; i-- {
+		counts[i] = remaining / sizes[i]
+		remaining -= counts[i] * sizes[i]
+	}
+	if remaining > 0 {
+		counts[0]++
+	}
+
+	excess := -remaining
+	if remaining > 0 {
+		if sizes[0]-remaining > math.MaxInt-units {
+			return nil, order.overflow("the amount of items to ship exceeds the maximum int")
+		}
+		excess = sizes[0] - remaining
+	}
+
+	for range maxGreedyRepairRounds {
+		if !repairGreedy(sizes, counts, &excess) {
+			break
+		}
+	}
+
+	res := []model.PackageUnit{}
+	for i, count := range counts {
+		if count > 0 {
+			res = append(res, model.PackageUnit{Size: sizes[i], Amount: count})
+		}
+	}
+	return res, nil
+}
+
+// repairGreedy applies one round of improvements to counts and reports whether anything changed.
+// excess is the amount of items shipped over the order.
+func repairGreedy(sizes, counts []int, excess *int) bool {
+	changed := false
+
+	// drop packs that aren't needed, biggest first
+	for i := len(sizes) - 1; i >= 0; i-- {
+		if drop := min(counts[i], *excess/sizes[i]); drop > 0 {
+			counts[i] -= drop
+			*excess -= drop * sizes[i]
+			changed = true
+		}
+	}
+
+	// swap packs for smaller ones while the order stays covered
+	for i := len(sizes) - 1; i > 0; i-- {
+		for j := 0; j < i && counts[i] > 0; j++ {
+			saving := sizes[i] - sizes[j]
+			if swap := min(counts[i], *excess/saving); swap > 0 {
+				counts[i] -= swap
+				counts[j] += swap
+				*excess -= swap * saving
+				changed = true
+			}
+		}
+	}
+
+	// merge packs of one size adding up exactly to a bigger size
+	for i := 0; i < len(sizes); i++ {
+		for j := len(sizes) - 1; j > i; j-- {
+			if sizes[j]%sizes[i] != 0 {
+				continue
+			}
+			per := sizes[j] / sizes[i]
+			if merge := counts[i] / per; merge > 0 {
+				counts[i] -= merge * per
+				counts[j] += merge
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
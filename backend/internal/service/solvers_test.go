@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// given random package sizes - the exact solvers return the same packs
+func TestExactSolversAgree(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 500 {
+		packageSizes := make([]int, 1+random.Intn(4))
+		for i := range packageSizes {
+			packageSizes[i] = 1 + random.Intn(60)
+		}
+		units := 1 + random.Intn(5000)
+
+		want, err := solvers[SolverDP].Solve(Order{Units: units, PackageSizes: slices.Clone(packageSizes)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := solvers[SolverBranchAndBound].Solve(Order{Units: units, PackageSizes: slices.Clone(packageSizes)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(want, got) {
+			t.Fatalf("sizes %v units %d: dp %v branch-and-bound %v", packageSizes, units, want, got)
+		}
+	}
+}
+
+// given random package sizes - the greedy solver covers the order and never beats the exact solver
+func TestGreedySolverCoversOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 500 {
+		packageSizes := make([]int, 1+random.Intn(4))
+		for i := range packageSizes {
+			packageSizes[i] = 1 + random.Intn(60)
+		}
+		units := 1 + random.Intn(5000)
+
+		exact, err := solvers[SolverDP].Solve(Order{Units: units, PackageSizes: slices.Clone(packageSizes)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		greedy, err := solvers[SolverGreedy].Solve(Order{Units: units, PackageSizes: slices.Clone(packageSizes)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exactItems, exactPacks := countItemsAndPacks(exact)
+		greedyItems, greedyPacks := countItemsAndPacks(greedy)
+		if greedyItems < units || greedyItems < exactItems || (greedyItems == exactItems && greedyPacks < exactPacks) {
+			t.Fatalf("sizes %v units %d: exact %v greedy %v", packageSizes, units, exact, greedy)
+		}
+	}
+}
+
+// given 250 500 1000 2000 5000 Items - every solver passes the requirements examples
+func TestSolversRequirementsExamples(t *testing.T) {
+	packageSizes := []int{250, 500, 1000, 2000, 5000}
+	tests := []struct {
+		quantity    int
+		expectedRes []model.PackageUnit
+	}{
+		{quantity: 1, expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}}},
+		{quantity: 250, expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}}},
+		{quantity: 251, expectedRes: []model.PackageUnit{{Size: 500, Amount: 1}}},
+		{quantity: 501, expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 500, Amount: 1}}},
+		{quantity: 12001, expectedRes: []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 2000, Amount: 1}, {Size: 5000, Amount: 2}}},
+	}
+
+	for _, name := range SolverNames() {
+		solver, err := GetSolver(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, testCase := range tests {
+			res, err := solver.Solve(Order{Units: testCase.quantity, PackageSizes: slices.Clone(packageSizes)})
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !slices.Equal(res, testCase.expectedRes) {
+				t.Fatalf("%s quantity %d: want %v got %v", name, testCase.quantity, testCase.expectedRes, res)
+			}
+		}
+	}
+}
+
+func TestBranchAndBoundSolverLimit(t *testing.T) {
+	solver := branchAndBoundSolver{maxNodes: 10}
+
+	_, err := solver.Solve(Order{Units: 4999 * 4999, PackageSizes: []int{4999, 5000}})
+	if !errors.Is(err, ErrSolverLimitExceeded) {
+		t.Fatalf("want limit exceeded, got %v", err)
+	}
+}
+
+func TestGetSolverUnknown(t *testing.T) {
+	_, err := GetSolver("simplex")
+	if !errors.Is(err, ErrUnknownSolver) {
+		t.Fail()
+	}
+}
+
+func TestCalculatePackagesSolverSelection(t *testing.T) {
+	tests := []struct {
+		productSolver string
+		requestSolver string
+		wantSolver    string
+	}{
+		{productSolver: "", requestSolver: "", wantSolver: DefaultSolver},
+		{productSolver: SolverGreedy, requestSolver: "", wantSolver: SolverGreedy},
+		{productSolver: SolverGreedy, requestSolver: SolverBranchAndBound, wantSolver: SolverBranchAndBound},
+	}
+
+	for _, testCase := range tests {
+		mockStorage := &mockPackageStorage{
+			wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: []int{250, 500}, Solver: testCase.productSolver},
+		}
+		service := NewPackageService(mockStorage)
+
+		res, err := service.CalculatePackages(context.TODO(), "ABC", 750, CalculateOptions{Solver: testCase.requestSolver})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Solver != testCase.wantSolver {
+			t.Fatalf("want solver %s got %s", testCase.wantSolver, res.Solver)
+		}
+	}
+
+	mockStorage := &mockPackageStorage{
+		wantRes: &model.Product{ID: uuid.NewString(), Name: "ABC", PackageSizes: []int{250, 500}},
+	}
+	service := NewPackageService(mockStorage)
+	_, err := service.CalculatePackages(context.TODO(), "ABC", 750, CalculateOptions{Solver: "simplex"})
+	if !errors.Is(err, ErrUnknownSolver) {
+		t.Fail()
+	}
+}
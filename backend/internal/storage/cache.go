@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"gymshark-interview/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache reads products with their package sizes through to a Backend, keeping them in memory for the TTL, so
+// calculations don't query the catalog each time. Every change to a product made through the cache drops it, so
+// it's only stale for changes made elsewhere, eg. by another server on the same database, and for at most the TTL.
+// Everything else goes straight to the Backend.
+type Cache struct {
+	Backend
+	ttl time.Duration
+
+	mu       sync.RWMutex
+	products map[string]cachedProduct
+	// generation counts the changes, so a product read before a change isn't cached after it
+	generation uint64
+
+	hits, misses atomic.Int64
+}
+
+type cachedProduct struct {
+	product model.Product
+	expires time.Time
+}
+
+// CacheStats counts the products read from a Cache, and from its Backend on a miss.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+func NewCache(backend Backend, ttl time.Duration) *Cache {
+	return &Cache{
+		Backend:  backend,
+		ttl:      ttl,
+		products: map[string]cachedProduct{},
+	}
+}
+
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Len returns the amount of products cached, including the expired ones not read or swept since.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.products)
+}
+
+func (c *Cache) GetProductWithPackageSizes(ctx context.Context, productID string) (*model.Product, error) {
+	if product, ok := c.get(productID); ok {
+		c.hits.Add(1)
+		return &product, nil
+	}
+	c.misses.Add(1)
+
+	generation := c.currentGeneration()
+	product, err := c.Backend.GetProductWithPackageSizes(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	c.put(generation, *product)
+	return product, nil
+}
+
+// GetProductsWithPackageSizes returns the products with the given ids, sorted by id, reading the ones that aren't
+// cached in a single call to the Backend. Missing products are left out.
+func (c *Cache) GetProductsWithPackageSizes(ctx context.Context, productIDs []string) ([]model.Product, error) {
+	products := []model.Product{}
+	var missed []string
+	seen := map[string]bool{}
+	for _, id := range productIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if product, ok := c.get(id); ok {
+			products = append(products, product)
+		} else {
+			missed = append(missed, id)
+		}
+	}
+	c.hits.Add(int64(len(products)))
+
+	if len(missed) > 0 {
+		c.misses.Add(int64(len(missed)))
+		generation := c.currentGeneration()
+		read, err := c.Backend.GetProductsWithPackageSizes(ctx, missed)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range read {
+			c.put(generation, product)
+		}
+		products = append(products, read...)
+		slices.SortFunc(products, func(a, b model.Product) int { return strings.Compare(a.ID, b.ID) })
+	}
+	return products, nil
+}
+
+func (c *Cache) DeleteProduct(ctx context.Context, id string, ifVersion int) error {
+	defer c.drop(id)
+	return c.Backend.DeleteProduct(ctx, id, ifVersion)
+}
+
+func (c *Cache) UpdateProductName(ctx context.Context, id string, name string, ifVersion int) error {
+	defer c.drop(id)
+	return c.Backend.UpdateProductName(ctx, id, name, ifVersion)
+}
+
+func (c *Cache) SetProductMaxOverfill(ctx context.Context, productID string, limit model.OverfillLimit, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.SetProductMaxOverfill(ctx, productID, limit, ifVersion)
+}
+
+func (c *Cache) AddPackageSize(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.AddPackageSize(ctx, productID, size, price, ifVersion)
+}
+
+func (c *Cache) RemovePackageSize(ctx context.Context, productID string, size int, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.RemovePackageSize(ctx, productID, size, ifVersion)
+}
+
+func (c *Cache) ReplacePackageSizes(ctx context.Context, productID string, sizes []int, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.ReplacePackageSizes(ctx, productID, sizes, ifVersion)
+}
+
+func (c *Cache) SetPackageSizePrice(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.SetPackageSizePrice(ctx, productID, size, price, ifVersion)
+}
+
+func (c *Cache) SetPackageSizeStock(ctx context.Context, productID string, size int, stock *int, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.SetPackageSizeStock(ctx, productID, size, stock, ifVersion)
+}
+
+func (c *Cache) AdjustPackageSizeStock(ctx context.Context, productID string, size int, delta int, ifVersion int) error {
+	defer c.drop(productID)
+	return c.Backend.AdjustPackageSizeStock(ctx, productID, size, delta, ifVersion)
+}
+
+// Sweep deletes the expired products, which are otherwise only deleted when they're read, and returns how many it
+// deleted.
+func (c *Cache) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	swept := 0
+	for id, cached := range c.products {
+		if !now.Before(cached.expires) {
+			delete(c.products, id)
+			swept++
+		}
+	}
+	return swept
+}
+
+// get returns a copy of a cached product, unless it has expired. An expired product is deleted.
+func (c *Cache) get(productID string) (model.Product, bool) {
+	c.mu.RLock()
+	cached, ok := c.products[productID]
+	c.mu.RUnlock()
+	if !ok {
+		return model.Product{}, false
+	}
+	if !time.Now().Before(cached.expires) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// unless it was cached again meanwhile
+		if current, ok := c.products[productID]; ok && current.expires.Equal(cached.expires) {
+			delete(c.products, productID)
+		}
+		return model.Product{}, false
+	}
+	return copyProduct(cached.product), true
+}
+
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// put caches a copy of a product read at generation, unless a product has changed since.
+func (c *Cache) put(generation uint64, product model.Product) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	c.products[product.ID] = cachedProduct{product: copyProduct(product), expires: time.Now().Add(c.ttl)}
+}
+
+// drop forgets a product after a change, failed or not: a version mismatch means the cached product is stale.
+func (c *Cache) drop(productID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	delete(c.products, productID)
+}
+
+func copyProduct(product model.Product) model.Product {
+	product.PackageSizes = slices.Clone(product.PackageSizes)
+	product.PackagePrices = slices.Clone(product.PackagePrices)
+	product.PackageStock = slices.Clone(product.PackageStock)
+	product.MaxOverfill = copyOverfillLimit(product.MaxOverfill)
+	return product
+}
@@ -1,12 +1,54 @@
 package storage
 
+import (
+	"database/sql"
+	"time"
+)
+
 type packageSize struct {
-	ID        string `db:"id"`
-	ProductID string `db:"product_id"`
-	Size      int    `db:"size"`
+	ID        string         `db:"id"`
+	ProductID string         `db:"product_id"`
+	Size      int            `db:"size"`
+	UnitCost  sql.NullInt64  `db:"unit_cost"`
+	Currency  sql.NullString `db:"currency"`
+	Stock     sql.NullInt64  `db:"stock"`
 }
 
 type product struct {
-	ID   string `db:"id"`
-	Name string `db:"name"`
+	ID     string `db:"id"`
+	Name   string `db:"name"`
+	Solver string `db:"solver"`
+}
+
+type order struct {
+	ID        string    `db:"id"`
+	ProductID string    `db:"product_id"`
+	Units     int       `db:"units"`
+	State     string    `db:"state"`
+	Solver    string    `db:"solver"`
+	Objective string    `db:"objective"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type orderPackageUnit struct {
+	OrderID string `db:"order_id"`
+	Size    int    `db:"size"`
+	Amount  int    `db:"amount"`
+}
+
+type orderPackageSize struct {
+	OrderID string `db:"order_id"`
+	Size    int    `db:"size"`
+}
+
+type idempotencyKey struct {
+	Key         string         `db:"key"`
+	Fingerprint string         `db:"fingerprint"`
+	Status      sql.NullInt64  `db:"status"`
+	ContentType sql.NullString `db:"content_type"`
+	Location    sql.NullString `db:"location"`
+	ETag        sql.NullString `db:"etag"`
+	Body        []byte         `db:"body"`
+	CreatedAt   time.Time      `db:"created_at"`
 }
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"log"
+	"time"
+)
+
+var (
+	ErrFailedToReserveIdempotencyKey = errors.New("failed to reserve idempotency key")
+	ErrFailedToSaveIdempotencyKey    = errors.New("failed to save idempotency key")
+)
+
+// ReserveIdempotencyKey records a request being handled under its key. It returns nil when the key is free, or
+// the stored response otherwise. Keys created before expiredBefore, and keys without a response created before
+// abandonedBefore, are deleted first, so they are free again.
+func (s *Storage) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*model.IdempotentResponse, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("failed to reserve idempotency key in DB: %v", err)
+		return nil, ErrFailedToReserveIdempotencyKey
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, s.db.Rebind("DELETE FROM idempotency_keys WHERE created_at < ? OR (status IS NULL AND created_at < ?)"),
+		expiredBefore.UTC(), abandonedBefore.UTC())
+	if err != nil {
+		log.Printf("failed to delete expired idempotency keys in DB: %v", err)
+		return nil, ErrFailedToReserveIdempotencyKey
+	}
+	res, err := tx.ExecContext(ctx, s.db.Rebind("INSERT INTO idempotency_keys (key,fingerprint,created_at) VALUES (?,?,?) ON CONFLICT DO NOTHING"),
+		key, fingerprint, time.Now().UTC())
+	if err != nil {
+		log.Printf("failed to reserve idempotency key in DB: %v", err)
+		return nil, ErrFailedToReserveIdempotencyKey
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("failed to reserve idempotency key in DB: %v", err)
+		return nil, ErrFailedToReserveIdempotencyKey
+	}
+
+	var stored *model.IdempotentResponse
+	if inserted == 0 {
+		var row idempotencyKey
+		err = tx.GetContext(ctx, &row, s.db.Rebind("SELECT key, fingerprint, status, content_type, location, etag, body, created_at FROM idempotency_keys WHERE key=?"), key)
+		if err != nil {
+			log.Printf("failed to get idempotency key from DB: %v", err)
+			return nil, ErrFailedToReserveIdempotencyKey
+		}
+		stored = &model.IdempotentResponse{
+			Key:         row.Key,
+			Fingerprint: row.Fingerprint,
+			Status:      int(row.Status.Int64),
+			ContentType: row.ContentType.String,
+			Location:    row.Location.String,
+			ETag:        row.ETag.String,
+			Body:        row.Body,
+			CreatedAt:   row.CreatedAt,
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("failed to reserve idempotency key in DB: %v", err)
+		return nil, ErrFailedToReserveIdempotencyKey
+	}
+	return stored, nil
+}
+
+// SaveIdempotentResponse stores the response to the request that reserved its key.
+func (s *Storage) SaveIdempotentResponse(ctx context.Context, response model.IdempotentResponse) error {
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE idempotency_keys SET status=?, content_type=?, location=?, etag=?, body=? WHERE key=? AND fingerprint=?"),
+		response.Status, response.ContentType, response.Location, response.ETag, response.Body, response.Key, response.Fingerprint)
+	if err != nil {
+		log.Printf("failed to save idempotent response in DB: %v", err)
+		return ErrFailedToSaveIdempotencyKey
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey frees a key, so the request can be retried.
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM idempotency_keys WHERE key=?"), key); err != nil {
+		log.Printf("failed to delete idempotency key in DB: %v", err)
+		return ErrFailedToSaveIdempotencyKey
+	}
+	return nil
+}
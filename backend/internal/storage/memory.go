@@ -0,0 +1,528 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Memory keeps everything Storage stores in Go maps, for tests and throwaway deployments. It behaves like Storage
+// on SQLite, down to the sentinel errors: names are unique, so are the sizes of a product, and deleting a product
+// deletes its package sizes and order history. A single lock makes every change atomic, reads share it.
+type Memory struct {
+	mu              sync.RWMutex
+	products        map[string]*memoryProduct
+	productNames    map[string]string // id of the product with each name
+	orders          map[string]model.Order
+	idempotencyKeys map[string]model.IdempotentResponse
+}
+
+type memoryProduct struct {
+	id          string
+	name        string
+	solver      string
+	maxOverfill model.OverfillLimit
+	version     int
+	sizes       map[int]*memoryPackageSize
+	history     []int
+}
+
+type memoryPackageSize struct {
+	price *model.PackagePrice
+	stock *int
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		products:        map[string]*memoryProduct{},
+		productNames:    map[string]string{},
+		orders:          map[string]model.Order{},
+		idempotencyKeys: map[string]model.IdempotentResponse{},
+	}
+}
+
+func (m *Memory) GetProductWithPackageSizes(_ context.Context, productID string) (*model.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.products[productID]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	product := p.product()
+	return &product, nil
+}
+
+// GetProductsWithPackageSizes returns the products with the given ids, sorted by id. Missing products are left out.
+func (m *Memory) GetProductsWithPackageSizes(_ context.Context, productIDs []string) ([]model.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	products := []model.Product{}
+	seen := map[string]bool{}
+	for _, id := range productIDs {
+		if p, ok := m.products[id]; ok && !seen[id] {
+			seen[id] = true
+			products = append(products, p.product())
+		}
+	}
+	slices.SortFunc(products, func(a, b model.Product) int { return strings.Compare(a.ID, b.ID) })
+	return products, nil
+}
+
+func (m *Memory) CreateProduct(_ context.Context, product model.Product) (*model.Product, error) {
+	id, _ := uuid.NewV7()
+	p := &memoryProduct{
+		id:          id.String(),
+		name:        product.Name,
+		solver:      product.Solver,
+		maxOverfill: copyOverfillLimit(product.MaxOverfill),
+		version:     1,
+		sizes:       map[int]*memoryPackageSize{},
+	}
+	for _, size := range product.PackageSizes {
+		if _, exists := p.sizes[size]; exists {
+			return nil, ErrConstraintViolation
+		}
+		p.sizes[size] = &memoryPackageSize{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, taken := m.productNames[p.name]; taken {
+		return nil, ErrConstraintViolation
+	}
+	m.products[p.id] = p
+	m.productNames[p.name] = p.id
+
+	res := &model.Product{
+		ID:          p.id,
+		Name:        product.Name,
+		Solver:      product.Solver,
+		MaxOverfill: product.MaxOverfill,
+		Version:     1,
+	}
+	if len(product.PackageSizes) != 0 {
+		res.PackageSizes = slices.Clone(product.PackageSizes)
+	}
+	return res, nil
+}
+
+// ListProducts returns a page of products, sorted and filtered like Storage does in SQL.
+func (m *Memory) ListProducts(_ context.Context, query model.ProductQuery) ([]model.Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sortKey := func(p *memoryProduct) string { return p.id }
+	after := ""
+	if query.After != nil {
+		after = query.After.ID
+	}
+	if query.SortBy == model.ProductSortName {
+		sortKey = func(p *memoryProduct) string { return p.name }
+		if query.After != nil {
+			after = query.After.Name
+		}
+	}
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+
+	var page []*memoryProduct
+	prefix := asciiLower(query.NamePrefix)
+	for _, p := range m.products {
+		if !strings.HasPrefix(asciiLower(p.name), prefix) {
+			continue
+		}
+		if query.After != nil && direction*strings.Compare(sortKey(p), after) <= 0 {
+			continue
+		}
+		page = append(page, p)
+	}
+	slices.SortFunc(page, func(a, b *memoryProduct) int { return direction * strings.Compare(sortKey(a), sortKey(b)) })
+	if query.Limit >= 0 && len(page) > query.Limit {
+		page = page[:query.Limit]
+	}
+
+	products := make([]model.Product, len(page))
+	for i, p := range page {
+		products[i] = p.product()
+	}
+	return products, nil
+}
+
+// asciiLower lower cases ASCII letters only, like the LOWER function of SQLite.
+func asciiLower(value string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, value)
+}
+
+// UpdateProductName renames a product, names are unique.
+func (m *Memory) UpdateProductName(_ context.Context, productID string, name string, ifVersion int) error {
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		if owner, taken := m.productNames[name]; taken && owner != p.id {
+			return ErrConstraintViolation
+		}
+		delete(m.productNames, p.name)
+		m.productNames[name] = p.id
+		p.name = name
+		return nil
+	})
+}
+
+// SetProductMaxOverfill sets the overfill limit of a product, nil fields clear it.
+func (m *Memory) SetProductMaxOverfill(_ context.Context, productID string, limit model.OverfillLimit, ifVersion int) error {
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		p.maxOverfill = copyOverfillLimit(limit)
+		return nil
+	})
+}
+
+// DeleteProduct deletes a product with its package sizes and order history. With a non-zero ifVersion only that
+// version of the product is deleted, and ErrVersionMismatch is returned when the product is missing or at another
+// version.
+func (m *Memory) DeleteProduct(_ context.Context, id string, ifVersion int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.products[id]
+	if !ok || (ifVersion != 0 && p.version != ifVersion) {
+		if ifVersion != 0 {
+			return ErrVersionMismatch
+		}
+		return nil
+	}
+	delete(m.products, id)
+	delete(m.productNames, p.name)
+	return nil
+}
+
+// AddPackageSize adds a package size to a product.
+func (m *Memory) AddPackageSize(_ context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		if _, exists := p.sizes[size]; exists {
+			return ErrConstraintViolation
+		}
+		p.sizes[size] = &memoryPackageSize{price: copyPrice(price)}
+		return nil
+	})
+}
+
+// RemovePackageSize removes a package size from a product, removing a size it doesn't have changes nothing.
+func (m *Memory) RemovePackageSize(_ context.Context, productID string, size int, ifVersion int) error {
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		if _, exists := p.sizes[size]; !exists {
+			return errUnchanged
+		}
+		delete(p.sizes, size)
+		return nil
+	})
+}
+
+// SetPackageSizePrice sets the price of an existing package size, a nil price clears it.
+func (m *Memory) SetPackageSizePrice(_ context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
+	return m.changePackageSize(productID, size, ifVersion, func(packageSize *memoryPackageSize) error {
+		packageSize.price = copyPrice(price)
+		return nil
+	})
+}
+
+// SetPackageSizeStock sets the packs in stock of an existing package size, a nil stock stops tracking it.
+func (m *Memory) SetPackageSizeStock(_ context.Context, productID string, size int, stock *int, ifVersion int) error {
+	return m.changePackageSize(productID, size, ifVersion, func(packageSize *memoryPackageSize) error {
+		packageSize.stock = copyInt(stock)
+		return nil
+	})
+}
+
+// AdjustPackageSizeStock adds delta packs to the stock of a tracked package size, which can't go below zero.
+func (m *Memory) AdjustPackageSizeStock(_ context.Context, productID string, size int, delta int, ifVersion int) error {
+	return m.changePackageSize(productID, size, ifVersion, func(packageSize *memoryPackageSize) error {
+		if packageSize.stock == nil {
+			return ErrStockNotTracked
+		}
+		if *packageSize.stock+delta < 0 {
+			return ErrNegativeStock
+		}
+		stock := *packageSize.stock + delta
+		packageSize.stock = &stock
+		return nil
+	})
+}
+
+// ReplacePackageSizes replaces the package sizes of a product. Sizes the product already has keep their price and
+// stock, the others are removed.
+func (m *Memory) ReplacePackageSizes(_ context.Context, productID string, sizes []int, ifVersion int) error {
+	if hasDuplicates(sizes) {
+		return ErrConstraintViolation
+	}
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		replaced := map[int]*memoryPackageSize{}
+		for _, size := range sizes {
+			if packageSize, exists := p.sizes[size]; exists {
+				replaced[size] = packageSize
+			} else {
+				replaced[size] = &memoryPackageSize{}
+			}
+		}
+		p.sizes = replaced
+		return nil
+	})
+}
+
+// SetOrderHistory replaces the historical order quantities of a product.
+func (m *Memory) SetOrderHistory(_ context.Context, productID string, quantities []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.products[productID]
+	if !ok {
+		return ErrProductNotFound
+	}
+	p.history = slices.Clone(quantities)
+	return nil
+}
+
+// GetOrderHistory returns the historical order quantities of a product, in the order they were stored.
+func (m *Memory) GetOrderHistory(_ context.Context, productID string) ([]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.products[productID]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	return append([]int{}, p.history...), nil
+}
+
+// CreateOrder stores a quoted order with the snapshot of its packages and package sizes.
+func (m *Memory) CreateOrder(_ context.Context, order model.Order) (*model.Order, error) {
+	id, _ := uuid.NewV7()
+	order.ID = id.String()
+	order.CreatedAt = time.Now().UTC()
+	order.UpdatedAt = order.CreatedAt
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.orders[order.ID] = storedOrder(order)
+	return &order, nil
+}
+
+func (m *Memory) GetOrder(_ context.Context, orderID string) (*model.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	order = storedOrder(order)
+	return &order, nil
+}
+
+// ListOrders returns the orders matching the filter, oldest first.
+func (m *Memory) ListOrders(_ context.Context, filter model.OrderFilter) ([]model.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := []model.Order{}
+	for _, order := range m.orders {
+		if (filter.ProductID == "" || order.ProductID == filter.ProductID) && (filter.State == "" || order.State == filter.State) {
+			orders = append(orders, storedOrder(order))
+		}
+	}
+	slices.SortFunc(orders, func(a, b model.Order) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+	return orders, nil
+}
+
+// UpdateOrderState moves an order from a state to another. It fails with ErrOrderStateChanged when the order is
+// no longer in the from state.
+func (m *Memory) UpdateOrderState(_ context.Context, orderID string, from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, ok := m.orders[orderID]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if order.State != from {
+		return ErrOrderStateChanged
+	}
+	order.State = to
+	order.UpdatedAt = time.Now().UTC()
+	m.orders[orderID] = order
+	return nil
+}
+
+// storedOrder copies the part of an order Storage keeps, with its snapshot sorted by size like Storage reads it.
+func storedOrder(order model.Order) model.Order {
+	units := slices.Clone(order.Package.PackageUnits)
+	if units == nil {
+		units = []model.PackageUnit{}
+	}
+	slices.SortStableFunc(units, func(a, b model.PackageUnit) int { return cmp.Compare(a.Size, b.Size) })
+	sizes := append([]int{}, order.PackageSizes...)
+	slices.Sort(sizes)
+
+	var totalCost *int64
+	if order.Package.TotalCost != nil {
+		cost := *order.Package.TotalCost
+		totalCost = &cost
+	}
+	return model.Order{
+		ID:        order.ID,
+		ProductID: order.ProductID,
+		Units:     order.Units,
+		State:     order.State,
+		Package: model.Package{
+			PackageUnits: units,
+			Solver:       order.Package.Solver,
+			Objective:    order.Package.Objective,
+			TotalCost:    totalCost,
+			Currency:     order.Package.Currency,
+		},
+		PackageSizes: sizes,
+		CreatedAt:    order.CreatedAt,
+		UpdatedAt:    order.UpdatedAt,
+	}
+}
+
+// ReserveIdempotencyKey records a request being handled under its key. It returns nil when the key is free, or
+// the stored response otherwise. Keys created before expiredBefore, and keys without a response created before
+// abandonedBefore, are deleted first, so they are free again.
+func (m *Memory) ReserveIdempotencyKey(_ context.Context, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*model.IdempotentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	maps.DeleteFunc(m.idempotencyKeys, func(_ string, stored model.IdempotentResponse) bool {
+		return stored.CreatedAt.Before(expiredBefore) || (stored.Status == 0 && stored.CreatedAt.Before(abandonedBefore))
+	})
+	if stored, ok := m.idempotencyKeys[key]; ok {
+		stored.Body = slices.Clone(stored.Body)
+		return &stored, nil
+	}
+	m.idempotencyKeys[key] = model.IdempotentResponse{Key: key, Fingerprint: fingerprint, CreatedAt: time.Now().UTC()}
+	return nil, nil
+}
+
+// SaveIdempotentResponse stores the response to the request that reserved its key.
+func (m *Memory) SaveIdempotentResponse(_ context.Context, response model.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.idempotencyKeys[response.Key]
+	if !ok || stored.Fingerprint != response.Fingerprint {
+		return nil
+	}
+	stored.Status = response.Status
+	stored.ContentType = response.ContentType
+	stored.Location = response.Location
+	stored.ETag = response.ETag
+	stored.Body = slices.Clone(response.Body)
+	m.idempotencyKeys[response.Key] = stored
+	return nil
+}
+
+// DeleteIdempotencyKey frees a key, so the request can be retried.
+func (m *Memory) DeleteIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotencyKeys, key)
+	return nil
+}
+
+// changeProduct applies change to a product under the lock, bumping its version, like Storage.changeProduct.
+// change must check everything before changing anything, so a failed change leaves the product as it was.
+func (m *Memory) changeProduct(productID string, ifVersion int, change func(p *memoryProduct) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.products[productID]
+	if !ok {
+		return ErrProductNotFound
+	}
+	if ifVersion != 0 && p.version != ifVersion {
+		return ErrVersionMismatch
+	}
+	if err := change(p); errors.Is(err, errUnchanged) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	p.version++
+	return nil
+}
+
+// changePackageSize applies change to an existing package size of a product, see changeProduct.
+func (m *Memory) changePackageSize(productID string, size int, ifVersion int, change func(packageSize *memoryPackageSize) error) error {
+	return m.changeProduct(productID, ifVersion, func(p *memoryProduct) error {
+		packageSize, exists := p.sizes[size]
+		if !exists {
+			return ErrPackageSizeNotFound
+		}
+		return change(packageSize)
+	})
+}
+
+// product copies a stored product, with its package sizes sorted like Storage reads them.
+func (p *memoryProduct) product() model.Product {
+	product := model.Product{
+		ID:          p.id,
+		Name:        p.name,
+		Solver:      p.solver,
+		MaxOverfill: copyOverfillLimit(p.maxOverfill),
+		Version:     p.version,
+	}
+	for _, size := range slices.Sorted(maps.Keys(p.sizes)) {
+		packageSize := p.sizes[size]
+		product.PackageSizes = append(product.PackageSizes, size)
+		if packageSize.price != nil {
+			product.PackagePrices = append(product.PackagePrices, model.PackagePrice{
+				Size:     size,
+				UnitCost: packageSize.price.UnitCost,
+				Currency: packageSize.price.Currency,
+			})
+		}
+		if packageSize.stock != nil {
+			product.PackageStock = append(product.PackageStock, model.PackageStock{Size: size, Available: *packageSize.stock})
+		}
+	}
+	return product
+}
+
+func copyOverfillLimit(limit model.OverfillLimit) model.OverfillLimit {
+	return model.OverfillLimit{Items: copyInt(limit.Items), Percent: copyInt(limit.Percent)}
+}
+
+func copyPrice(price *model.PackagePrice) *model.PackagePrice {
+	if price == nil {
+		return nil
+	}
+	return &model.PackagePrice{UnitCost: price.UnitCost, Currency: price.Currency}
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+)
+
+var (
+	ErrFailedToSetOrderHistory = errors.New("failed to set order history")
+	ErrFailedToGetOrderHistory = errors.New("failed to get order history")
+)
+
+// orderHistoryBatch bounds the rows inserted by a statement, below the variables SQLite accepts per statement
+const orderHistoryBatch = 10_000
+
+// SetOrderHistory replaces the historical order quantities of a product, in a single transaction.
+func (s *Storage) SetOrderHistory(ctx context.Context, productID string, quantities []int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("failed to set order history in DB: %v", err)
+		return ErrFailedToSetOrderHistory
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, s.db.Rebind("SELECT 1 FROM products WHERE id=?"), productID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	} else if err != nil {
+		log.Printf("failed to get product from DB: %v", err)
+		return ErrFailedToSetOrderHistory
+	}
+
+	if _, err = tx.ExecContext(ctx, s.db.Rebind("DELETE FROM order_history WHERE product_id=?"), productID); err != nil {
+		log.Printf("failed to clear order history in DB: %v", err)
+		return ErrFailedToSetOrderHistory
+	}
+	for start := 0; start < len(quantities); start += orderHistoryBatch {
+		batch := quantities[start:min(start+orderHistoryBatch, len(quantities))]
+		args := make([]interface{}, 0, 2*len(batch))
+		for _, units := range batch {
+			args = append(args, productID, units)
+		}
+		command := "INSERT INTO order_history (product_id,units) VALUES" + strings.Repeat(" (?,?),", len(batch))
+		// remove last comma
+		command = command[:len(command)-1]
+		if _, err = tx.ExecContext(ctx, s.db.Rebind(command), args...); err != nil {
+			log.Printf("failed to create order history in DB: %v", err)
+			return ErrFailedToSetOrderHistory
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("failed to set order history in DB: %v", err)
+		return ErrFailedToSetOrderHistory
+	}
+	return nil
+}
+
+// GetOrderHistory returns the historical order quantities of a product, in the order they were stored.
+func (s *Storage) GetOrderHistory(ctx context.Context, productID string) ([]int, error) {
+	var exists int
+	err := s.db.GetContext(ctx, &exists, s.db.Rebind("SELECT 1 FROM products WHERE id=?"), productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		log.Printf("failed to get product from DB: %v", err)
+		return nil, ErrFailedToGetOrderHistory
+	}
+
+	quantities := []int{}
+	err = s.db.SelectContext(ctx, &quantities, s.db.Rebind("SELECT units FROM order_history WHERE product_id=? ORDER BY id"), productID)
+	if err != nil {
+		log.Printf("failed to get order history from DB: %v", err)
+		return nil, ErrFailedToGetOrderHistory
+	}
+	return quantities, nil
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gymshark-interview/internal/model"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrFailedToCreateOrder = errors.New("failed to create order")
+	ErrFailedToGetOrder    = errors.New("failed to get order")
+	ErrFailedToListOrders  = errors.New("failed to list orders")
+	ErrFailedToUpdateOrder = errors.New("failed to update order")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderStateChanged   = errors.New("order state changed")
+)
+
+const orderColumns = `SELECT id, product_id, units, state, solver, objective, total_cost, currency, created_at, updated_at
+		FROM orders`
+
+// CreateOrder stores a quoted order with the snapshot of its packages and package sizes, in a single transaction.
+func (s *Storage) CreateOrder(ctx context.Context, order model.Order) (*model.Order, error) {
+	id, _ := uuid.NewV7()
+	order.ID = id.String()
+	order.CreatedAt = time.Now().UTC()
+	order.UpdatedAt = order.CreatedAt
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("failed to create order in DB: %v", err)
+		return nil, ErrFailedToCreateOrder
+	}
+	defer tx.Rollback()
+
+	var totalCost sql.NullInt64
+	if order.Package.TotalCost != nil {
+		totalCost = sql.NullInt64{Int64: *order.Package.TotalCost, Valid: true}
+	}
+	currency := sql.NullString{String: order.Package.Currency, Valid: order.Package.Currency != ""}
+	_, err = tx.ExecContext(ctx, s.db.Rebind(`INSERT INTO orders (id,product_id,units,state,solver,objective,total_cost,currency,created_at,updated_at)
+		VALUES (?,?,?,?,?,?,?,?,?,?)`), order.ID, order.ProductID, order.Units, order.State, order.Package.Solver,
+		order.Package.Objective, totalCost, currency, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		log.Printf("failed to create order in DB: %v", err)
+		return nil, ErrFailedToCreateOrder
+	}
+	for _, packageUnit := range order.Package.PackageUnits {
+		_, err = tx.ExecContext(ctx, s.db.Rebind("INSERT INTO order_package_units (order_id,size,amount) VALUES (?,?,?)"),
+			order.ID, packageUnit.Size, packageUnit.Amount)
+		if err != nil {
+			log.Printf("failed to create order package units in DB: %v", err)
+			return nil, ErrFailedToCreateOrder
+		}
+	}
+	for _, size := range order.PackageSizes {
+		_, err = tx.ExecContext(ctx, s.db.Rebind("INSERT INTO order_package_sizes (order_id,size) VALUES (?,?)"), order.ID, size)
+		if err != nil {
+			log.Printf("failed to create order package sizes in DB: %v", err)
+			return nil, ErrFailedToCreateOrder
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("failed to create order in DB: %v", err)
+		return nil, ErrFailedToCreateOrder
+	}
+	return &order, nil
+}
+
+func (s *Storage) GetOrder(ctx context.Context, orderID string) (*model.Order, error) {
+	orders, err := s.queryOrders(ctx, orderColumns+" WHERE id=?", orderID)
+	if err != nil {
+		return nil, ErrFailedToGetOrder
+	}
+	if len(orders) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return &orders[0], nil
+}
+
+// ListOrders returns the orders matching the filter, oldest first.
+func (s *Storage) ListOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, error) {
+	query := orderColumns + " WHERE (?='' OR product_id=?) AND (?='' OR state=?) ORDER BY created_at, id"
+
+	orders, err := s.queryOrders(ctx, query, filter.ProductID, filter.ProductID, filter.State, filter.State)
+	if err != nil {
+		return nil, ErrFailedToListOrders
+	}
+	return orders, nil
+}
+
+// UpdateOrderState moves an order from a state to another. It fails with ErrOrderStateChanged when the order is
+// no longer in the from state, so concurrent transitions can't both apply.
+func (s *Storage) UpdateOrderState(ctx context.Context, orderID string, from, to string) error {
+	res, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE orders SET state=?, updated_at=? WHERE id=? AND state=?"),
+		to, time.Now().UTC(), orderID, from)
+	if err != nil {
+		log.Printf("failed to update order state in DB: %v", err)
+		return ErrFailedToUpdateOrder
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("failed to update order state in DB: %v", err)
+		return ErrFailedToUpdateOrder
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists int
+	err = s.db.GetContext(ctx, &exists, s.db.Rebind("SELECT 1 FROM orders WHERE id=?"), orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOrderNotFound
+	} else if err != nil {
+		log.Printf("failed to get order from DB: %v", err)
+		return ErrFailedToUpdateOrder
+	}
+	return ErrOrderStateChanged
+}
+
+// queryOrders runs a query of orderColumns and loads the snapshots of the orders it returns.
+func (s *Storage) queryOrders(ctx context.Context, query string, args ...interface{}) ([]model.Order, error) {
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		log.Printf("failed to get orders from DB: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []model.Order{}
+	index := map[string]int{}
+	for rows.Next() {
+		var (
+			o         order
+			totalCost sql.NullInt64
+			currency  sql.NullString
+		)
+		err := rows.Scan(&o.ID, &o.ProductID, &o.Units, &o.State, &o.Solver, &o.Objective, &totalCost, &currency,
+			&o.CreatedAt, &o.UpdatedAt)
+		if err != nil {
+			log.Printf("failed to scan row: %v", err)
+			return nil, err
+		}
+		index[o.ID] = len(orders)
+		orders = append(orders, model.Order{
+			ID:        o.ID,
+			ProductID: o.ProductID,
+			Units:     o.Units,
+			State:     o.State,
+			Package: model.Package{
+				PackageUnits: []model.PackageUnit{},
+				Solver:       o.Solver,
+				Objective:    o.Objective,
+				TotalCost:    nullableInt64(totalCost),
+				Currency:     currency.String,
+			},
+			PackageSizes: []int{},
+			CreatedAt:    o.CreatedAt,
+			UpdatedAt:    o.UpdatedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("failed to read rows: %v", err)
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	var units []orderPackageUnit
+	if err := s.selectIn(ctx, &units, "SELECT order_id, size, amount FROM order_package_units WHERE order_id IN (?) ORDER BY size", ids); err != nil {
+		return nil, err
+	}
+	for _, u := range units {
+		o := &orders[index[u.OrderID]]
+		o.Package.PackageUnits = append(o.Package.PackageUnits, model.PackageUnit{Size: u.Size, Amount: u.Amount})
+	}
+	var sizes []orderPackageSize
+	if err := s.selectIn(ctx, &sizes, "SELECT order_id, size FROM order_package_sizes WHERE order_id IN (?) ORDER BY size", ids); err != nil {
+		return nil, err
+	}
+	for _, size := range sizes {
+		o := &orders[index[size.OrderID]]
+		o.PackageSizes = append(o.PackageSizes, size.Size)
+	}
+	return orders, nil
+}
+
+func (s *Storage) selectIn(ctx context.Context, dest interface{}, query string, ids []string) error {
+	query, args, err := sqlx.In(query, ids)
+	if err != nil {
+		log.Printf("failed to build query: %v", err)
+		return err
+	}
+	if err = s.db.SelectContext(ctx, dest, s.db.Rebind(query), args...); err != nil {
+		log.Printf("failed to get order snapshots from DB: %v", err)
+		return err
+	}
+	return nil
+}
+
+func nullableInt64(column sql.NullInt64) *int64 {
+	if !column.Valid {
+		return nil
+	}
+	value := column.Int64
+	return &value
+}
@@ -4,55 +4,169 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"gymshark-interview/internal/model"
 	"log"
+	"slices"
 
-	sqlite "github.com/glebarez/go-sqlite"
 	"github.com/google/uuid"
-	sqlite3 "modernc.org/sqlite/lib"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	ErrFailedToCreatePackageSize = errors.New("failed to create package size")
 	ErrFailedToDeletePackageSize = errors.New("failed to delete package size")
+	ErrFailedToUpdatePackageSize = errors.New("failed to update package size")
+	ErrPackageSizeNotFound       = errors.New("package size not found")
+	ErrStockNotTracked           = errors.New("package size stock isn't tracked")
+	ErrNegativeStock             = errors.New("package size stock can't go below zero")
 )
 
-func (s *Storage) AddPackageSize(ctx context.Context, productID string, size int) error {
+// AddPackageSize adds a package size to a product. A non-zero ifVersion is the version of the product the change
+// expects, see changeProduct.
+func (s *Storage) AddPackageSize(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
 	id, _ := uuid.NewV7()
+	unitCost, currency := priceColumns(price)
 
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	_, err := s.db.ExecContext(ctx, "INSERT INTO package_sizes (id,product_id,size) VALUES (?,?,?)",
-		id, productID, size)
-	if err != nil {
-		log.Printf("failed to create package size in DB: %v", err)
-		var sqliteError *sqlite.Error
-		if errors.As(err, &sqliteError) {
-			if sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToCreatePackageSize, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, s.db.Rebind("INSERT INTO package_sizes (id,product_id,size,unit_cost,currency) VALUES (?,?,?,?,?)"),
+			id, productID, size, unitCost, currency)
+		if err != nil {
+			log.Printf("failed to create package size in DB: %v", err)
+			if isUniqueViolation(err) {
 				return ErrConstraintViolation
 			}
+			return ErrFailedToCreatePackageSize
 		}
-		return ErrFailedToCreatePackageSize
+		return nil
+	})
+}
+
+// RemovePackageSize removes a package size from a product, removing a size it doesn't have changes nothing.
+func (s *Storage) RemovePackageSize(ctx context.Context, productID string, size int, ifVersion int) error {
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToDeletePackageSize, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM package_sizes WHERE product_id=? AND size=?"), productID, size)
+		if err != nil {
+			log.Printf("failed to delete package size from DB: %v", err)
+			return ErrFailedToDeletePackageSize
+		}
+		return changed(res, ErrFailedToDeletePackageSize, errUnchanged)
+	})
+}
+
+// SetPackageSizePrice sets the price of an existing package size, a nil price clears it.
+func (s *Storage) SetPackageSizePrice(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error {
+	unitCost, currency := priceColumns(price)
+
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdatePackageSize, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE package_sizes SET unit_cost=?, currency=? WHERE product_id=? AND size=?"),
+			unitCost, currency, productID, size)
+		if err != nil {
+			log.Printf("failed to update package size price in DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		return changed(res, ErrFailedToUpdatePackageSize, ErrPackageSizeNotFound)
+	})
+}
+
+// SetPackageSizeStock sets the packs in stock of an existing package size, a nil stock stops tracking it.
+func (s *Storage) SetPackageSizeStock(ctx context.Context, productID string, size int, stock *int, ifVersion int) error {
+	var column sql.NullInt64
+	if stock != nil {
+		column = sql.NullInt64{Int64: int64(*stock), Valid: true}
 	}
-	return nil
+
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdatePackageSize, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE package_sizes SET stock=? WHERE product_id=? AND size=?"),
+			column, productID, size)
+		if err != nil {
+			log.Printf("failed to update package size stock in DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		return changed(res, ErrFailedToUpdatePackageSize, ErrPackageSizeNotFound)
+	})
 }
 
-func (s *Storage) RemovePackageSize(ctx context.Context, productID string, size int) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// AdjustPackageSizeStock adds delta packs to the stock of a tracked package size. The update is a single
+// statement so concurrent adjustments can't take the stock below zero.
+func (s *Storage) AdjustPackageSizeStock(ctx context.Context, productID string, size int, delta int, ifVersion int) error {
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdatePackageSize, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, s.db.Rebind(`UPDATE package_sizes SET stock=stock+?
+			WHERE product_id=? AND size=? AND stock IS NOT NULL AND stock+?>=0`), delta, productID, size, delta)
+		if err != nil {
+			log.Printf("failed to adjust package size stock in DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			log.Printf("failed to adjust package size stock in DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		if affected > 0 {
+			return nil
+		}
+
+		// find out why nothing was updated
+		var stock sql.NullInt64
+		err = tx.GetContext(ctx, &stock, s.db.Rebind("SELECT stock FROM package_sizes WHERE product_id=? AND size=?"), productID, size)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPackageSizeNotFound
+		} else if err != nil {
+			log.Printf("failed to get package size stock from DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		if !stock.Valid {
+			return ErrStockNotTracked
+		}
+		return ErrNegativeStock
+	})
+}
 
-	_, err := s.db.ExecContext(ctx, "DELETE FROM package_sizes WHERE product_id=? AND size=?", productID, size)
-	if err != nil {
-		log.Printf("failed to delete package size from DB: %v", err)
-		var sqliteError *sqlite.Error
-		if errors.As(err, &sqliteError) {
-			if sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
-				return ErrConstraintViolation
+// ReplacePackageSizes replaces the package sizes of a product in a single transaction. Sizes the product already
+// has keep their price and stock, the others are removed.
+func (s *Storage) ReplacePackageSizes(ctx context.Context, productID string, sizes []int, ifVersion int) error {
+	if hasDuplicates(sizes) {
+		return ErrConstraintViolation
+	}
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdatePackageSize, func(tx *sqlx.Tx) error {
+		var current []int
+		if err := tx.SelectContext(ctx, &current, s.db.Rebind("SELECT size FROM package_sizes WHERE product_id=?"), productID); err != nil {
+			log.Printf("failed to get package sizes from DB: %v", err)
+			return ErrFailedToUpdatePackageSize
+		}
+		var added []int
+		for _, size := range sizes {
+			if !slices.Contains(current, size) {
+				added = append(added, size)
 			}
 		}
-		return ErrFailedToDeletePackageSize
+		for _, size := range current {
+			if slices.Contains(sizes, size) {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM package_sizes WHERE product_id=? AND size=?"), productID, size); err != nil {
+				log.Printf("failed to delete package size from DB: %v", err)
+				return ErrFailedToUpdatePackageSize
+			}
+		}
+		if len(added) > 0 {
+			if _, err := s.createPackageSizes(ctx, tx.Tx, productID, added); err != nil {
+				return ErrFailedToUpdatePackageSize
+			}
+		}
+		return nil
+	})
+}
+
+func hasDuplicates(sizes []int) bool {
+	sorted := slices.Sorted(slices.Values(sizes))
+	return len(slices.Compact(sorted)) != len(sizes)
+}
+
+func priceColumns(price *model.PackagePrice) (sql.NullInt64, sql.NullString) {
+	if price == nil {
+		return sql.NullInt64{}, sql.NullString{}
 	}
-	return nil
+	return sql.NullInt64{Int64: price.UnitCost, Valid: true}, sql.NullString{String: price.Currency, Valid: true}
 }
 
 func (s *Storage) createPackageSizes(ctx context.Context, tx *sql.Tx, productID string, sizes []int) ([]int, error) {
@@ -65,7 +179,7 @@ func (s *Storage) createPackageSizes(ctx context.Context, tx *sql.Tx, productID
 	}
 	// remove last comma
 	command = command[:len(command)-1]
-	_, err := tx.ExecContext(ctx, command, args...)
+	_, err := tx.ExecContext(ctx, s.db.Rebind(command), args...)
 	if err != nil {
 		log.Printf("failed to create package size in DB: %v", err)
 		return nil, err
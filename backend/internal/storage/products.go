@@ -6,10 +6,10 @@ import (
 	"errors"
 	"gymshark-interview/internal/model"
 	"log"
+	"strings"
 
-	sqlite "github.com/glebarez/go-sqlite"
 	"github.com/google/uuid"
-	sqlite3 "modernc.org/sqlite/lib"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
@@ -17,55 +17,133 @@ var (
 	ErrFailedToDeleteProduct = errors.New("failed to delete product")
 	ErrFailedToListProducts  = errors.New("failed to list products")
 	ErrFailedToGetProduct    = errors.New("failed to get product")
+	ErrFailedToUpdateProduct = errors.New("failed to update product")
 	ErrProductNotFound       = errors.New("product not found")
 	ErrConstraintViolation   = errors.New("database constraint violation")
+	ErrVersionMismatch       = errors.New("product version doesn't match")
 )
 
+// errUnchanged rolls back a product change that had nothing to do, so the version isn't bumped.
+var errUnchanged = errors.New("product unchanged")
+
+// productFields are selected by the queries that load products with their package sizes, see scanProducts.
+const productFields = `SELECT p.id AS product_id, p.name, p.solver, p.max_overfill, p.max_overfill_percent, p.version,
+		pkg.size, pkg.unit_cost, pkg.currency, pkg.stock`
+
+const productColumns = productFields + ` FROM products p 
+		LEFT JOIN package_sizes pkg ON pkg.product_id = p.id`
+
 func (s *Storage) GetProductWithPackageSizes(ctx context.Context, productID string) (*model.Product, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	rows, err := s.db.QueryxContext(ctx, `
-		SELECT p.id AS product_id, p.name, pkg.size FROM products p 
-		LEFT JOIN package_sizes pkg ON pkg.product_id = p.id WHERE p.id = ?
-	`, productID)
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(productColumns+" WHERE p.id = ? ORDER BY pkg.size"), productID)
 	if err != nil {
 		log.Printf("failed to get product with package sizes in DB: %v", err)
 		return nil, ErrFailedToGetProduct
 	}
 	defer rows.Close()
 
-	var prod *model.Product
+	products, err := scanProducts(rows)
+	if err != nil {
+		return nil, ErrFailedToGetProduct
+	}
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	return &products[0], nil
+}
+
+// GetProductsWithPackageSizes returns the products with the given ids in a single query, sorted by id. Missing
+// products are left out.
+func (s *Storage) GetProductsWithPackageSizes(ctx context.Context, productIDs []string) ([]model.Product, error) {
+	if len(productIDs) == 0 {
+		return []model.Product{}, nil
+	}
+	query, args, err := sqlx.In(productColumns+" WHERE p.id IN (?) ORDER BY p.id, pkg.size", productIDs)
+	if err != nil {
+		log.Printf("failed to build products query: %v", err)
+		return nil, ErrFailedToGetProduct
+	}
+
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		log.Printf("failed to get products with package sizes in DB: %v", err)
+		return nil, ErrFailedToGetProduct
+	}
+	defer rows.Close()
+
+	products, err := scanProducts(rows)
+	if err != nil {
+		return nil, ErrFailedToGetProduct
+	}
+	return products, nil
+}
 
+// scanProducts groups the rows of productColumns by product, in the order the products first appear.
+func scanProducts(rows *sqlx.Rows) ([]model.Product, error) {
+	products := []model.Product{}
+	index := map[string]int{}
 	for rows.Next() {
 		var (
-			pID, pName string
-			pkgSize    sql.NullInt64
+			pID, pName, pSolver        string
+			pMaxOverfill, pMaxPercent  sql.NullInt64
+			pVersion                   int
+			pkgSize, pkgCost, pkgStock sql.NullInt64
+			pkgCurrency                sql.NullString
 		)
 
-		if err := rows.Scan(&pID, &pName, &pkgSize); err != nil {
+		if err := rows.Scan(&pID, &pName, &pSolver, &pMaxOverfill, &pMaxPercent, &pVersion, &pkgSize, &pkgCost, &pkgCurrency, &pkgStock); err != nil {
 			log.Printf("failed to scan row: %v", err)
-			return nil, ErrFailedToGetProduct
+			return nil, err
 		}
 
 		// only register once
-		if prod == nil {
-			prod = &model.Product{
-				ID:   pID,
-				Name: pName,
-			}
-
+		i, exists := index[pID]
+		if !exists {
+			i = len(products)
+			index[pID] = i
+			products = append(products, model.Product{
+				ID:     pID,
+				Name:   pName,
+				Solver: pSolver,
+				MaxOverfill: model.OverfillLimit{
+					Items:   nullableInt(pMaxOverfill),
+					Percent: nullableInt(pMaxPercent),
+				},
+				Version: pVersion,
+			})
 		}
+		prod := &products[i]
 
 		if pkgSize.Valid {
 			prod.PackageSizes = append(prod.PackageSizes, int(pkgSize.Int64))
+			if pkgCost.Valid {
+				prod.PackagePrices = append(prod.PackagePrices, model.PackagePrice{
+					Size:     int(pkgSize.Int64),
+					UnitCost: pkgCost.Int64,
+					Currency: pkgCurrency.String,
+				})
+			}
+			if pkgStock.Valid {
+				prod.PackageStock = append(prod.PackageStock, model.PackageStock{
+					Size:      int(pkgSize.Int64),
+					Available: int(pkgStock.Int64),
+				})
+			}
 		}
 	}
-
-	if prod == nil {
-		return nil, ErrProductNotFound
+	if err := rows.Err(); err != nil {
+		log.Printf("failed to read rows: %v", err)
+		return nil, err
 	}
+	return products, nil
+}
 
-	return prod, nil
+func nullableInt(column sql.NullInt64) *int {
+	if !column.Valid {
+		return nil
+	}
+	value := int(column.Int64)
+	return &value
 }
 
 func handleCreateProductError(tx *sql.Tx, err error) error {
@@ -74,11 +152,8 @@ func handleCreateProductError(tx *sql.Tx, err error) error {
 		err = errors.Join(err, txErr)
 	}
 	log.Printf("failed to create product in DB: %v", err)
-	var sqliteError *sqlite.Error
-	if errors.As(err, &sqliteError) {
-		if sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
-			return ErrConstraintViolation
-		}
+	if isUniqueViolation(err) {
+		return ErrConstraintViolation
 	}
 	return ErrFailedToCreateProduct
 }
@@ -86,15 +161,15 @@ func handleCreateProductError(tx *sql.Tx, err error) error {
 func (s *Storage) CreateProduct(ctx context.Context, product model.Product) (*model.Product, error) {
 	id, _ := uuid.NewV7()
 	var res model.Product
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, handleCreateProductError(tx, err)
+		// there's no transaction to roll back
+		log.Printf("failed to create product in DB: %v", err)
+		return nil, ErrFailedToCreateProduct
 	}
 
-	_, err = tx.ExecContext(ctx, "INSERT INTO products (id,name) VALUES (?,?)",
-		id.String(), product.Name)
+	_, err = tx.ExecContext(ctx, s.db.Rebind("INSERT INTO products (id,name,solver,max_overfill,max_overfill_percent) VALUES (?,?,?,?,?)"),
+		id.String(), product.Name, product.Solver, product.MaxOverfill.Items, product.MaxOverfill.Percent)
 	if err != nil {
 		return nil, handleCreateProductError(tx, err)
 	}
@@ -111,14 +186,53 @@ func (s *Storage) CreateProduct(ctx context.Context, product model.Product) (*mo
 		return nil, handleCreateProductError(tx, err)
 	}
 
+	res.ID = id.String()
+	res.Name = product.Name
+	res.Solver = product.Solver
+	res.MaxOverfill = product.MaxOverfill
+	res.Version = 1
 	return &res, nil
 }
 
-func (s *Storage) ListProducts(ctx context.Context) ([]model.Product, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	rows, err := s.db.QueryxContext(ctx, `SELECT p.id AS product_id, p.name, pkg.size FROM products p 
-		LEFT JOIN package_sizes pkg ON pkg.product_id = p.id`)
+// ListProducts returns a page of products, sorted and filtered in SQL. The page is selected before joining the
+// package sizes, so the limit counts products rather than rows.
+func (s *Storage) ListProducts(ctx context.Context, query model.ProductQuery) ([]model.Product, error) {
+	order, compare := "ASC", ">"
+	if query.Descending {
+		order, compare = "DESC", "<"
+	}
+	sortColumn := "id"
+	if query.SortBy == model.ProductSortName {
+		sortColumn = "name"
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if query.NamePrefix != "" {
+		where = append(where, `LOWER(name) LIKE LOWER(?) ESCAPE '\'`)
+		args = append(args, escapeLike(query.NamePrefix)+"%")
+	}
+	if query.After != nil {
+		// names are unique, so either column orders the products completely
+		if sortColumn == "name" {
+			where = append(where, "name "+compare+" ?")
+			args = append(args, query.After.Name)
+		} else {
+			where = append(where, "id "+compare+" ?")
+			args = append(args, query.After.ID)
+		}
+	}
+	page := "SELECT * FROM products"
+	if len(where) > 0 {
+		page += " WHERE " + strings.Join(where, " AND ")
+	}
+	page += " ORDER BY " + sortColumn + " " + order + " LIMIT ?"
+	args = append(args, query.Limit)
+
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(productFields+" FROM ("+page+") p LEFT JOIN package_sizes pkg ON pkg.product_id = p.id"+
+		" ORDER BY p."+sortColumn+" "+order+", pkg.size"), args...)
 	if err != nil {
 		log.Printf("failed to list products in DB: %v", err)
 		return nil, ErrFailedToListProducts
@@ -126,45 +240,111 @@ func (s *Storage) ListProducts(ctx context.Context) ([]model.Product, error) {
 
 	defer rows.Close()
 
-	products := make(map[string]*model.Product, 0)
-	for rows.Next() {
-		var (
-			pID, pName string
-			pkgSize    sql.NullInt64
-		)
+	products, err := scanProducts(rows)
+	if err != nil {
+		return nil, ErrFailedToListProducts
+	}
+	return products, nil
+}
 
-		if err := rows.Scan(&pID, &pName, &pkgSize); err != nil {
-			log.Printf("failed to scan row: %v", err)
-			return nil, ErrFailedToGetProduct
-		}
+// escapeLike escapes the wildcards of a LIKE pattern, with a backslash.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
 
-		// only register once
-		if _, exists := products[pID]; !exists {
-			products[pID] = &model.Product{
-				ID:   pID,
-				Name: pName,
+// UpdateProductName renames a product, names are unique.
+func (s *Storage) UpdateProductName(ctx context.Context, productID string, name string, ifVersion int) error {
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdateProduct, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE products SET name=? WHERE id=?"), name, productID); err != nil {
+			log.Printf("failed to update product name in DB: %v", err)
+			if isUniqueViolation(err) {
+				return ErrConstraintViolation
 			}
+			return ErrFailedToUpdateProduct
 		}
+		return nil
+	})
+}
 
-		if pkgSize.Valid {
-			products[pID].PackageSizes = append(products[pID].PackageSizes, int(pkgSize.Int64))
+// SetProductMaxOverfill sets the overfill limit of a product, nil fields clear it.
+func (s *Storage) SetProductMaxOverfill(ctx context.Context, productID string, limit model.OverfillLimit, ifVersion int) error {
+	return s.changeProduct(ctx, productID, ifVersion, ErrFailedToUpdateProduct, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE products SET max_overfill=?, max_overfill_percent=? WHERE id=?"),
+			limit.Items, limit.Percent, productID)
+		if err != nil {
+			log.Printf("failed to update product max overfill in DB: %v", err)
+			return ErrFailedToUpdateProduct
 		}
+		return nil
+	})
+}
+
+// DeleteProduct deletes a product. With a non-zero ifVersion only that version of the product is deleted, and
+// ErrVersionMismatch is returned when the product is missing or at another version.
+func (s *Storage) DeleteProduct(ctx context.Context, id string, ifVersion int) error {
+	res, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM products WHERE id=? AND (?=0 OR version=?)"), id, ifVersion, ifVersion)
+	if err != nil {
+		log.Printf("failed to delete product from DB: %v", err)
+		return ErrFailedToDeleteProduct
+	}
+	if ifVersion != 0 {
+		return changed(res, ErrFailedToDeleteProduct, ErrVersionMismatch)
+	}
+	return nil
+}
+
+// changeProduct applies change to a product in a transaction, bumping its version. A non-zero ifVersion is the
+// version the caller last read: the change is only applied if the product is still at it, otherwise
+// ErrVersionMismatch is returned. Internal errors are reported as failed.
+func (s *Storage) changeProduct(ctx context.Context, productID string, ifVersion int, failed error, change func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		log.Printf("failed to begin product change in DB: %v", err)
+		return failed
 	}
+	defer tx.Rollback()
 
-	res := make([]model.Product, 0)
-	for _, p := range products {
-		res = append(res, *p)
+	res, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE products SET version=version+1 WHERE id=? AND (?=0 OR version=?)"),
+		productID, ifVersion, ifVersion)
+	if err != nil {
+		log.Printf("failed to bump product version in DB: %v", err)
+		return failed
 	}
-	return res, nil
+	if err = changed(res, failed, ErrVersionMismatch); errors.Is(err, ErrVersionMismatch) {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, s.db.Rebind("SELECT EXISTS (SELECT 1 FROM products WHERE id=?)"), productID); err != nil {
+			log.Printf("failed to get product from DB: %v", err)
+			return failed
+		}
+		if !exists {
+			return ErrProductNotFound
+		}
+		return ErrVersionMismatch
+	} else if err != nil {
+		return err
+	}
+
+	if err = change(tx); errors.Is(err, errUnchanged) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Printf("failed to commit product change in DB: %v", err)
+		return failed
+	}
+	return nil
 }
 
-func (s *Storage) DeleteProduct(ctx context.Context, id string) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	_, err := s.db.ExecContext(ctx, "DELETE FROM products WHERE id=?", id)
+// changed returns notFound when a statement affected no rows.
+func changed(res sql.Result, failed error, notFound error) error {
+	affected, err := res.RowsAffected()
 	if err != nil {
-		log.Printf("failed to delete product from DB: %v", err)
-		return ErrFailedToDeleteProduct
+		log.Printf("failed to get affected rows: %v", err)
+		return failed
+	}
+	if affected == 0 {
+		return notFound
 	}
 	return nil
 }
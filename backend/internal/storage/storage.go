@@ -1,19 +1,71 @@
 package storage
 
 import (
-	"sync"
+	"context"
+	"errors"
+	"gymshark-interview/internal/model"
+	"time"
 
+	sqlite "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
+// pgUniqueViolation is the SQLSTATE of a failed unique constraint in Postgres
+const pgUniqueViolation = "23505"
+
+// Storage runs on any database opened with a supported driver, see the database package. Queries are written
+// with ? placeholders and rebound to the dialect of the driver. It's safe for concurrent use without locks of its
+// own: reads are single statements and changes run in transactions, so the database keeps them consistent.
 type Storage struct {
-	db    *sqlx.DB
-	mutex *sync.Mutex
+	db *sqlx.DB
 }
 
 func New(db *sqlx.DB) *Storage {
 	return &Storage{
-		db:    db,
-		mutex: new(sync.Mutex),
+		db: db,
+	}
+}
+
+// Backend is everything the services store, implemented alike by Storage, Memory and a Cache in front of either.
+type Backend interface {
+	GetProductWithPackageSizes(ctx context.Context, id string) (*model.Product, error)
+	GetProductsWithPackageSizes(ctx context.Context, ids []string) ([]model.Product, error)
+	ListProducts(ctx context.Context, query model.ProductQuery) ([]model.Product, error)
+	CreateProduct(ctx context.Context, product model.Product) (*model.Product, error)
+	DeleteProduct(ctx context.Context, id string, ifVersion int) error
+	UpdateProductName(ctx context.Context, id string, name string, ifVersion int) error
+	SetProductMaxOverfill(ctx context.Context, productID string, limit model.OverfillLimit, ifVersion int) error
+	AddPackageSize(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error
+	RemovePackageSize(ctx context.Context, productID string, size int, ifVersion int) error
+	ReplacePackageSizes(ctx context.Context, productID string, sizes []int, ifVersion int) error
+	SetPackageSizePrice(ctx context.Context, productID string, size int, price *model.PackagePrice, ifVersion int) error
+	SetPackageSizeStock(ctx context.Context, productID string, size int, stock *int, ifVersion int) error
+	AdjustPackageSizeStock(ctx context.Context, productID string, size int, delta int, ifVersion int) error
+	SetOrderHistory(ctx context.Context, productID string, quantities []int) error
+	GetOrderHistory(ctx context.Context, productID string) ([]int, error)
+	CreateOrder(ctx context.Context, order model.Order) (*model.Order, error)
+	GetOrder(ctx context.Context, id string) (*model.Order, error)
+	ListOrders(ctx context.Context, filter model.OrderFilter) ([]model.Order, error)
+	UpdateOrderState(ctx context.Context, id string, from, to string) error
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, response model.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+var (
+	_ Backend = (*Storage)(nil)
+	_ Backend = (*Memory)(nil)
+	_ Backend = (*Cache)(nil)
+)
+
+// isUniqueViolation tells whether err is a unique constraint failing, in any of the supported databases.
+func isUniqueViolation(err error) bool {
+	var sqliteError *sqlite.Error
+	if errors.As(err, &sqliteError) {
+		return sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
 	}
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == pgUniqueViolation
 }
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"testing"
+)
+
+func TestAnalysePackSet(t *testing.T) {
+	resp, err := http.Get(hostname + "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/analysis")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var analysis server.AnalysePackSetResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&analysis)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if analysis.GreatestCommonDivisor != 250 || analysis.FrobeniusNumber != nil || analysis.WorstOverfill != 249 || len(analysis.RedundantSizes) != 0 {
+		t.Fatalf("Unexpected analysis: %+v", analysis)
+	}
+}
+
+func TestAnalyseProposedPackSet(t *testing.T) {
+	resp, err := http.Get(hostname + "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/analysis?package_sizes=250,400&from=1&to=2000")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var analysis server.AnalysePackSetResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&analysis)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if analysis.GreatestCommonDivisor != 50 || analysis.FrobeniusNumber == nil || *analysis.FrobeniusNumber != 1350 || analysis.To != 2000 {
+		t.Fatalf("Unexpected analysis: %+v", analysis)
+	}
+}
+
+func TestAnalysePackSetInvalidRange(t *testing.T) {
+	resp, err := http.Get(hostname + "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/analysis?from=10&to=5")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status Bad Request, got %d", resp.StatusCode)
+	}
+}
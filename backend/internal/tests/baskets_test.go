@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"testing"
+)
+
+func TestCalculateBasket(t *testing.T) {
+	body := `{"lines":[{"product_id":"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d","units":12001},{"product_id":"some-prod-id","units":1}]}`
+	resp, err := http.Post(hostname+"/v1/baskets/calculate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var basket server.CalculateBasketResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&basket)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+
+	if len(basket.Lines) != 2 || basket.Lines[0].Error != nil || basket.Lines[0].Items != 12250 {
+		t.Fatalf("Unexpected first line: %+v", basket.Lines)
+	}
+	if basket.Lines[1].Error == nil || basket.Lines[1].Error.Status != http.StatusNotFound {
+		t.Fatalf("Expected the second line to be not found, got %+v", basket.Lines[1])
+	}
+	if basket.Items != 12250 || basket.Packs != 4 || basket.Overfill != 249 || basket.FailedLines != 1 {
+		t.Fatalf("Unexpected totals: %+v", basket)
+	}
+}
+
+func TestCalculateEmptyBasket(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/baskets/calculate", "application/json", bytes.NewBufferString(`{"lines":[]}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+}
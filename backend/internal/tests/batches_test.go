@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gymshark-interview/internal/server"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestCalculatePackagesBatch(t *testing.T) {
+	body := `{"quantities":[251,12001,0]}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var batch server.CalculateBatchResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&batch)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+
+	if len(batch.Results) != 3 || batch.Solver != "dp" {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+	if batch.Results[0].Units != 251 || batch.Results[0].Items != 500 || batch.Results[0].Packs != 1 {
+		t.Fatalf("Unexpected first result: %+v", batch.Results[0])
+	}
+	if batch.Results[1].Units != 12001 || batch.Results[1].Items != 12250 || batch.Results[1].Overfill != 249 {
+		t.Fatalf("Unexpected second result: %+v", batch.Results[1])
+	}
+	if batch.Results[2].Error == nil || batch.Results[2].Error.Status != http.StatusBadRequest {
+		t.Fatalf("Expected the third result to be a bad request, got %+v", batch.Results[2])
+	}
+}
+
+func TestCalculatePackagesBatchRange(t *testing.T) {
+	body := `{"range":{"from":1000,"to":2000,"step":250}}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var batch server.CalculateBatchResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&batch)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+
+	want := []int{1000, 1250, 1500, 1750, 2000}
+	if len(batch.Results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(batch.Results))
+	}
+	for i, units := range want {
+		if batch.Results[i].Units != units || batch.Results[i].Overfill != 0 {
+			t.Fatalf("Unexpected result %d: %+v", i, batch.Results[i])
+		}
+	}
+}
+
+// given a range ending at the maximum int - test it's expanded without wrapping around
+func TestCalculatePackagesBatchRangeToMaxInt(t *testing.T) {
+	body := fmt.Sprintf(`{"range":{"from":%d,"to":%d}}`, math.MaxInt-2, math.MaxInt)
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var batch server.CalculateBatchResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&batch)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if len(batch.Results) != 3 || batch.Results[2].Units != math.MaxInt {
+		t.Fatalf("Unexpected batch: %+v", batch)
+	}
+}
+
+func TestCalculatePackagesBatchWithoutQuantities(t *testing.T) {
+	for _, body := range []string{`{}`, `{"quantities":[1],"range":{"from":1,"to":2}}`, `{"range":{"from":2,"to":1}}`} {
+		resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected status Bad Request, got %d", body, resp.StatusCode)
+		}
+	}
+}
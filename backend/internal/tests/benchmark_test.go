@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"gymshark-interview/database"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/service"
+	"gymshark-interview/internal/storage"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const exampleProductID = "0196b5d3-c52c-7e50-ac45-f83b35ee9e3d"
+
+// benchmarkStorage returns the storage of an sqlite file with the example product, like the server runs on.
+func benchmarkStorage(b *testing.B) *storage.Storage {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: filepath.Join(b.TempDir(), "bench.db"), Seed: true})
+	if err != nil {
+		b.Fatalf("Failed opening database: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return storage.New(db)
+}
+
+// calculateInParallel calculates orders of the example product from b.RunParallel goroutines, one per -cpu.
+func calculateInParallel(b *testing.B, packages *service.Packages) {
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		units := 1
+		for pb.Next() {
+			if _, err := packages.CalculatePackages(context.Background(), exampleProductID, units, service.CalculateOptions{}); err != nil {
+				b.Errorf("Failed calculating %d units: %v", units, err)
+				return
+			}
+			units = units%20_000 + 251
+		}
+	})
+}
+
+// Run with -cpu 1,2,4,8: the calculations only read the catalog, so their throughput scales with the cores.
+func BenchmarkCalculatePackages(b *testing.B) {
+	calculateInParallel(b, service.NewPackageService(benchmarkStorage(b)))
+}
+
+// with the catalog cached, the calculations don't query the database and the solver is all that's left
+func BenchmarkCalculatePackagesCached(b *testing.B) {
+	calculateInParallel(b, service.NewPackageService(storage.NewCache(benchmarkStorage(b), time.Minute)))
+}
+
+// the calculations carry on while the stock of another product is changed non stop
+func BenchmarkCalculatePackagesWhileWriting(b *testing.B) {
+	repo := benchmarkStorage(b)
+	packages := service.NewPackageService(repo)
+	product, err := service.NewProductService(repo).Create(context.Background(), model.Product{
+		Name:         "Benchmark Writes",
+		PackageSizes: []int{100},
+	})
+	if err != nil {
+		b.Fatalf("Failed creating product: %v", err)
+	}
+	stock := 0
+	if _, err = packages.SetPackageSizeStock(context.Background(), product.ID, 100, &stock, 0); err != nil {
+		b.Fatalf("Failed tracking stock: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if _, err := packages.AdjustPackageSizeStock(ctx, product.ID, 100, 1, 0); err != nil && ctx.Err() == nil {
+				b.Errorf("Failed adjusting stock: %v", err)
+				return
+			}
+		}
+	}()
+	calculateInParallel(b, packages)
+	b.StopTimer()
+	cancel()
+	<-done
+}
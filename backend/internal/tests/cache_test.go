@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/internal/service"
+	"gymshark-interview/internal/storage"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCatalogCacheHitsAndMisses(t *testing.T) {
+	backend := storage.NewMemory()
+	cache := storage.NewCache(backend, time.Minute)
+	packages := service.NewPackageService(cache)
+	product := createStorageProduct(t, cache, "Cached Product", 250, 500)
+
+	for range 3 {
+		if _, err := packages.CalculatePackages(context.TODO(), product.ID, 251, service.CalculateOptions{}); err != nil {
+			t.Fatalf("Failed calculating packages: %v", err)
+		}
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 2, Misses: 1}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+
+	// a change through the service drops the cached product, so the service reads the changed one back
+	if _, err := packages.AddPackageSize(context.TODO(), product.ID, 1000, nil, 0); err != nil {
+		t.Fatalf("Failed adding package size: %v", err)
+	}
+	got := getStorageProduct(t, cache, product.ID)
+	if !slices.Equal(got.PackageSizes, []int{250, 500, 1000}) || got.Version != 2 {
+		t.Fatalf("Unexpected product: %+v", got)
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 3, Misses: 2}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// given a change made around the cache, eg. by another server - test the cached product is served until it expires
+func TestCatalogCacheExpires(t *testing.T) {
+	backend := storage.NewMemory()
+	cache := storage.NewCache(backend, 50*time.Millisecond)
+	product := createStorageProduct(t, cache, "Cached Product", 250)
+	getStorageProduct(t, cache, product.ID)
+
+	if err := backend.AddPackageSize(context.TODO(), product.ID, 500, nil, 0); err != nil {
+		t.Fatalf("Failed adding package size: %v", err)
+	}
+	if got := getStorageProduct(t, cache, product.ID); !slices.Equal(got.PackageSizes, []int{250}) {
+		t.Fatalf("Expected the cached product, got %+v", got)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if got := getStorageProduct(t, cache, product.ID); !slices.Equal(got.PackageSizes, []int{250, 500}) {
+		t.Fatalf("Expected the changed product, got %+v", got)
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 1, Misses: 2}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// given a product deleted around the cache - test its expired entry is removed when it's read again
+func TestCatalogCacheRemovesExpired(t *testing.T) {
+	backend := storage.NewMemory()
+	cache := storage.NewCache(backend, 50*time.Millisecond)
+	product := createStorageProduct(t, cache, "Cached Product", 250)
+	getStorageProduct(t, cache, product.ID)
+	if cache.Len() != 1 {
+		t.Fatalf("Expected the product cached, got %d products", cache.Len())
+	}
+
+	if err := backend.DeleteProduct(context.TODO(), product.ID, 0); err != nil {
+		t.Fatalf("Failed deleting product: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := cache.GetProductWithPackageSizes(context.TODO(), product.ID); !errors.Is(err, storage.ErrProductNotFound) {
+		t.Fatalf("Expected %v, got %v", storage.ErrProductNotFound, err)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("Expected the expired product removed, got %d products", cache.Len())
+	}
+}
+
+// given products never read again - test sweeping deletes the expired ones only
+func TestCatalogCacheSweepsExpired(t *testing.T) {
+	cache := storage.NewCache(storage.NewMemory(), 50*time.Millisecond)
+	expired := createStorageProduct(t, cache, "Expired Product", 250)
+	getStorageProduct(t, cache, expired.ID)
+	time.Sleep(60 * time.Millisecond)
+	fresh := createStorageProduct(t, cache, "Fresh Product", 250)
+	getStorageProduct(t, cache, fresh.ID)
+
+	if swept := cache.Sweep(); swept != 1 {
+		t.Fatalf("Expected 1 product swept, got %d", swept)
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("Expected the fresh product kept, got %d products", cache.Len())
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 0, Misses: 2}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// given a product read from the cache - test changing it doesn't change the cached one
+func TestCatalogCacheReturnsCopies(t *testing.T) {
+	cache := storage.NewCache(storage.NewMemory(), time.Minute)
+	product := createStorageProduct(t, cache, "Cached Product", 250, 500)
+
+	got := getStorageProduct(t, cache, product.ID)
+	got.PackageSizes[0] = 1
+	got = getStorageProduct(t, cache, product.ID)
+	got.PackageSizes[0] = 2
+	if got = getStorageProduct(t, cache, product.ID); !slices.Equal(got.PackageSizes, []int{250, 500}) {
+		t.Fatalf("Unexpected package sizes: %v", got.PackageSizes)
+	}
+}
+
+// given a basket of cached and uncached products - test only the uncached ones are read, in a single call
+func TestCatalogCacheGetProducts(t *testing.T) {
+	cache := storage.NewCache(storage.NewMemory(), time.Minute)
+	first := createStorageProduct(t, cache, "First", 250)
+	second := createStorageProduct(t, cache, "Second", 500)
+	getStorageProduct(t, cache, second.ID)
+
+	products, err := cache.GetProductsWithPackageSizes(context.TODO(), []string{second.ID, first.ID, "missing"})
+	if err != nil {
+		t.Fatalf("Failed getting products: %v", err)
+	}
+	if len(products) != 2 || products[0].ID != first.ID || products[1].ID != second.ID {
+		t.Fatalf("Unexpected products: %+v", products)
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 1, Misses: 3}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+
+	if _, err = cache.GetProductsWithPackageSizes(context.TODO(), []string{first.ID, second.ID}); err != nil {
+		t.Fatalf("Failed getting products: %v", err)
+	}
+	if stats := cache.Stats(); stats != (storage.CacheStats{Hits: 3, Misses: 3}) {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"gymshark-interview/internal/server"
 	"net/http"
+	"slices"
+	"strconv"
 	"testing"
 )
 
@@ -60,3 +62,198 @@ func TestCalculatePackageForProductWithPackageSizes(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculatePackageWithSolver(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?solver=branch-and-bound", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if calculateResponse.Solver != "branch-and-bound" {
+		t.Fatalf("Expected solver branch-and-bound, got %s", calculateResponse.Solver)
+	}
+}
+
+func TestCalculatePackageWithUnknownSolver(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?solver=simplex", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+}
+
+func TestCalculatePackageWithObjective(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?objective=packs-first", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	want := []server.PackageResponseBody{{Amount: 3, Size: 5000}}
+	if calculateResponse.Objective != "packs-first" || !slices.Equal(want, calculateResponse.Packages) {
+		t.Fatalf("Unexpected response: want %v got %v", want, calculateResponse)
+	}
+}
+
+func TestCalculatePackageWithExactObjective(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?objective=exact", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+}
+
+func TestCalculatePackageWithAlternatives(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/501?alternatives=3", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+
+	want := []server.AlternativeResponseBody{
+		{Packages: []server.PackageResponseBody{{Amount: 1, Size: 250}, {Amount: 1, Size: 500}}, Items: 750, Packs: 2, Overfill: 249},
+		{Packages: []server.PackageResponseBody{{Amount: 3, Size: 250}}, Items: 750, Packs: 3, Overfill: 249},
+		{Packages: []server.PackageResponseBody{{Amount: 1, Size: 1000}}, Items: 1000, Packs: 1, Overfill: 499},
+	}
+	if len(calculateResponse.Alternatives) != len(want) {
+		t.Fatalf("Unexpected response: want %v got %v", want, calculateResponse.Alternatives)
+	}
+	for i, alternative := range calculateResponse.Alternatives {
+		if !slices.Equal(want[i].Packages, alternative.Packages) || want[i].Items != alternative.Items ||
+			want[i].Packs != alternative.Packs || want[i].Overfill != alternative.Overfill {
+			t.Fatalf("Unexpected alternative %d: want %v got %v", i, want[i], alternative)
+		}
+	}
+}
+
+func TestCalculatePackageWithExplanation(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?explain=true", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+
+	explanation := calculateResponse.Explanation
+	if explanation == nil || !explanation.ResidueShortcut || len(explanation.Rejected) == 0 {
+		t.Fatalf("Unexpected explanation: %+v", explanation)
+	}
+	whole := explanation.Rejected[0]
+	if whole.Rule != "whole-packs" || whole.PartialItems != 1 || whole.Items != 12001 || whole.Overfill != 0 {
+		t.Fatalf("Unexpected whole packs rejection: %+v", whole)
+	}
+	threePacks := explanation.Rejected[len(explanation.Rejected)-1]
+	want := []server.PackageResponseBody{{Amount: 3, Size: 5000}}
+	if threePacks.Rule != "fewest-items" || !slices.Equal(want, threePacks.Packages) || threePacks.Overfill != 2999 {
+		t.Fatalf("Unexpected fewest items rejection: %+v", threePacks)
+	}
+}
+
+// setPackageSizePrice sets the price of a package size of the example product, an empty body clears it
+func setPackageSizePrice(t *testing.T, size int, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/packageSizes/"+strconv.Itoa(size), bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func TestCalculatePackageWithCostObjective(t *testing.T) {
+	for size, body := range map[int]string{250: `{"unit_cost":100,"currency":"GBP"}`, 5000: `{"unit_cost":1000,"currency":"GBP"}`} {
+		resp := setPackageSizePrice(t, size, body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+		}
+		t.Cleanup(func() { setPackageSizePrice(t, size, `{}`).Body.Close() })
+	}
+
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/251?objective=cost", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	want := []server.PackageResponseBody{{Amount: 2, Size: 250}}
+	if !slices.Equal(want, calculateResponse.Packages) || calculateResponse.TotalCost == nil ||
+		*calculateResponse.TotalCost != 200 || calculateResponse.Currency != "GBP" {
+		t.Fatalf("Unexpected response: want %v costing 200 GBP got %v", want, calculateResponse)
+	}
+}
+
+func TestSetPriceOfInexistentPackageSize(t *testing.T) {
+	resp := setPackageSizePrice(t, 123, `{"unit_cost":100,"currency":"GBP"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status Not Found, got %d", resp.StatusCode)
+	}
+}
+
+// given an unknown solver - test the product isn't created
+func TestCreateProductWithUnknownSolver(t *testing.T) {
+	body := `{"name":"Unknown Solver Product","package_sizes":[100],"solver":"simplex"}`
+	resp, err := http.Post(hostname+"/v1/products", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gymshark-interview/database"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// given an sqlite file - test its data survives opening it again, with the migrations and seeds applied once
+func TestDatabaseFileSurvivesReopen(t *testing.T) {
+	cfg := database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db"), Seed: true}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	created, err := storage.New(db).CreateProduct(context.TODO(), model.Product{Name: "Durable Product", PackageSizes: []int{10}})
+	if err != nil {
+		t.Fatalf("Failed creating product: %v", err)
+	}
+	db.Close()
+
+	db, err = database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed opening database again: %v", err)
+	}
+	defer db.Close()
+	products, err := storage.New(db).ListProducts(context.TODO(), model.ProductQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed listing products: %v", err)
+	}
+	if len(products) != 2 || products[0].ID != "0196b5d3-c52c-7e50-ac45-f83b35ee9e3d" || products[1].ID != created.ID {
+		t.Fatalf("Unexpected products: %+v", products)
+	}
+}
+
+func TestDatabaseWithoutSeed(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	defer db.Close()
+	products, err := storage.New(db).ListProducts(context.TODO(), model.ProductQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed listing products: %v", err)
+	}
+	if len(products) != 0 {
+		t.Fatalf("Unexpected products: %+v", products)
+	}
+}
+
+func TestDatabaseWithUnsupportedDriver(t *testing.T) {
+	if _, err := database.Open(database.Config{Driver: "oracle"}); !errors.Is(err, database.ErrUnsupportedDriver) {
+		t.Fatalf("Expected unsupported driver, got %v", err)
+	}
+}
+
+func TestDatabaseFileUsesWAL(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	defer db.Close()
+	var mode string
+	if err = db.Get(&mode, "PRAGMA journal_mode"); err != nil {
+		t.Fatalf("Failed getting journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Fatalf("Unexpected journal mode: %s", mode)
+	}
+}
+
+// given an sqlite file opened twice, like by two servers - test changes through both wait for the write lock
+// rather than failing with the database busy
+func TestDatabaseFileConcurrentWriters(t *testing.T) {
+	cfg := database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")}
+	first, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	defer first.Close()
+	second, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed opening database again: %v", err)
+	}
+	defer second.Close()
+
+	var timeout int
+	if err = second.Get(&timeout, "PRAGMA busy_timeout"); err != nil {
+		t.Fatalf("Failed getting busy timeout: %v", err)
+	}
+	if timeout <= 0 {
+		t.Fatalf("Unexpected busy timeout: %d", timeout)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*8*25)
+	for d, db := range []*sqlx.DB{first, second} {
+		repo := storage.New(db)
+		for w := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 25 {
+					name := fmt.Sprintf("Concurrent Product %d-%d-%d", d, w, i)
+					_, err := repo.CreateProduct(context.TODO(), model.Product{Name: name, PackageSizes: []int{10, 20}})
+					errs <- err
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent write failed: %v", err)
+		}
+	}
+
+	var products int
+	if err = first.Get(&products, "SELECT COUNT(*) FROM products"); err != nil {
+		t.Fatalf("Failed counting products: %v", err)
+	}
+	if products != 2*8*25 {
+		t.Fatalf("Expected %d products, got %d", 2*8*25, products)
+	}
+}
+
+// given concurrent changes and reads of a product in an sqlite file - test no change is lost or fails on a lock
+func TestDatabaseFileConcurrentChanges(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	defer db.Close()
+	repo := storage.New(db)
+	product, err := repo.CreateProduct(context.TODO(), model.Product{Name: "Concurrent Product", PackageSizes: []int{10}})
+	if err != nil {
+		t.Fatalf("Failed creating product: %v", err)
+	}
+	stock := 0
+	if err = repo.SetPackageSizeStock(context.TODO(), product.ID, 10, &stock, 0); err != nil {
+		t.Fatalf("Failed tracking stock: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16*25)
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 25 {
+				errs <- repo.AdjustPackageSizeStock(context.TODO(), product.ID, 10, 1, 0)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 25 {
+				_, err := repo.GetProductWithPackageSizes(context.TODO(), product.ID)
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent access failed: %v", err)
+		}
+	}
+
+	got, err := repo.GetProductWithPackageSizes(context.TODO(), product.ID)
+	if err != nil {
+		t.Fatalf("Failed getting product: %v", err)
+	}
+	if len(got.PackageStock) != 1 || got.PackageStock[0].Available != 200 || got.Version != 202 {
+		t.Fatalf("Unexpected product: %+v", got)
+	}
+}
+
+// given a product with package sizes and history in sqlite - test deleting it deletes their rows, like Postgres
+func TestDatabaseDeleteProductCascades(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed opening database: %v", err)
+	}
+	defer db.Close()
+	repo := storage.New(db)
+	product, err := repo.CreateProduct(context.TODO(), model.Product{Name: "Deleted Product", PackageSizes: []int{10, 20}})
+	if err != nil {
+		t.Fatalf("Failed creating product: %v", err)
+	}
+	if err = repo.SetOrderHistory(context.TODO(), product.ID, []int{5, 15}); err != nil {
+		t.Fatalf("Failed setting history: %v", err)
+	}
+	if err = repo.DeleteProduct(context.TODO(), product.ID, 0); err != nil {
+		t.Fatalf("Failed deleting product: %v", err)
+	}
+
+	var rows int
+	err = db.Get(&rows, "SELECT (SELECT COUNT(*) FROM package_sizes) + (SELECT COUNT(*) FROM order_history)")
+	if err != nil {
+		t.Fatalf("Failed counting rows: %v", err)
+	}
+	if rows != 0 {
+		t.Fatalf("Expected no rows left, got %d", rows)
+	}
+}
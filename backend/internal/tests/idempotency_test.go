@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func postWithIdempotencyKey(t *testing.T, path, key, body string) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodPost, hostname+path, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	res, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed reading response: %v", err)
+	}
+	return resp, res
+}
+
+// given a retried request - test the original response is replayed instead of a constraint violation
+func TestAddPackageSizeRetriedWithIdempotencyKey(t *testing.T) {
+	path := "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/packageSizes/750"
+	resp, first := postWithIdempotencyKey(t, path, "add-750", "")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	t.Cleanup(func() {
+		req, _ := http.NewRequest(http.MethodDelete, hostname+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+
+	resp, replayed := postWithIdempotencyKey(t, path, "add-750", "")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Idempotent-Replayed") != "true" || !bytes.Equal(first, replayed) {
+		t.Fatalf("Expected the first response replayed, got %s", replayed)
+	}
+	if resp.Header.Get("ETag") == "" || resp.Header.Get("ETag") != etag {
+		t.Fatalf("Expected the first response replayed with its ETag %s, got %s", etag, resp.Header.Get("ETag"))
+	}
+	if !strings.Contains(resp.Header.Get("Access-Control-Expose-Headers"), "Idempotent-Replayed") {
+		t.Fatalf("Expected browsers allowed to read the replay header, got %q", resp.Header.Get("Access-Control-Expose-Headers"))
+	}
+
+	resp, _ = postWithIdempotencyKey(t, path, "add-750-again", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status BadRequest without replay, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	body := `{"product_id":"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d","units":%d}`
+	resp, _ := postWithIdempotencyKey(t, "/v1/orders", "order-1", fmt.Sprintf(body, 250))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+
+	location := resp.Header.Get("Location")
+	resp, _ = postWithIdempotencyKey(t, "/v1/orders", "order-1", fmt.Sprintf(body, 250))
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != location {
+		t.Fatalf("Expected the response replayed with its location, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, _ = postWithIdempotencyKey(t, "/v1/orders", "order-1", fmt.Sprintf(body, 500))
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status Conflict, got %d", resp.StatusCode)
+	}
+}
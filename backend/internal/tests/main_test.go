@@ -2,50 +2,77 @@ package tests
 
 import (
 	"context"
-	"fmt"
-	"gymshark-interview/database/migrations"
+	"errors"
+	"gymshark-interview/database"
 	"gymshark-interview/internal/server"
 	"gymshark-interview/internal/service"
 	"gymshark-interview/internal/storage"
 	"log"
+	"net"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 
-	_ "github.com/glebarez/go-sqlite"
 	"github.com/jmoiron/sqlx"
-	migrate "github.com/rubenv/sql-migrate"
 )
 
 var hostname string
 
 func TestMain(m *testing.M) {
-	// start in-memory sqlite with empty db
-	db, err := sqlx.Open("sqlite", ":memory:")
-	if err != nil {
-		log.Fatal(err)
+	// in-memory sqlite with the example product, unless TEST_DATABASE_DRIVER and TEST_DATABASE_DSN select a fresh
+	// database, eg. postgres in a container
+	cfg := database.Config{Driver: "sqlite", DSN: ":memory:", Seed: true}
+	if driver := os.Getenv("TEST_DATABASE_DRIVER"); driver != "" {
+		cfg.Driver, cfg.DSN = driver, os.Getenv("TEST_DATABASE_DSN")
 	}
+	db := waitForDatabase(cfg)
 	defer db.Close()
-
-	// run migrations + seeds
-	mg := migrations.GetMigrationSource()
-	migrate.SetTable("migrations")
-	_, err = migrate.Exec(db.DB, "sqlite3", mg, migrate.Up)
-	if err != nil {
-		log.Fatal(fmt.Errorf("migrations failed: %w", err))
-	}
-	// init deps + server
-	repo := storage.New(db)
+	// init deps + server, with the catalog cached like the server runs
+	repo := storage.NewCache(storage.New(db), time.Minute)
 	packageService := service.NewPackageService(repo)
 	productService := service.NewProductService(repo)
+	orderService := service.NewOrderService(repo)
+	idempotencyService := service.NewIdempotencyService(repo, time.Hour)
 
 	port := 3000
-	server := server.New(port, productService, packageService)
+	server := server.New(port, productService, packageService, orderService, idempotencyService)
 
 	hostname = "http://localhost:" + strconv.Itoa(port)
 
 	go server.Start()
+	waitForServer(port)
 
 	_ = m.Run()
 
 	_ = server.Shutdown(context.Background())
 }
+
+// waitForDatabase opens the database, waiting for a container that is still starting to accept connections
+func waitForDatabase(cfg database.Config) *sqlx.DB {
+	var err error
+	for range 50 {
+		var db *sqlx.DB
+		if db, err = database.Open(cfg); err == nil {
+			return db
+		} else if errors.Is(err, database.ErrUnsupportedDriver) {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	log.Fatal(err)
+	return nil
+}
+
+// waitForServer blocks until the server accepts connections on the given port
+func waitForServer(port int) {
+	for range 50 {
+		conn, err := net.Dial("tcp", "localhost:"+strconv.Itoa(port))
+		if err == nil {
+			_ = conn.Close()
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Fatal("server did not start")
+}
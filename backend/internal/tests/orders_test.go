@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func createOrder(t *testing.T, body string) server.OrderResponseBody {
+	resp, err := http.Post(hostname+"/v1/orders", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+	var order server.OrderResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if location := resp.Header.Get("Location"); location != "/v1/orders/"+order.ID {
+		t.Fatalf("Unexpected location: %s", location)
+	}
+	return order
+}
+
+func getOrder(t *testing.T, id string) server.OrderResponseBody {
+	resp, err := http.Get(hostname + "/v1/orders/" + id)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var order server.OrderResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	return order
+}
+
+func setOrderState(t *testing.T, id string, state string) *http.Response {
+	body := `{"state":"` + state + `"}`
+	req, err := http.NewRequest(http.MethodPut, hostname+"/v1/orders/"+id+"/state", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+// given an order - test catalog changes don't rewrite its snapshot
+func TestCreateOrderSnapshot(t *testing.T) {
+	order := createOrder(t, `{"product_id":"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d","units":12001}`)
+	if order.State != "quoted" || order.Units != 12001 {
+		t.Fatalf("Unexpected order: %+v", order)
+	}
+	wantPackages := []server.PackageResponseBody{{Amount: 1, Size: 250}, {Amount: 1, Size: 2000}, {Amount: 2, Size: 5000}}
+	if !slices.Equal(order.Packages, wantPackages) {
+		t.Fatalf("Unexpected packages: %+v", order.Packages)
+	}
+
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/packageSizes/750", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+	t.Cleanup(func() {
+		req, _ := http.NewRequest(http.MethodDelete, hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/packageSizes/750", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+
+	got := getOrder(t, order.ID)
+	if !slices.Equal(got.Packages, wantPackages) {
+		t.Fatalf("Unexpected packages: %+v", got.Packages)
+	}
+	if !slices.Equal(got.PackageSizes, []int{250, 500, 1000, 2000, 5000}) {
+		t.Fatalf("Unexpected package sizes: %v", got.PackageSizes)
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	order := createOrder(t, `{"product_id":"0196b5d3-c52c-7e50-ac45-f83b35ee9e3d","units":501}`)
+
+	for _, state := range []string{"confirmed", "picked"} {
+		resp := setOrderState(t, order.ID, state)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status OK moving to %s, got %d", state, resp.StatusCode)
+		}
+	}
+
+	resp := setOrderState(t, order.ID, "quoted")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status Conflict, got %d", resp.StatusCode)
+	}
+
+	resp = setOrderState(t, order.ID, "cancelled")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(hostname + "/v1/orders?state=cancelled&product_id=0196b5d3-c52c-7e50-ac45-f83b35ee9e3d")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var orders []server.OrderResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&orders); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if !slices.ContainsFunc(orders, func(o server.OrderResponseBody) bool { return o.ID == order.ID }) {
+		t.Fatalf("Cancelled order not listed: %+v", orders)
+	}
+	if slices.ContainsFunc(orders, func(o server.OrderResponseBody) bool { return o.State != "cancelled" }) {
+		t.Fatalf("Unexpected orders listed: %+v", orders)
+	}
+}
+
+func TestGetInexistentOrder(t *testing.T) {
+	resp, err := http.Get(hostname + "/v1/orders/0196b5d3-c52c-7e50-ac45-000000000000")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status NotFound, got %d", resp.StatusCode)
+	}
+}
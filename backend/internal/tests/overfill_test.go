@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"testing"
+)
+
+func setMaxOverfill(t *testing.T, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/maxOverfill", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func TestCalculatePackageOverProductMaxOverfill(t *testing.T) {
+	resp := setMaxOverfill(t, `{"max_overfill":100}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { setMaxOverfill(t, `{}`).Body.Close() })
+	var product server.ProductResponseBody
+	err := json.NewDecoder(resp.Body).Decode(&product)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if product.MaxOverfill == nil || *product.MaxOverfill != 100 || product.MaxOverfillPercent != nil {
+		t.Fatalf("Unexpected product: %+v", product)
+	}
+
+	resp, err = http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+	var apiErr server.NoFeasiblePackingErrorModel
+	err = json.NewDecoder(resp.Body).Decode(&apiErr)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if apiErr.NearestBelow != 12000 || apiErr.NearestAbove != 12250 {
+		t.Fatalf("Unexpected nearest quantities: %+v", apiErr)
+	}
+
+	resp, err = http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12000", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+}
+
+func TestCalculatePackageExactOnly(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/501?exact=true&objective=packs-first", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+	var apiErr server.NoFeasiblePackingErrorModel
+	err = json.NewDecoder(resp.Body).Decode(&apiErr)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if apiErr.NearestBelow != 500 || apiErr.NearestAbove != 750 {
+		t.Fatalf("Unexpected nearest quantities: %+v", apiErr)
+	}
+}
+
+func TestCalculatePackageWithinMaxOverfillPercent(t *testing.T) {
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001?max_overfill_percent=3", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,342 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func listProducts(t *testing.T, query url.Values) server.ListProductsResponseBody {
+	resp, err := http.Get(hostname + "/v1/products?" + query.Encode())
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var page server.ListProductsResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	return page
+}
+
+// listAllProducts follows the cursors of a listing and returns the names of every page.
+func listAllProducts(t *testing.T, query url.Values) [][]string {
+	var pages [][]string
+	for {
+		page := listProducts(t, query)
+		names := []string{}
+		for _, product := range page.Data {
+			names = append(names, product.Name)
+		}
+		pages = append(pages, names)
+		if page.NextCursor == "" {
+			return pages
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+}
+
+// createPagedProducts creates products named with a prefix no other test uses, deleted when the test ends, and
+// returns their IDs.
+func createPagedProducts(t *testing.T, names ...string) []string {
+	var ids []string
+	t.Cleanup(func() {
+		for _, id := range ids {
+			req, _ := http.NewRequest(http.MethodDelete, hostname+"/v1/products/"+id, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+	})
+	for _, name := range names {
+		resp, err := http.Post(hostname+"/v1/products", "application/json", bytes.NewBufferString(`{"name":"`+name+`"}`))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var product server.ProductResponseBody
+		err = json.NewDecoder(resp.Body).Decode(&product)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+		}
+		if err != nil {
+			t.Fatalf("Failed decoding: %v", err)
+		}
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+func TestListProductsPages(t *testing.T) {
+	createPagedProducts(t, "Paged C", "Paged A", "Paged D", "Paged B", "Paged E")
+
+	pages := listAllProducts(t, url.Values{"name_prefix": {"paged"}, "sort": {"name"}, "limit": {"2"}})
+	want := [][]string{{"Paged A", "Paged B"}, {"Paged C", "Paged D"}, {"Paged E"}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Fatalf("Unexpected pages: %v", pages)
+	}
+
+	pages = listAllProducts(t, url.Values{"name_prefix": {"Paged"}, "order": {"desc"}, "limit": {"3"}})
+	want = [][]string{{"Paged E", "Paged B", "Paged D"}, {"Paged A", "Paged C"}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Fatalf("Unexpected pages by creation time: %v", pages)
+	}
+}
+
+func TestListProductsCursorOfAnotherListing(t *testing.T) {
+	createPagedProducts(t, "Paged X", "Paged Y")
+
+	page := listProducts(t, url.Values{"name_prefix": {"Paged"}, "limit": {"1"}})
+	if page.NextCursor == "" {
+		t.Fatalf("Expected a next cursor")
+	}
+
+	resp, err := http.Get(hostname + "/v1/products?sort=name&limit=1&name_prefix=Paged&cursor=" + page.NextCursor)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status BadRequest, got %d", resp.StatusCode)
+	}
+}
+
+func sendJSON(t *testing.T, method, path, body string) *http.Response {
+	req, err := http.NewRequest(method, hostname+path, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func TestUpdateProductAndReplacePackageSizes(t *testing.T) {
+	id := createPagedProducts(t, "Paged Editable")[0]
+
+	resp := sendJSON(t, http.MethodPatch, "/v1/products/"+id, `{"name":"Paged Renamed"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var product server.ProductResponseBody
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if product.ID != id || product.Name != "Paged Renamed" {
+		t.Fatalf("Unexpected product: %+v", product)
+	}
+
+	resp = sendJSON(t, http.MethodPost, "/v1/products/"+id+"/packageSizes/500", `{"unit_cost":499,"currency":"GBP"}`)
+	resp.Body.Close()
+	resp = sendJSON(t, http.MethodPut, "/v1/products/"+id+"/packageSizes", `{"package_sizes":[1000,500,300]}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if !slices.Equal(product.PackageSizes, []int{300, 500, 1000}) {
+		t.Fatalf("Unexpected package sizes: %v", product.PackageSizes)
+	}
+	if len(product.PackagePrices) != 1 || product.PackagePrices[0].Size != 500 {
+		t.Fatalf("Expected the price of the kept size, got %+v", product.PackagePrices)
+	}
+}
+
+func TestUpdateProductToTakenName(t *testing.T) {
+	resp := sendJSON(t, http.MethodPatch, "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d", `{"name":"Product ABC"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK renaming to the same name, got %d", resp.StatusCode)
+	}
+
+	createPagedProducts(t, "Paged Taken")
+	resp = sendJSON(t, http.MethodPatch, "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d", `{"name":"Paged Taken"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status BadRequest, got %d", resp.StatusCode)
+	}
+
+	resp = sendJSON(t, http.MethodPut, "/v1/products/0196b5d3-c52c-7e50-ac45-000000000000/packageSizes", `{"package_sizes":[250]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status NotFound, got %d", resp.StatusCode)
+	}
+}
+
+// given a created product - test the response and its Location carry the persisted product
+func TestCreateAndGetProduct(t *testing.T) {
+	body := `{"name":"Created Product","package_sizes":[100,200],"solver":"greedy","max_overfill":50}`
+	resp, err := http.Post(hostname+"/v1/products", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status Created, got %d", resp.StatusCode)
+	}
+	var created server.ProductResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	t.Cleanup(func() {
+		req, _ := http.NewRequest(http.MethodDelete, hostname+"/v1/products/"+created.ID, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+	if created.ID == "" || created.Name != "Created Product" || created.Solver != "greedy" ||
+		created.MaxOverfill == nil || *created.MaxOverfill != 50 {
+		t.Fatalf("Unexpected created product: %+v", created)
+	}
+	location := resp.Header.Get("Location")
+	if location != "/v1/products/"+created.ID {
+		t.Fatalf("Unexpected location: %s", location)
+	}
+
+	resp, err = http.Get(hostname + location)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var got server.ProductResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	slices.Sort(got.PackageSizes)
+	if got.ID != created.ID || got.Name != created.Name || got.Solver != created.Solver ||
+		!slices.Equal(got.PackageSizes, []int{100, 200}) {
+		t.Fatalf("Unexpected product: %+v", got)
+	}
+}
+
+func TestGetInexistentProduct(t *testing.T) {
+	resp, err := http.Get(hostname + "/v1/products/0196b5d3-c52c-7e50-ac45-000000000000")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status NotFound, got %d", resp.StatusCode)
+	}
+}
+
+func sendJSONIfMatch(t *testing.T, method, path, etag, body string) *http.Response {
+	req, err := http.NewRequest(method, hostname+path, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+// given If-Match lists of ETags - test a change applies when any ETag is the current one, and "*" always matches
+func TestChangeProductWithETagList(t *testing.T) {
+	id := createPagedProducts(t, "Paged Listed")[0]
+	path := "/v1/products/" + id
+
+	// every change that applies renames the product, bumping its version
+	for i, tt := range []struct {
+		ifMatch    string
+		wantStatus int
+	}{
+		{`"3", "4"`, http.StatusPreconditionFailed},
+		{`W/"1"`, http.StatusPreconditionFailed},
+		{`"abc", "1"`, http.StatusOK},
+		{`"1", "2"`, http.StatusOK},
+		{`*`, http.StatusOK},
+		{`"1","2", "3"`, http.StatusPreconditionFailed},
+		{`"1","2", "4"`, http.StatusOK},
+	} {
+		body := fmt.Sprintf(`{"name":"Paged Listed %d"}`, i)
+		resp := sendJSONIfMatch(t, http.MethodPatch, path, tt.ifMatch, body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Fatalf("If-Match %s: expected status %d, got %d", tt.ifMatch, tt.wantStatus, resp.StatusCode)
+		}
+	}
+
+	resp := sendJSONIfMatch(t, http.MethodPatch, "/v1/products/0196b5d3-c52c-7e50-ac45-000000000000", `"1", "2"`, `{"name":"Paged Listed Missing"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status NotFound, got %d", resp.StatusCode)
+	}
+}
+
+// given a product changed by someone else - test changes made with its previous ETag are refused
+func TestChangeProductWithStaleETag(t *testing.T) {
+	id := createPagedProducts(t, "Paged Versioned")[0]
+	path := "/v1/products/" + id
+
+	resp, err := http.Get(hostname + path)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("Unexpected ETag: %s", etag)
+	}
+
+	resp = sendJSONIfMatch(t, http.MethodPut, path+"/packageSizes", etag, `{"package_sizes":[100,200]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	current := resp.Header.Get("ETag")
+	if current != `"2"` {
+		t.Fatalf("Unexpected ETag: %s", current)
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{http.MethodPatch, path, `{"name":"Paged Stale"}`},
+		{http.MethodPost, path + "/packageSizes/300", ""},
+		{http.MethodDelete, path + "/packageSizes/100", ""},
+		{http.MethodPut, path + "/packageSizes/100/stock", `{"available":3}`},
+		{http.MethodDelete, path, ""},
+	} {
+		resp = sendJSONIfMatch(t, tt.method, tt.path, etag, tt.body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Fatalf("Expected status PreconditionFailed for %s %s, got %d", tt.method, tt.path, resp.StatusCode)
+		}
+	}
+
+	resp = sendJSONIfMatch(t, http.MethodPatch, path, current, `{"name":"Paged Versioned Again"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var product server.ProductResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	slices.Sort(product.PackageSizes)
+	if product.Version != 3 || resp.Header.Get("ETag") != `"3"` || !slices.Equal(product.PackageSizes, []int{100, 200}) {
+		t.Fatalf("Unexpected product: %+v, ETag %s", product, resp.Header.Get("ETag"))
+	}
+}
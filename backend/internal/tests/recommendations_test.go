@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRecommendPackSet(t *testing.T) {
+	body := `{"sizes":2,"min_size":100,"quantities":[300,300,700]}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/recommend", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var recommendation server.RecommendPackSetResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&recommendation)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	recommended := recommendation.Recommended
+	if !slices.Equal(recommended.PackageSizes, []int{300, 700}) || recommended.Overfill != 0 || recommended.ExpectedPacks != 1 {
+		t.Fatalf("Unexpected recommendation: %+v", recommendation)
+	}
+	if recommendation.Current.Overfill != 200+200+50 {
+		t.Fatalf("Unexpected current totals: %+v", recommendation.Current)
+	}
+}
+
+func TestRecommendPackSetInvalidConstraints(t *testing.T) {
+	body := `{"sizes":2,"min_size":500,"max_size":250,"quantities":[300]}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/recommend", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status Bad Request, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+const orderHistoryPath = "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/orderHistory"
+
+func setOrderHistory(t *testing.T, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, hostname+orderHistoryPath, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func TestSimulatePackSetOrderHistory(t *testing.T) {
+	resp := setOrderHistory(t, `{"quantities":[12001,251,5000,12001]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { setOrderHistory(t, `{"quantities":[]}`).Body.Close() })
+
+	resp, err := http.Get(hostname + orderHistoryPath)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var history server.OrderHistoryResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&history)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if !slices.Equal(history.Quantities, []int{12001, 251, 5000, 12001}) {
+		t.Fatalf("Unexpected order history: %v", history.Quantities)
+	}
+
+	body := `{"package_sizes":[250,500,1000,2000]}`
+	resp, err = http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/simulate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var simulation server.SimulatePackSetResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&simulation)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if simulation.Orders != 4 || simulation.ItemsDelta != 0 || simulation.PacksDelta != 8 || simulation.OverfillDelta != 0 {
+		t.Fatalf("Unexpected simulation: %+v", simulation)
+	}
+	if len(simulation.Changed) != 2 || simulation.Changed[1].Units != 12001 || simulation.Changed[1].Candidate.Packs != 7 {
+		t.Fatalf("Unexpected changed quantities: %+v", simulation.Changed)
+	}
+}
+
+func TestSimulatePackSetQuantities(t *testing.T) {
+	body := `{"package_sizes":[250,500,750],"quantities":[750,1]}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/simulate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var simulation server.SimulatePackSetResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&simulation)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	if simulation.Orders != 2 || simulation.PacksDelta != -1 || len(simulation.Changed) != 1 || simulation.Changed[0].Units != 750 {
+		t.Fatalf("Unexpected simulation: %+v", simulation)
+	}
+}
+
+func TestSimulatePackSetWithoutOrderHistory(t *testing.T) {
+	body := `{"package_sizes":[250,500]}`
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/simulate", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status Unprocessable Entity, got %d", resp.StatusCode)
+	}
+}
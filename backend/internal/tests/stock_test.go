@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"gymshark-interview/internal/server"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+const stockProductPath = "/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/packageSizes/"
+
+func setPackageSizeStock(t *testing.T, size int, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPut, hostname+stockProductPath+strconv.Itoa(size)+"/stock", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func adjustPackageSizeStock(t *testing.T, size int, delta int) *http.Response {
+	resp, err := http.Post(hostname+stockProductPath+strconv.Itoa(size)+"/stock/adjustments", "application/json",
+		bytes.NewBufferString(`{"delta":`+strconv.Itoa(delta)+`}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+func TestCalculatePackageWithinStock(t *testing.T) {
+	resp := setPackageSizeStock(t, 5000, `{"available":1}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { setPackageSizeStock(t, 5000, `{}`).Body.Close() })
+
+	resp, err := http.Post(hostname+"/v1/products/0196b5d3-c52c-7e50-ac45-f83b35ee9e3d/calculate/12001", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var calculateResponse server.CalculatePackageSizeResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&calculateResponse)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	items := 0
+	for _, pack := range calculateResponse.Packages {
+		items += pack.Amount * pack.Size
+		if pack.Size == 5000 && pack.Amount > 1 {
+			t.Fatalf("Expected at most one 5000 pack, got %v", calculateResponse.Packages)
+		}
+	}
+	if items != 12250 {
+		t.Fatalf("Expected 12250 items, got %v", calculateResponse.Packages)
+	}
+}
+
+func TestAdjustPackageSizeStock(t *testing.T) {
+	resp := setPackageSizeStock(t, 2000, `{"available":2}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { setPackageSizeStock(t, 2000, `{}`).Body.Close() })
+
+	resp = adjustPackageSizeStock(t, 2000, -3)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status Conflict, got %d", resp.StatusCode)
+	}
+
+	resp = adjustPackageSizeStock(t, 2000, -2)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status OK, got %d", resp.StatusCode)
+	}
+	var product server.ProductResponseBody
+	err := json.NewDecoder(resp.Body).Decode(&product)
+	if err != nil {
+		t.Fatalf("Failed decoding: %v", err)
+	}
+	want := []server.PackageStockResponseBody{{Size: 2000, Available: 0}}
+	if len(product.PackageStock) != 1 || product.PackageStock[0] != want[0] {
+		t.Fatalf("Unexpected stock: want %v got %v", want, product.PackageStock)
+	}
+}
+
+func TestAdjustUntrackedPackageSizeStock(t *testing.T) {
+	resp := adjustPackageSizeStock(t, 250, 1)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status Conflict, got %d", resp.StatusCode)
+	}
+}
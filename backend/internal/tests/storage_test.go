@@ -0,0 +1,526 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"gymshark-interview/database"
+	"gymshark-interview/internal/model"
+	"gymshark-interview/internal/storage"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// forEachStorage runs a conformance test against an empty sqlite database, an empty in-memory storage and a cache in
+// front of sqlite, so they are all held to the same behaviour
+func forEachStorage(t *testing.T, test func(t *testing.T, s storage.Backend)) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) storage.Backend
+	}{
+		{name: "sqlite", open: func(t *testing.T) storage.Backend {
+			db, err := database.Open(database.Config{Driver: "sqlite", DSN: ":memory:"})
+			if err != nil {
+				t.Fatalf("Failed opening database: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return storage.New(db)
+		}},
+		{name: "memory", open: func(t *testing.T) storage.Backend {
+			return storage.NewMemory()
+		}},
+		{name: "cached sqlite", open: func(t *testing.T) storage.Backend {
+			db, err := database.Open(database.Config{Driver: "sqlite", DSN: ":memory:"})
+			if err != nil {
+				t.Fatalf("Failed opening database: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return storage.NewCache(storage.New(db), time.Minute)
+		}},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			test(t, backend.open(t))
+		})
+	}
+}
+
+func createStorageProduct(t *testing.T, s storage.Backend, name string, sizes ...int) *model.Product {
+	product, err := s.CreateProduct(context.TODO(), model.Product{Name: name, PackageSizes: sizes})
+	if err != nil {
+		t.Fatalf("Failed creating product %s: %v", name, err)
+	}
+	return product
+}
+
+func getStorageProduct(t *testing.T, s storage.Backend, id string) *model.Product {
+	product, err := s.GetProductWithPackageSizes(context.TODO(), id)
+	if err != nil {
+		t.Fatalf("Failed getting product: %v", err)
+	}
+	return product
+}
+
+func TestStorageCreateProduct(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		items := 10
+		created, err := s.CreateProduct(context.TODO(), model.Product{
+			Name:         "Product",
+			PackageSizes: []int{500, 250},
+			Solver:       "greedy",
+			MaxOverfill:  model.OverfillLimit{Items: &items},
+		})
+		if err != nil {
+			t.Fatalf("Failed creating product: %v", err)
+		}
+		want := model.Product{
+			ID:           created.ID,
+			Name:         "Product",
+			PackageSizes: []int{250, 500},
+			Solver:       "greedy",
+			MaxOverfill:  model.OverfillLimit{Items: &items},
+			Version:      1,
+		}
+		if got := getStorageProduct(t, s, created.ID); !reflect.DeepEqual(*got, want) {
+			t.Fatalf("Unexpected product: %+v", got)
+		}
+
+		empty := createStorageProduct(t, s, "Empty")
+		if got := getStorageProduct(t, s, empty.ID); got.PackageSizes != nil || got.PackagePrices != nil || got.PackageStock != nil {
+			t.Fatalf("Unexpected package sizes: %+v", got)
+		}
+
+		if _, err = s.CreateProduct(context.TODO(), model.Product{Name: "Product"}); !errors.Is(err, storage.ErrConstraintViolation) {
+			t.Fatalf("Expected constraint violation for a taken name, got %v", err)
+		}
+		if _, err = s.CreateProduct(context.TODO(), model.Product{Name: "Twice", PackageSizes: []int{5, 5}}); !errors.Is(err, storage.ErrConstraintViolation) {
+			t.Fatalf("Expected constraint violation for a repeated size, got %v", err)
+		}
+		// the failed product wasn't created, so its name is free
+		createStorageProduct(t, s, "Twice", 5)
+
+		if _, err = s.GetProductWithPackageSizes(context.TODO(), "missing"); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+	})
+}
+
+func TestStorageGetProducts(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		first := createStorageProduct(t, s, "First", 10, 5)
+		second := createStorageProduct(t, s, "Second")
+
+		products, err := s.GetProductsWithPackageSizes(context.TODO(), []string{second.ID, "missing", first.ID, second.ID})
+		if err != nil {
+			t.Fatalf("Failed getting products: %v", err)
+		}
+		if len(products) != 2 || products[0].ID != first.ID || products[1].ID != second.ID ||
+			!slices.Equal(products[0].PackageSizes, []int{5, 10}) {
+			t.Fatalf("Unexpected products: %+v", products)
+		}
+
+		if products, err = s.GetProductsWithPackageSizes(context.TODO(), nil); err != nil || products == nil || len(products) != 0 {
+			t.Fatalf("Unexpected products %+v, error %v", products, err)
+		}
+	})
+}
+
+func TestStorageListProducts(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		ids := map[string]string{}
+		for _, name := range []string{"banana", "Apple", "apricot", "a_b", "axb", "Cherry"} {
+			ids[createStorageProduct(t, s, name, 1).ID] = name
+		}
+		list := func(query model.ProductQuery) []string {
+			products, err := s.ListProducts(context.TODO(), query)
+			if err != nil {
+				t.Fatalf("Failed listing products: %v", err)
+			}
+			names := []string{}
+			for _, p := range products {
+				if !slices.Equal(p.PackageSizes, []int{1}) {
+					t.Fatalf("Unexpected package sizes: %+v", p)
+				}
+				names = append(names, p.Name)
+			}
+			return names
+		}
+
+		tests := []struct {
+			query model.ProductQuery
+			want  []string
+		}{
+			{query: model.ProductQuery{SortBy: model.ProductSortName, Limit: 10}, want: []string{"Apple", "Cherry", "a_b", "apricot", "axb", "banana"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, Descending: true, Limit: 2}, want: []string{"banana", "axb"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, NamePrefix: "AP", Limit: 10}, want: []string{"Apple", "apricot"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, NamePrefix: "a_", Limit: 10}, want: []string{"a_b"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, After: &model.ProductCursor{Name: "apricot"}, Limit: 10}, want: []string{"axb", "banana"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, Descending: true, NamePrefix: "a", After: &model.ProductCursor{Name: "apricot"}, Limit: 10}, want: []string{"a_b", "Apple"}},
+			{query: model.ProductQuery{SortBy: model.ProductSortName, NamePrefix: "z", Limit: 10}, want: []string{}},
+		}
+		for _, tt := range tests {
+			if got := list(tt.query); !slices.Equal(got, tt.want) {
+				t.Fatalf("Unexpected products %v for %+v", got, tt.query)
+			}
+		}
+
+		// ids are v7 uuids, so sorting by id lists the products in the order they were created
+		byID := list(model.ProductQuery{SortBy: model.ProductSortCreated, Limit: 10})
+		if !slices.Equal(byID, []string{"banana", "Apple", "apricot", "a_b", "axb", "Cherry"}) {
+			t.Fatalf("Unexpected products by id: %v", byID)
+		}
+		var afterID string
+		for id, name := range ids {
+			if name == "apricot" {
+				afterID = id
+			}
+		}
+		after := list(model.ProductQuery{SortBy: model.ProductSortCreated, After: &model.ProductCursor{ID: afterID}, Limit: 2})
+		if !slices.Equal(after, []string{"a_b", "axb"}) {
+			t.Fatalf("Unexpected products after id: %v", after)
+		}
+	})
+}
+
+func TestStorageChangeProduct(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		product := createStorageProduct(t, s, "Product", 250)
+		createStorageProduct(t, s, "Taken")
+
+		if err := s.UpdateProductName(context.TODO(), product.ID, "Renamed", 1); err != nil {
+			t.Fatalf("Failed renaming product: %v", err)
+		}
+		if err := s.UpdateProductName(context.TODO(), product.ID, "Taken", 0); !errors.Is(err, storage.ErrConstraintViolation) {
+			t.Fatalf("Expected constraint violation, got %v", err)
+		}
+		if err := s.UpdateProductName(context.TODO(), product.ID, "Again", 1); !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Fatalf("Expected version mismatch, got %v", err)
+		}
+		if err := s.UpdateProductName(context.TODO(), "missing", "Again", 1); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+		// the old name is free again
+		createStorageProduct(t, s, "Product")
+
+		percent := 5
+		if err := s.SetProductMaxOverfill(context.TODO(), product.ID, model.OverfillLimit{Percent: &percent}, 2); err != nil {
+			t.Fatalf("Failed setting max overfill: %v", err)
+		}
+		percent = 50
+		got := getStorageProduct(t, s, product.ID)
+		if got.Name != "Renamed" || got.Version != 3 || got.MaxOverfill.Items != nil || *got.MaxOverfill.Percent != 5 {
+			t.Fatalf("Unexpected product: %+v", got)
+		}
+	})
+}
+
+func TestStoragePackageSizes(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		ctx := context.TODO()
+		product := createStorageProduct(t, s, "Product", 250, 500)
+		price := &model.PackagePrice{UnitCost: 399, Currency: "GBP"}
+		stock := 3
+
+		steps := []struct {
+			name    string
+			change  func() error
+			wantErr error
+		}{
+			{name: "add", change: func() error { return s.AddPackageSize(ctx, product.ID, 1000, price, 1) }},
+			{name: "add twice", change: func() error { return s.AddPackageSize(ctx, product.ID, 1000, nil, 0) }, wantErr: storage.ErrConstraintViolation},
+			{name: "add to missing", change: func() error { return s.AddPackageSize(ctx, "missing", 1000, nil, 0) }, wantErr: storage.ErrProductNotFound},
+			{name: "price", change: func() error { return s.SetPackageSizePrice(ctx, product.ID, 250, price, 2) }},
+			{name: "price missing", change: func() error { return s.SetPackageSizePrice(ctx, product.ID, 750, price, 0) }, wantErr: storage.ErrPackageSizeNotFound},
+			{name: "stock", change: func() error { return s.SetPackageSizeStock(ctx, product.ID, 500, &stock, 3) }},
+			{name: "stale stock", change: func() error { return s.SetPackageSizeStock(ctx, product.ID, 500, &stock, 3) }, wantErr: storage.ErrVersionMismatch},
+			{name: "adjust", change: func() error { return s.AdjustPackageSizeStock(ctx, product.ID, 500, -2, 4) }},
+			{name: "adjust below zero", change: func() error { return s.AdjustPackageSizeStock(ctx, product.ID, 500, -2, 0) }, wantErr: storage.ErrNegativeStock},
+			{name: "adjust untracked", change: func() error { return s.AdjustPackageSizeStock(ctx, product.ID, 250, 1, 0) }, wantErr: storage.ErrStockNotTracked},
+			{name: "adjust missing", change: func() error { return s.AdjustPackageSizeStock(ctx, product.ID, 750, 1, 0) }, wantErr: storage.ErrPackageSizeNotFound},
+			{name: "remove missing", change: func() error { return s.RemovePackageSize(ctx, product.ID, 750, 5) }},
+			{name: "replace with duplicates", change: func() error { return s.ReplacePackageSizes(ctx, product.ID, []int{100, 100, 250}, 5) }, wantErr: storage.ErrConstraintViolation},
+			{name: "replace", change: func() error { return s.ReplacePackageSizes(ctx, product.ID, []int{100, 250, 500}, 5) }},
+		}
+		for _, step := range steps {
+			if err := step.change(); !errors.Is(err, step.wantErr) {
+				t.Fatalf("Step %s: expected %v, got %v", step.name, step.wantErr, err)
+			}
+		}
+
+		// failed changes and removing a missing size don't bump the version
+		want := model.Product{
+			ID:            product.ID,
+			Name:          "Product",
+			PackageSizes:  []int{100, 250, 500},
+			PackagePrices: []model.PackagePrice{{Size: 250, UnitCost: 399, Currency: "GBP"}},
+			PackageStock:  []model.PackageStock{{Size: 500, Available: 1}},
+			Version:       6,
+		}
+		if got := getStorageProduct(t, s, product.ID); !reflect.DeepEqual(*got, want) {
+			t.Fatalf("Unexpected product: %+v", got)
+		}
+
+		if err := s.RemovePackageSize(ctx, product.ID, 100, 6); err != nil {
+			t.Fatalf("Failed removing package size: %v", err)
+		}
+		if err := s.SetPackageSizePrice(ctx, product.ID, 250, nil, 0); err != nil {
+			t.Fatalf("Failed clearing price: %v", err)
+		}
+		if err := s.SetPackageSizeStock(ctx, product.ID, 500, nil, 0); err != nil {
+			t.Fatalf("Failed clearing stock: %v", err)
+		}
+		got := getStorageProduct(t, s, product.ID)
+		if !slices.Equal(got.PackageSizes, []int{250, 500}) || got.PackagePrices != nil || got.PackageStock != nil || got.Version != 9 {
+			t.Fatalf("Unexpected product: %+v", got)
+		}
+	})
+}
+
+func TestStorageOrderHistory(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		product := createStorageProduct(t, s, "Product", 250)
+
+		history, err := s.GetOrderHistory(context.TODO(), product.ID)
+		if err != nil || history == nil || len(history) != 0 {
+			t.Fatalf("Unexpected history %v, error %v", history, err)
+		}
+		if err = s.SetOrderHistory(context.TODO(), product.ID, []int{30, 10, 20}); err != nil {
+			t.Fatalf("Failed setting history: %v", err)
+		}
+		if history, err = s.GetOrderHistory(context.TODO(), product.ID); err != nil || !slices.Equal(history, []int{30, 10, 20}) {
+			t.Fatalf("Unexpected history %v, error %v", history, err)
+		}
+		if got := getStorageProduct(t, s, product.ID); got.Version != 1 {
+			t.Fatalf("Expected the history not to change the version, got %d", got.Version)
+		}
+
+		if err = s.SetOrderHistory(context.TODO(), "missing", []int{1}); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+		if _, err = s.GetOrderHistory(context.TODO(), "missing"); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+	})
+}
+
+// given a product with package sizes and history - test deleting it deletes them too, and frees its name
+func TestStorageDeleteProduct(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		product := createStorageProduct(t, s, "Product", 250, 500)
+		if err := s.SetOrderHistory(context.TODO(), product.ID, []int{100}); err != nil {
+			t.Fatalf("Failed setting history: %v", err)
+		}
+
+		if err := s.DeleteProduct(context.TODO(), product.ID, 2); !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Fatalf("Expected version mismatch, got %v", err)
+		}
+		if err := s.DeleteProduct(context.TODO(), product.ID, 1); err != nil {
+			t.Fatalf("Failed deleting product: %v", err)
+		}
+		if _, err := s.GetProductWithPackageSizes(context.TODO(), product.ID); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+		if _, err := s.GetOrderHistory(context.TODO(), product.ID); !errors.Is(err, storage.ErrProductNotFound) {
+			t.Fatalf("Expected product not found, got %v", err)
+		}
+		if err := s.DeleteProduct(context.TODO(), product.ID, 0); err != nil {
+			t.Fatalf("Expected deleting a missing product to succeed, got %v", err)
+		}
+		if err := s.DeleteProduct(context.TODO(), product.ID, 1); !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Fatalf("Expected version mismatch, got %v", err)
+		}
+
+		again := createStorageProduct(t, s, "Product", 250)
+		if got := getStorageProduct(t, s, again.ID); !slices.Equal(got.PackageSizes, []int{250}) {
+			t.Fatalf("Unexpected package sizes: %v", got.PackageSizes)
+		}
+	})
+}
+
+func TestStorageOrders(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		cost := int64(1200)
+		first, err := s.CreateOrder(context.TODO(), model.Order{
+			ProductID: "product",
+			Units:     501,
+			State:     "quoted",
+			Package: model.Package{
+				PackageUnits: []model.PackageUnit{{Size: 500, Amount: 1}, {Size: 250, Amount: 1}},
+				Solver:       "dp",
+				Objective:    "items",
+				TotalCost:    &cost,
+				Currency:     "GBP",
+			},
+			PackageSizes: []int{500, 250},
+		})
+		if err != nil {
+			t.Fatalf("Failed creating order: %v", err)
+		}
+		second, err := s.CreateOrder(context.TODO(), model.Order{ProductID: "other", Units: 1, State: "quoted"})
+		if err != nil {
+			t.Fatalf("Failed creating order: %v", err)
+		}
+
+		got, err := s.GetOrder(context.TODO(), first.ID)
+		if err != nil {
+			t.Fatalf("Failed getting order: %v", err)
+		}
+		if !got.CreatedAt.Equal(first.CreatedAt) || !got.UpdatedAt.Equal(first.CreatedAt) {
+			t.Fatalf("Unexpected times: %+v", got)
+		}
+		got.CreatedAt, got.UpdatedAt = time.Time{}, time.Time{}
+		want := model.Order{
+			ID:        first.ID,
+			ProductID: "product",
+			Units:     501,
+			State:     "quoted",
+			Package: model.Package{
+				PackageUnits: []model.PackageUnit{{Size: 250, Amount: 1}, {Size: 500, Amount: 1}},
+				Solver:       "dp",
+				Objective:    "items",
+				TotalCost:    &cost,
+				Currency:     "GBP",
+			},
+			PackageSizes: []int{250, 500},
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Fatalf("Unexpected order: %+v", got)
+		}
+		if got, err = s.GetOrder(context.TODO(), second.ID); err != nil || got.Package.PackageUnits == nil || got.PackageSizes == nil {
+			t.Fatalf("Unexpected order %+v, error %v", got, err)
+		}
+
+		if err = s.UpdateOrderState(context.TODO(), first.ID, "quoted", "confirmed"); err != nil {
+			t.Fatalf("Failed updating order state: %v", err)
+		}
+		if err = s.UpdateOrderState(context.TODO(), first.ID, "quoted", "cancelled"); !errors.Is(err, storage.ErrOrderStateChanged) {
+			t.Fatalf("Expected order state changed, got %v", err)
+		}
+		if err = s.UpdateOrderState(context.TODO(), "missing", "quoted", "cancelled"); !errors.Is(err, storage.ErrOrderNotFound) {
+			t.Fatalf("Expected order not found, got %v", err)
+		}
+		if _, err = s.GetOrder(context.TODO(), "missing"); !errors.Is(err, storage.ErrOrderNotFound) {
+			t.Fatalf("Expected order not found, got %v", err)
+		}
+
+		listIDs := func(filter model.OrderFilter) []string {
+			orders, err := s.ListOrders(context.TODO(), filter)
+			if err != nil {
+				t.Fatalf("Failed listing orders: %v", err)
+			}
+			ids := []string{}
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+			}
+			return ids
+		}
+		if ids := listIDs(model.OrderFilter{}); !slices.Equal(ids, []string{first.ID, second.ID}) {
+			t.Fatalf("Unexpected orders: %v", ids)
+		}
+		if ids := listIDs(model.OrderFilter{State: "quoted"}); !slices.Equal(ids, []string{second.ID}) {
+			t.Fatalf("Unexpected quoted orders: %v", ids)
+		}
+		if ids := listIDs(model.OrderFilter{ProductID: "product", State: "quoted"}); len(ids) != 0 {
+			t.Fatalf("Unexpected orders: %v", ids)
+		}
+	})
+}
+
+func TestStorageIdempotencyKeys(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		ctx := context.TODO()
+		expiredBefore, abandonedBefore := time.Now().Add(-time.Hour), time.Now().Add(-time.Minute)
+
+		stored, err := s.ReserveIdempotencyKey(ctx, "key", "request", expiredBefore, abandonedBefore)
+		if err != nil || stored != nil {
+			t.Fatalf("Expected a free key, got %+v, error %v", stored, err)
+		}
+		if stored, err = s.ReserveIdempotencyKey(ctx, "key", "other", expiredBefore, abandonedBefore); err != nil || stored == nil ||
+			stored.Fingerprint != "request" || stored.Status != 0 {
+			t.Fatalf("Expected the key in flight, got %+v, error %v", stored, err)
+		}
+
+		// only the request that reserved the key saves its response
+		response := model.IdempotentResponse{Key: "key", Fingerprint: "other", Status: 500}
+		if err = s.SaveIdempotentResponse(ctx, response); err != nil {
+			t.Fatalf("Failed saving response: %v", err)
+		}
+		response = model.IdempotentResponse{Key: "key", Fingerprint: "request", Status: 201, ContentType: "application/json",
+			Location: "/v1/orders/1", ETag: `"2"`, Body: []byte(`{}`)}
+		if err = s.SaveIdempotentResponse(ctx, response); err != nil {
+			t.Fatalf("Failed saving response: %v", err)
+		}
+		stored, err = s.ReserveIdempotencyKey(ctx, "key", "request", expiredBefore, abandonedBefore)
+		if err != nil || stored == nil {
+			t.Fatalf("Expected the saved response, got error %v", err)
+		}
+		if stored.Status != 201 || stored.ContentType != "application/json" || stored.Location != "/v1/orders/1" ||
+			stored.ETag != `"2"` || string(stored.Body) != `{}` || stored.CreatedAt.Before(expiredBefore) {
+			t.Fatalf("Unexpected response: %+v", stored)
+		}
+
+		if err = s.DeleteIdempotencyKey(ctx, "key"); err != nil {
+			t.Fatalf("Failed deleting key: %v", err)
+		}
+		if stored, err = s.ReserveIdempotencyKey(ctx, "key", "retry", expiredBefore, abandonedBefore); err != nil || stored != nil {
+			t.Fatalf("Expected a free key, got %+v, error %v", stored, err)
+		}
+		// keys older than the window are free again
+		if stored, err = s.ReserveIdempotencyKey(ctx, "key", "later", time.Now().Add(time.Minute), abandonedBefore); err != nil || stored != nil {
+			t.Fatalf("Expected an expired key, got %+v, error %v", stored, err)
+		}
+
+		// keys still without a response after the lease are free again, the others are kept for the window
+		if err = s.SaveIdempotentResponse(ctx, model.IdempotentResponse{Key: "key", Fingerprint: "later", Status: 201}); err != nil {
+			t.Fatalf("Failed saving response: %v", err)
+		}
+		if stored, err = s.ReserveIdempotencyKey(ctx, "abandoned", "request", expiredBefore, abandonedBefore); err != nil || stored != nil {
+			t.Fatalf("Expected a free key, got %+v, error %v", stored, err)
+		}
+		if stored, err = s.ReserveIdempotencyKey(ctx, "abandoned", "retry", expiredBefore, time.Now().Add(time.Minute)); err != nil || stored != nil {
+			t.Fatalf("Expected an abandoned key, got %+v, error %v", stored, err)
+		}
+		if stored, err = s.ReserveIdempotencyKey(ctx, "key", "later", expiredBefore, time.Now().Add(time.Minute)); err != nil || stored == nil || stored.Status != 201 {
+			t.Fatalf("Expected the saved response, got %+v, error %v", stored, err)
+		}
+	})
+}
+
+// given concurrent stock adjustments - test none is lost and the stock never goes below zero
+func TestStorageConcurrentStockAdjustments(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s storage.Backend) {
+		product := createStorageProduct(t, s, "Product", 250)
+		stock := 10
+		if err := s.SetPackageSizeStock(context.TODO(), product.ID, 250, &stock, 0); err != nil {
+			t.Fatalf("Failed setting stock: %v", err)
+		}
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			negative int
+		)
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := s.AdjustPackageSizeStock(context.TODO(), product.ID, 250, -1, 0)
+				if errors.Is(err, storage.ErrNegativeStock) {
+					mu.Lock()
+					negative++
+					mu.Unlock()
+				} else if err != nil {
+					t.Errorf("Failed adjusting stock: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		got := getStorageProduct(t, s, product.ID)
+		if negative != 10 || !slices.Equal(got.PackageStock, []model.PackageStock{{Size: 250, Available: 0}}) || got.Version != 12 {
+			t.Fatalf("Unexpected product %+v after %d refused adjustments", got, negative)
+		}
+	})
+}
@@ -25,9 +25,16 @@ function App() {
 
   const fetchProducts = async () => {
     try {
-      const res = await fetch(API_URL);
-      const data = await res.json();
-      setProducts(data.Data || []);
+      // the listing is paginated, follow the cursors to load every product
+      const all: Product[] = [];
+      let cursor = '';
+      do {
+        const res = await fetch(`${API_URL}?limit=100${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ''}`);
+        const data = await res.json();
+        all.push(...(data.Data || []));
+        cursor = data.next_cursor || '';
+      } while (cursor);
+      setProducts(all);
     } catch (err) {
       console.error('Error fetching products:', err);
     }